@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// DefaultPageSize is how many users a UserIterator asks for at a time unless told otherwise
+const DefaultPageSize = 50
+
+type ListUsersOptions struct {
+	// Query matches usernames and emails, empty lists everybody
+	Query string
+	// PageSize defaults to DefaultPageSize, the api caps it at 200
+	PageSize int
+}
+
+// UserIterator walks through users a page at a time, use it like sql.Rows:
+//
+//	users := c.AdminListUsers(ctx, client.ListUsersOptions{Query: "example.com"})
+//	for users.Next() {
+//		fmt.Println(users.User().Email)
+//	}
+//	if err := users.Err(); err != nil {
+//		return err
+//	}
+type UserIterator struct {
+	ctx     context.Context
+	client  *Client
+	options ListUsersOptions
+
+	page   []User
+	index  int
+	offset int
+	done   bool
+	err    error
+}
+
+// AdminListUsers searches users, only admins are allowed to
+func (c *Client) AdminListUsers(ctx context.Context, options ListUsersOptions) *UserIterator {
+	if options.PageSize <= 0 {
+		options.PageSize = DefaultPageSize
+	}
+	return &UserIterator{ctx: ctx, client: c, options: options, index: -1}
+}
+
+// Next moves to the next user, fetching the next page when the current one runs out.
+// it returns false at the end or on an error, check Err to tell them apart.
+func (it *UserIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.index++
+	if it.index < len(it.page) {
+		return true
+	}
+	if it.done {
+		return false
+	}
+
+	query := url.Values{
+		"limit":  {strconv.Itoa(it.options.PageSize)},
+		"offset": {strconv.Itoa(it.offset)},
+	}
+	if it.options.Query != "" {
+		query.Set("q", it.options.Query)
+	}
+	var page []User
+	if err := it.client.do(it.ctx, http.MethodGet, "/admin/users", query, nil, &page); err != nil {
+		it.err = err
+		return false
+	}
+
+	it.page = page
+	it.index = 0
+	it.offset += len(page)
+	// a short page is the last one
+	if len(page) < it.options.PageSize {
+		it.done = true
+	}
+	return len(page) > 0
+}
+
+// User is the user Next moved to
+func (it *UserIterator) User() User {
+	return it.page[it.index]
+}
+
+func (it *UserIterator) Err() error {
+	return it.err
+}
+
+// All drains the iterator
+func (it *UserIterator) All() ([]User, error) {
+	var users []User
+	for it.Next() {
+		users = append(users, it.User())
+	}
+	return users, it.Err()
+}
+
+func (c *Client) AdminUser(ctx context.Context, userId int) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/admin/users/%d", userId), nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// AdminSetUserDisabled disables or re-enables an account
+func (c *Client) AdminSetUserDisabled(ctx context.Context, userId int, disabled bool) (*User, error) {
+	action := "enable"
+	if disabled {
+		action = "disable"
+	}
+	var user User
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/admin/users/%d/%s", userId, action), nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) AdminTransferFormOwner(ctx context.Context, formId int, userId int) (*Form, error) {
+	body := struct {
+		UserId int `json:"user_id"`
+	}{userId}
+
+	var form Form
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/admin/forms/%d/owner", formId), nil, body, &form); err != nil {
+		return nil, err
+	}
+	return &form, nil
+}
+
+func (c *Client) AdminDeleteForm(ctx context.Context, formId int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/admin/forms/%d", formId), nil, nil, nil)
+}
+
+func (c *Client) AdminStats(ctx context.Context) (*SystemStats, error) {
+	var stats SystemStats
+	if err := c.do(ctx, http.MethodGet, "/admin/stats", nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
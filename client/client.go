@@ -0,0 +1,141 @@
+// Package client is the Go client for the feedback app api.
+//
+//	c := client.New("https://feedback.example.com")
+//	if _, err := c.Login(ctx, "someone@example.com", "Sup3r$ecret"); err != nil {
+//		return err
+//	}
+//	forms, err := c.MyForms(ctx, nil)
+//
+// Register and Login keep the session token in the client, Token returns it so a service can
+// store it and start later clients with WithToken instead of logging in again. Every error the
+// api answers with is returned as an *APIError, errors.Is works against ErrNotFound and friends.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const authCookieName = "auth_token"
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+
+	mu    sync.RWMutex
+	token string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default http client (30s timeout), give it a cookie jar to
+// authenticate with the session cookie rather than the bearer token
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken authenticates every request with a token from an earlier Register or Login
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// New returns a client for the api served at baseURL, the /api/v1 prefix is added by the client
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		userAgent:  "feedback-app-go-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Token is the session token the client authenticates with, empty before Register or Login
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+func (c *Client) setToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// do sends body as json and decodes the response into out, out can be nil
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body any, out any) error {
+	endpoint := c.baseURL + "/api/v1" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request body :- %w", err)
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.Token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newAPIError(resp)
+	}
+
+	// the session cookie is only marked Secure, pick the token up here so plain http works too
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == authCookieName {
+			c.setToken(cookie.Value)
+		}
+	}
+
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s %s response :- %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// FormDefinition describes a form so it can be kept in git and applied with ApplyForm. it carries
+// yaml tags as well, a definition file can be decoded straight into it with gopkg.in/yaml.v3.
+type FormDefinition struct {
+	// Key is unique across the instance, prefix it with a team name
+	Key         string `json:"key" yaml:"key"`
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description" yaml:"description"`
+	// WorkspaceId is the workspace the form is created in, a form can't be moved later
+	WorkspaceId *int              `json:"workspace_id,omitempty" yaml:"workspace_id,omitempty"`
+	Fields      []FieldDefinition `json:"fields" yaml:"fields"`
+}
+
+type FieldDefinition struct {
+	Key      string   `json:"key" yaml:"key"`
+	Title    string   `json:"title" yaml:"title"`
+	Type     string   `json:"type,omitempty" yaml:"type,omitempty"`
+	Required bool     `json:"required" yaml:"required"`
+	Options  []string `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+const (
+	PlanCreateForm  = "create_form"
+	PlanUpdateForm  = "update_form"
+	PlanCreateField = "create_field"
+	PlanUpdateField = "update_field"
+	PlanDeleteField = "delete_field"
+)
+
+// FormPlan lists the changes applying a definition makes, no changes means the form already matches it
+type FormPlan struct {
+	FormKey string       `json:"form_key"`
+	FormId  *int         `json:"form_id"`
+	Changes []PlanChange `json:"changes"`
+	Applied bool         `json:"applied"`
+	// Form is the form after ApplyForm
+	Form *Form `json:"form"`
+}
+
+type PlanChange struct {
+	Action   string          `json:"action"`
+	FieldKey string          `json:"field_key"`
+	FieldId  *int            `json:"field_id"`
+	Diffs    []AttributeDiff `json:"diffs"`
+}
+
+// AttributeDiff is one attribute a change sets, From is nil for what doesn't exist yet
+type AttributeDiff struct {
+	Attribute string `json:"attribute"`
+	From      any    `json:"from"`
+	To        any    `json:"to"`
+}
+
+// PlanForm shows what ApplyForm would change without changing anything
+func (c *Client) PlanForm(ctx context.Context, definition FormDefinition) (*FormPlan, error) {
+	var plan FormPlan
+	if err := c.do(ctx, http.MethodPost, "/form/plan", nil, definition, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ApplyForm creates the form with the definition's key or brings it in line with the definition,
+// fields missing from the definition are deleted along with their answers
+func (c *Client) ApplyForm(ctx context.Context, definition FormDefinition) (*FormPlan, error) {
+	var plan FormPlan
+	if err := c.do(ctx, http.MethodPost, "/form/apply", nil, definition, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
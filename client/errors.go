@@ -0,0 +1,101 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+// APIError is the problem document the api answers errors with
+type APIError struct {
+	StatusCode int          `json:"status"`
+	Type       string       `json:"type"`
+	Title      string       `json:"title"`
+	Detail     string       `json:"detail"`
+	RequestID  string       `json:"request_id"`
+	Errors     []FieldError `json:"errors"`
+	// RetryAfter is set on rate limited responses
+	RetryAfter time.Duration `json:"-"`
+}
+
+// FieldError is one invalid field of the request, rule names the broken rule when there is one
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("api error %d", e.StatusCode)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	} else if e.Title != "" {
+		msg += ": " + e.Title
+	}
+	if e.RequestID != "" {
+		msg += " (request id " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// Is lets errors.Is(err, client.ErrNotFound) match on the status code
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// FieldError returns the error for field, nil when that field was fine
+func (e *APIError) FieldError(field string) *FieldError {
+	for i := range e.Errors {
+		if e.Errors[i].Field == field {
+			return &e.Errors[i]
+		}
+	}
+	return nil
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	// anything in front of the api (a proxy, a load balancer) may answer with something else than a problem document
+	if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Title == "" {
+		apiErr = &APIError{Title: http.StatusText(resp.StatusCode), Detail: string(body)}
+	}
+	apiErr.StatusCode = resp.StatusCode
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = resp.Header.Get("X-Request-ID")
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
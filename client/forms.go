@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type CreateFormRequest struct {
+	FormTitle       string `json:"form_title"`
+	FormDescription string `json:"form_description"`
+	// WorkspaceId puts the form in a workspace the caller belongs to
+	WorkspaceId *int `json:"workspace_id,omitempty"`
+	// FormKey lets form definitions refer to the form, see ApplyForm
+	FormKey *string `json:"form_key,omitempty"`
+}
+
+type FormFieldRequest struct {
+	FieldTitle string `json:"field_title"`
+	Required   bool   `json:"required"`
+	// FieldType defaults to FieldTypeText for new fields and is left as it is on updates when empty
+	FieldType string `json:"field_type,omitempty"`
+	// Options are the answers a FieldTypeChoice field accepts
+	Options []string `json:"options,omitempty"`
+}
+
+func workspaceQuery(workspaceId *int) url.Values {
+	if workspaceId == nil {
+		return nil
+	}
+	return url.Values{"workspace_id": {strconv.Itoa(*workspaceId)}}
+}
+
+// Forms lists forms ready to be answered, workspaceId narrows them down to one workspace
+func (c *Client) Forms(ctx context.Context, workspaceId *int) ([]Form, error) {
+	var forms []Form
+	if err := c.do(ctx, http.MethodGet, "/form", workspaceQuery(workspaceId), nil, &forms); err != nil {
+		return nil, err
+	}
+	return forms, nil
+}
+
+// MyForms lists the forms the caller authored, workspaceId narrows them down to one workspace
+func (c *Client) MyForms(ctx context.Context, workspaceId *int) ([]Form, error) {
+	var forms []Form
+	if err := c.do(ctx, http.MethodGet, "/form/my-forms", workspaceQuery(workspaceId), nil, &forms); err != nil {
+		return nil, err
+	}
+	return forms, nil
+}
+
+func (c *Client) CreateForm(ctx context.Context, req CreateFormRequest) (*Form, error) {
+	var form Form
+	if err := c.do(ctx, http.MethodPost, "/form", nil, req, &form); err != nil {
+		return nil, err
+	}
+	return &form, nil
+}
+
+// Form returns a form with its fields and author
+func (c *Client) Form(ctx context.Context, formId int) (*Form, error) {
+	var form Form
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/form/%d", formId), nil, nil, &form); err != nil {
+		return nil, err
+	}
+	return &form, nil
+}
+
+// DeleteForm moves a form to the trash, it can be restored until the server purges it
+func (c *Client) DeleteForm(ctx context.Context, formId int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/form/%d", formId), nil, nil, nil)
+}
+
+// Trash lists the deleted forms the caller could restore, the most recently deleted first
+func (c *Client) Trash(ctx context.Context) ([]Form, error) {
+	var forms []Form
+	if err := c.do(ctx, http.MethodGet, "/form/trash", nil, nil, &forms); err != nil {
+		return nil, err
+	}
+	return forms, nil
+}
+
+// RestoreForm takes a form out of the trash along with its fields and responses
+func (c *Client) RestoreForm(ctx context.Context, formId int) (*Form, error) {
+	var form Form
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/form/%d/restore", formId), nil, nil, &form); err != nil {
+		return nil, err
+	}
+	return &form, nil
+}
+
+// CreateFormField adds a field at the end of a form, the form becomes ready once it has a field
+func (c *Client) CreateFormField(ctx context.Context, formId int, req FormFieldRequest) (*FormField, error) {
+	body := struct {
+		FormFieldRequest
+		FormId int `json:"form_id"`
+	}{req, formId}
+
+	var field FormField
+	if err := c.do(ctx, http.MethodPost, "/form/fields", nil, body, &field); err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+func (c *Client) UpdateFormField(ctx context.Context, fieldId int, req FormFieldRequest) (*FormField, error) {
+	var field FormField
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/form/fields/%d", fieldId), nil, req, &field); err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+func (c *Client) DeleteFormField(ctx context.Context, fieldId int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/form/fields/%d", fieldId), nil, nil, nil)
+}
+
+func (c *Client) FormCollaborators(ctx context.Context, formId int) ([]FormCollaborator, error) {
+	var collaborators []FormCollaborator
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/form/%d/collaborators", formId), nil, nil, &collaborators); err != nil {
+		return nil, err
+	}
+	return collaborators, nil
+}
+
+// AddFormCollaborator shares the form with the registered user with email, role is one of the Collaborator roles
+func (c *Client) AddFormCollaborator(ctx context.Context, formId int, email string, role string) (*FormCollaborator, error) {
+	body := struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}{email, role}
+
+	var collaborator FormCollaborator
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/form/%d/collaborators", formId), nil, body, &collaborator); err != nil {
+		return nil, err
+	}
+	return &collaborator, nil
+}
+
+func (c *Client) RemoveFormCollaborator(ctx context.Context, formId int, userId int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/form/%d/collaborators/%d", formId, userId), nil, nil, nil)
+}
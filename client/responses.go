@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Answer is the value given to one field of a form
+type Answer struct {
+	FormFieldId int    `json:"form_field_id"`
+	FieldValue  string `json:"field_value"`
+}
+
+type SubmittedResponse struct {
+	FormResponseId int             `json:"form_response_id"`
+	ResponseFields []ResponseField `json:"response_fields"`
+}
+
+// SubmitResponse answers a form, every required field needs an answer
+func (c *Client) SubmitResponse(ctx context.Context, formId int, answers []Answer) (*SubmittedResponse, error) {
+	body := struct {
+		FormId         int      `json:"form_id"`
+		ResponseFields []Answer `json:"response_fields"`
+	}{formId, answers}
+
+	var submitted SubmittedResponse
+	if err := c.do(ctx, http.MethodPost, "/form-responses", nil, body, &submitted); err != nil {
+		return nil, err
+	}
+	return &submitted, nil
+}
+
+// MyResponses lists the responses the caller submitted
+func (c *Client) MyResponses(ctx context.Context) ([]FormResponse, error) {
+	var responses []FormResponse
+	if err := c.do(ctx, http.MethodGet, "/form-responses", nil, nil, &responses); err != nil {
+		return nil, err
+	}
+	return responses, nil
+}
+
+// FormResponses lists the responses to a form, the caller has to own or collaborate on it
+func (c *Client) FormResponses(ctx context.Context, formId int) ([]FormResponse, error) {
+	var responses []FormResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/form-responses/%d", formId), nil, nil, &responses); err != nil {
+		return nil, err
+	}
+	return responses, nil
+}
+
+// ResponseFields returns the answers of one response
+func (c *Client) ResponseFields(ctx context.Context, formResponseId int) ([]ResponseField, error) {
+	var fields []ResponseField
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/form-responses/response-fields/%d", formResponseId), nil, nil, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// EditResponse replaces the answers of the caller's response, answers left out are removed.
+// the form has to allow edits and its edit window must still be open
+func (c *Client) EditResponse(ctx context.Context, formResponseId int, answers []Answer) (*SubmittedResponse, error) {
+	body := struct {
+		ResponseFields []Answer `json:"response_fields"`
+	}{answers}
+
+	var edited SubmittedResponse
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/form-responses/%d", formResponseId), nil, body, &edited); err != nil {
+		return nil, err
+	}
+	return &edited, nil
+}
+
+// WithdrawResponse deletes the caller's response, the form has to allow it
+func (c *Client) WithdrawResponse(ctx context.Context, formResponseId int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/form-responses/%d", formResponseId), nil, nil, nil)
+}
+
+// ResponseEdits returns the answers a response had before it was edited, oldest first
+func (c *Client) ResponseEdits(ctx context.Context, formResponseId int) ([]ResponseFieldEdit, error) {
+	var edits []ResponseFieldEdit
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/form-responses/response-fields/%d/edits", formResponseId), nil, nil, &edits); err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+func (c *Client) ResponseSettings(ctx context.Context, formId int) (*ResponseSettings, error) {
+	var settings ResponseSettings
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/form/%d/response-settings", formId), nil, nil, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SetResponseSettings decides what respondents can do with their responses, the caller has to own the form.
+// editWindow is in seconds, nil keeps responses editable for good
+func (c *Client) SetResponseSettings(ctx context.Context, formId int, allowEdits bool, editWindow *int, allowWithdrawal bool) (*ResponseSettings, error) {
+	body := struct {
+		AllowEdits      bool `json:"allow_edits"`
+		EditWindow      *int `json:"edit_window"`
+		AllowWithdrawal bool `json:"allow_withdrawal"`
+	}{allowEdits, editWindow, allowWithdrawal}
+
+	var settings ResponseSettings
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/form/%d/response-settings", formId), nil, body, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveDraft replaces the caller's draft for a form, required fields can be left out until it is submitted
+func (c *Client) SaveDraft(ctx context.Context, formId int, answers []Answer) (*ResponseDraft, error) {
+	if answers == nil {
+		answers = []Answer{}
+	}
+	body := struct {
+		ResponseFields []Answer `json:"response_fields"`
+	}{answers}
+
+	var draft ResponseDraft
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/form-responses/drafts/%d", formId), nil, body, &draft); err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// Draft returns the caller's draft for a form, saved from this device or any other
+func (c *Client) Draft(ctx context.Context, formId int) (*ResponseDraft, error) {
+	var draft ResponseDraft
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/form-responses/drafts/%d", formId), nil, nil, &draft); err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// Drafts lists the caller's drafts, the most recently saved first
+func (c *Client) Drafts(ctx context.Context) ([]ResponseDraft, error) {
+	var drafts []ResponseDraft
+	if err := c.do(ctx, http.MethodGet, "/form-responses/drafts", nil, nil, &drafts); err != nil {
+		return nil, err
+	}
+	return drafts, nil
+}
+
+func (c *Client) DiscardDraft(ctx context.Context, formId int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/form-responses/drafts/%d", formId), nil, nil, nil)
+}
+
+// SubmittedDraft is the response a draft was turned into, DroppedAnswers are the draft's answers to fields
+// the form no longer has, they weren't submitted
+type SubmittedDraft struct {
+	SubmittedResponse
+	DroppedAnswers []Answer `json:"dropped_answers"`
+}
+
+// SubmitDraft turns the caller's draft for a form into a response, every required field needs an answer
+func (c *Client) SubmitDraft(ctx context.Context, formId int) (*SubmittedDraft, error) {
+	var submitted SubmittedDraft
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/form-responses/drafts/%d/submit", formId), nil, nil, &submitted); err != nil {
+		return nil, err
+	}
+	return &submitted, nil
+}
+
+// ExportedResponse is one response with its answers in the order of the form's fields
+type ExportedResponse struct {
+	Id          int              `json:"id"`
+	Respondent  string           `json:"respondent"`
+	SubmittedAt string           `json:"submitted_at"`
+	Answers     []ExportedAnswer `json:"answers"`
+}
+
+type ExportedAnswer struct {
+	FormFieldId int    `json:"form_field_id"`
+	FieldTitle  string `json:"field_title"`
+	FieldValue  string `json:"field_value"`
+}
+
+// ExportResponses returns the form along with every response to it. the api has no export endpoint,
+// the export is put together from the form, its responses and their answers so it costs one request
+// per response.
+func (c *Client) ExportResponses(ctx context.Context, formId int) (*Form, []ExportedResponse, error) {
+	form, err := c.Form(ctx, formId)
+	if err != nil {
+		return nil, nil, err
+	}
+	responses, err := c.FormResponses(ctx, formId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exported := make([]ExportedResponse, 0, len(responses))
+	for _, response := range responses {
+		exportedResponse, err := c.ExportResponse(ctx, form, response)
+		if err != nil {
+			return nil, nil, err
+		}
+		exported = append(exported, *exportedResponse)
+	}
+	return form, exported, nil
+}
+
+// ExportResponse fetches the answers of one response to form
+func (c *Client) ExportResponse(ctx context.Context, form *Form, response FormResponse) (*ExportedResponse, error) {
+	answers, err := c.ResponseFields(ctx, response.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	byField := make(map[int]string, len(answers))
+	for _, answer := range answers {
+		byField[answer.FormFieldId] = answer.FieldValue
+	}
+
+	respondent := strconv.Itoa(response.RespondentId)
+	if response.Respondent != nil {
+		respondent = response.Respondent.Email
+	}
+	exported := ExportedResponse{Id: response.Id, Respondent: respondent, SubmittedAt: response.SubmittedAt}
+	for _, field := range form.FormFields {
+		exported.Answers = append(exported.Answers, ExportedAnswer{field.Id, field.FieldTitle, byField[field.Id]})
+	}
+	return &exported, nil
+}
+
+// ExportResponsesCSV writes every response to a form as csv, one row per response and one column
+// per field, see ExportResponses for what it costs
+func (c *Client) ExportResponsesCSV(ctx context.Context, formId int, w io.Writer) error {
+	form, responses, err := c.ExportResponses(ctx, formId)
+	if err != nil {
+		return err
+	}
+
+	out := csv.NewWriter(w)
+	header := []string{"response_id", "respondent", "submitted_at"}
+	for _, field := range form.FormFields {
+		header = append(header, field.FieldTitle)
+	}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for _, response := range responses {
+		row := []string{strconv.Itoa(response.Id), response.Respondent, response.SubmittedAt}
+		for _, answer := range response.Answers {
+			row = append(row, answer.FieldValue)
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
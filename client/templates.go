@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// CopyFormRequest names the new form and picks its workspace, both are optional: the title defaults
+// to the template's title or "Copy of" the form's title, the workspace to the source's when the caller is a member of it
+type CopyFormRequest struct {
+	FormTitle   *string `json:"form_title,omitempty"`
+	WorkspaceId *int    `json:"workspace_id,omitempty"`
+}
+
+// DuplicateForm copies a form the caller can view along with its fields, responses aren't copied
+func (c *Client) DuplicateForm(ctx context.Context, formId int, req CopyFormRequest) (*Form, error) {
+	var form Form
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/form/%d/duplicate", formId), nil, req, &form); err != nil {
+		return nil, err
+	}
+	return &form, nil
+}
+
+// Templates lists the templates the caller can create forms from
+func (c *Client) Templates(ctx context.Context) ([]Form, error) {
+	var templates []Form
+	if err := c.do(ctx, http.MethodGet, "/form/templates", nil, nil, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// InstantiateTemplate creates a form with a copy of the template's fields
+func (c *Client) InstantiateTemplate(ctx context.Context, templateId int, req CopyFormRequest) (*Form, error) {
+	var form Form
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/form/templates/%d/instantiate", templateId), nil, req, &form); err != nil {
+		return nil, err
+	}
+	return &form, nil
+}
+
+// SetFormTemplate makes a form the caller owns a template, visibility is one of TemplatePrivate,
+// TemplateWorkspace or TemplatePublic
+func (c *Client) SetFormTemplate(ctx context.Context, formId int, visibility string) (*Form, error) {
+	body := struct {
+		Visibility string `json:"visibility"`
+	}{visibility}
+
+	var form Form
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/form/%d/template", formId), nil, body, &form); err != nil {
+		return nil, err
+	}
+	return &form, nil
+}
+
+// UnsetFormTemplate makes the template a regular form again
+func (c *Client) UnsetFormTemplate(ctx context.Context, formId int) (*Form, error) {
+	var form Form
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/form/%d/template", formId), nil, nil, &form); err != nil {
+		return nil, err
+	}
+	return &form, nil
+}
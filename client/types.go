@@ -0,0 +1,167 @@
+package client
+
+// the types mirror the api's json bodies, see /api/v1/openapi.json
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+
+	CollaboratorViewer = "viewer"
+	CollaboratorEditor = "editor"
+	CollaboratorOwner  = "owner"
+
+	WorkspaceRoleMember = "member"
+	WorkspaceRoleAdmin  = "admin"
+	WorkspaceRoleOwner  = "owner"
+
+	FieldTypeText     = "text"
+	FieldTypeLongText = "long_text"
+	FieldTypeNumber   = "number"
+	FieldTypeEmail    = "email"
+	FieldTypeDate     = "date"
+	FieldTypeChoice   = "choice"
+
+	TemplatePrivate   = "private"
+	TemplateWorkspace = "workspace"
+	TemplatePublic    = "public"
+)
+
+type User struct {
+	Id         int     `json:"id"`
+	Email      string  `json:"email"`
+	Username   string  `json:"username"`
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  *string `json:"updated_at"`
+	Role       string  `json:"role"`
+	DisabledAt *string `json:"disabled_at"`
+}
+
+type LoginLockout struct {
+	Id             int    `json:"id"`
+	UserId         int    `json:"user_id"`
+	IpAddress      string `json:"ip_address"`
+	FailedAttempts int    `json:"failed_attempts"`
+	LockedUntil    string `json:"locked_until"`
+	CreatedAt      string `json:"created_at"`
+}
+
+// Form.TemplateVisibility is nil unless the form is a template, see SetFormTemplate.
+// Form.PublishedVersion is nil until the form is published, see PublishForm.
+// Form.DeletedAt is only set for forms in the trash, see Trash
+type Form struct {
+	Id                 int         `json:"id"`
+	FormTitle          string      `json:"form_title"`
+	FormDescription    string      `json:"form_description"`
+	IsReady            bool        `json:"is_ready"`
+	UserId             int         `json:"user_id"`
+	CreatedAt          string      `json:"created_at"`
+	WorkspaceId        *int        `json:"workspace_id"`
+	FormKey            *string     `json:"form_key"`
+	TemplateVisibility *string     `json:"template_visibility"`
+	PublishedVersion   *int        `json:"published_version"`
+	DeletedAt          *string     `json:"deleted_at"`
+	User               *User       `json:"user"`
+	FormFields         []FormField `json:"form_fields"`
+}
+
+type FormField struct {
+	Id         int      `json:"id"`
+	FieldKey   *string  `json:"field_key"`
+	FieldTitle string   `json:"field_title"`
+	FieldType  string   `json:"field_type"`
+	Required   bool     `json:"required"`
+	Position   int      `json:"position"`
+	Options    []string `json:"options"`
+	FormId     int      `json:"form_id"`
+}
+
+type FormCollaborator struct {
+	FormId    int    `json:"form_id"`
+	UserId    int    `json:"user_id"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+	User      *User  `json:"user"`
+}
+
+type Workspace struct {
+	Id            int               `json:"id"`
+	WorkspaceName string            `json:"workspace_name"`
+	CreatedBy     *int              `json:"created_by"`
+	CreatedAt     string            `json:"created_at"`
+	Members       []WorkspaceMember `json:"members,omitempty"`
+}
+
+// WorkspaceMembership is a workspace along with the caller's role in it
+type WorkspaceMembership struct {
+	Workspace
+	Role string `json:"role"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceId int    `json:"workspace_id"`
+	UserId      int    `json:"user_id"`
+	Role        string `json:"role"`
+	CreatedAt   string `json:"created_at"`
+	User        *User  `json:"user"`
+}
+
+// FormResponse.FormVersion is the version of the form the response was submitted against,
+// UpdatedAt is when the respondent last edited it
+type FormResponse struct {
+	Id           int     `json:"id"`
+	FormId       int     `json:"form_id"`
+	RespondentId int     `json:"respondent_id"`
+	FormVersion  *int    `json:"form_version"`
+	SubmittedAt  string  `json:"submitted_at"`
+	UpdatedAt    *string `json:"updated_at"`
+	Respondent   *User   `json:"respondent"`
+	Form         *Form   `json:"form"`
+}
+
+type ResponseField struct {
+	Id             int       `json:"id"`
+	FieldValue     string    `json:"field_value"`
+	FormResponseId int       `json:"form_response_id"`
+	FormFieldId    int       `json:"form_field_id"`
+	FormField      FormField `json:"form_field"`
+}
+
+// ResponseFieldEdit is an answer as it was before its respondent edited the response
+type ResponseFieldEdit struct {
+	Id             int    `json:"id"`
+	FormResponseId int    `json:"form_response_id"`
+	FormFieldId    int    `json:"form_field_id"`
+	FieldValue     string `json:"field_value"`
+	EditedAt       string `json:"edited_at"`
+}
+
+// ResponseSettings.EditWindow is how many seconds after submitting a response stays editable, nil for no limit
+type ResponseSettings struct {
+	FormId          int     `json:"form_id"`
+	AllowEdits      bool    `json:"allow_edits"`
+	EditWindow      *int    `json:"edit_window"`
+	AllowWithdrawal bool    `json:"allow_withdrawal"`
+	UpdatedAt       *string `json:"updated_at"`
+}
+
+// ResponseDraft holds answers saved without submitting them, ExpiresAt is nil when the server keeps drafts for good
+type ResponseDraft struct {
+	Id             int      `json:"id"`
+	FormId         int      `json:"form_id"`
+	RespondentId   int      `json:"respondent_id"`
+	ResponseFields []Answer `json:"response_fields"`
+	CreatedAt      string   `json:"created_at"`
+	UpdatedAt      string   `json:"updated_at"`
+	ExpiresAt      *string  `json:"expires_at"`
+}
+
+type SystemStats struct {
+	Users            int `json:"users"`
+	Admins           int `json:"admins"`
+	DisabledUsers    int `json:"disabled_users"`
+	Forms            int `json:"forms"`
+	ReadyForms       int `json:"ready_forms"`
+	FormResponses    int `json:"form_responses"`
+	FormResponses24h int `json:"form_responses_24h"`
+	LoginLockouts24h int `json:"login_lockouts_24h"`
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+type userEnvelope struct {
+	Message string `json:"message"`
+	User    User   `json:"user"`
+}
+
+// Register creates an account, the client is logged in as the new user afterwards
+func (c *Client) Register(ctx context.Context, username string, email string, password string) (*User, error) {
+	body := struct {
+		Email    string `json:"email"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{email, username, password}
+
+	var envelope userEnvelope
+	if err := c.do(ctx, http.MethodPost, "/user/register", nil, body, &envelope); err != nil {
+		return nil, err
+	}
+	return &envelope.User, nil
+}
+
+// Login starts a session, later requests are made as that user
+func (c *Client) Login(ctx context.Context, email string, password string) (*User, error) {
+	body := struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}{email, password}
+
+	var envelope userEnvelope
+	if err := c.do(ctx, http.MethodPost, "/user/login", nil, body, &envelope); err != nil {
+		return nil, err
+	}
+	return &envelope.User, nil
+}
+
+// Logout ends the session and forgets the token
+func (c *Client) Logout(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodGet, "/user/logout", nil, nil, nil); err != nil {
+		return err
+	}
+	c.setToken("")
+	return nil
+}
+
+// Me is the user the client is logged in as
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodGet, "/user/authenticated", nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// LoginLockouts lists the times the account got locked after failed logins
+func (c *Client) LoginLockouts(ctx context.Context) ([]LoginLockout, error) {
+	var lockouts []LoginLockout
+	if err := c.do(ctx, http.MethodGet, "/user/lockouts", nil, nil, &lockouts); err != nil {
+		return nil, err
+	}
+	return lockouts, nil
+}
+
+// UpdateProfile changes the username and email, empty values are left as they are
+func (c *Client) UpdateProfile(ctx context.Context, username string, email string) (*User, error) {
+	body := struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+	}{username, email}
+
+	var user User
+	if err := c.do(ctx, http.MethodPut, "/user/profile", nil, body, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) ChangePassword(ctx context.Context, currentPassword string, newPassword string) error {
+	body := struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}{currentPassword, newPassword}
+
+	return c.do(ctx, http.MethodPut, "/user/password", nil, body, nil)
+}
+
+// DeleteAccount deletes the logged in account along with its forms, or hands the forms
+// over to the user with transferToEmail when it isn't empty
+func (c *Client) DeleteAccount(ctx context.Context, password string, transferToEmail string) error {
+	body := struct {
+		Password        string `json:"password"`
+		Forms           string `json:"forms"`
+		TransferToEmail string `json:"transfer_to_email,omitempty"`
+	}{Password: password, Forms: "delete", TransferToEmail: transferToEmail}
+	if transferToEmail != "" {
+		body.Forms = "transfer"
+	}
+
+	if err := c.do(ctx, http.MethodDelete, "/user", nil, body, nil); err != nil {
+		return err
+	}
+	c.setToken("")
+	return nil
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// FormVersion is a published snapshot of a form's title, description and fields, it never changes
+type FormVersion struct {
+	Id              int         `json:"id"`
+	FormId          int         `json:"form_id"`
+	Version         int         `json:"version"`
+	FormTitle       string      `json:"form_title"`
+	FormDescription string      `json:"form_description"`
+	FormFields      []FormField `json:"form_fields"`
+	PublishedBy     *int        `json:"published_by"`
+	PublishedAt     string      `json:"published_at"`
+}
+
+// PublishForm publishes the form's current fields as its next version, new responses answer it
+func (c *Client) PublishForm(ctx context.Context, formId int) (*FormVersion, error) {
+	var version FormVersion
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/form/%d/publish", formId), nil, nil, &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// FormVersions lists the published versions of a form, oldest first
+func (c *Client) FormVersions(ctx context.Context, formId int) ([]FormVersion, error) {
+	var versions []FormVersion
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/form/%d/versions", formId), nil, nil, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// FormVersion returns one published version of a form, what the respondents of that version saw
+func (c *Client) FormVersion(ctx context.Context, formId int, version int) (*FormVersion, error) {
+	var formVersion FormVersion
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/form/%d/versions/%d", formId, version), nil, nil, &formVersion); err != nil {
+		return nil, err
+	}
+	return &formVersion, nil
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// CreateWorkspace creates a workspace owned by the caller
+func (c *Client) CreateWorkspace(ctx context.Context, workspaceName string) (*Workspace, error) {
+	body := struct {
+		WorkspaceName string `json:"workspace_name"`
+	}{workspaceName}
+
+	var workspace Workspace
+	if err := c.do(ctx, http.MethodPost, "/workspaces", nil, body, &workspace); err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// Workspaces lists the workspaces the caller belongs to
+func (c *Client) Workspaces(ctx context.Context) ([]WorkspaceMembership, error) {
+	var workspaces []WorkspaceMembership
+	if err := c.do(ctx, http.MethodGet, "/workspaces", nil, nil, &workspaces); err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// Workspace returns a workspace with its members
+func (c *Client) Workspace(ctx context.Context, workspaceId int) (*Workspace, error) {
+	var workspace Workspace
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/workspaces/%d", workspaceId), nil, nil, &workspace); err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// AddWorkspaceMember adds the registered user with email, an empty role means WorkspaceRoleMember
+func (c *Client) AddWorkspaceMember(ctx context.Context, workspaceId int, email string, role string) (*WorkspaceMember, error) {
+	body := struct {
+		Email string `json:"email"`
+		Role  string `json:"role,omitempty"`
+	}{email, role}
+
+	var member WorkspaceMember
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/workspaces/%d/members", workspaceId), nil, body, &member); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (c *Client) RemoveWorkspaceMember(ctx context.Context, workspaceId int, userId int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/workspaces/%d/members/%d", workspaceId, userId), nil, nil, nil)
+}
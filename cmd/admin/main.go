@@ -0,0 +1,53 @@
+// admin bootstraps administrators, it promotes (or demotes) an already registered user.
+//
+//	DB_CONN=... go run ./cmd/admin -email someone@example.com
+//	DB_CONN=... go run ./cmd/admin -email someone@example.com -role user
+//	DB_CONN=sqlite://feedback.db go run ./cmd/admin -email someone@example.com
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the registered user to update")
+	role := flag.String("role", storage.RoleAdmin, "role to give the user (user or admin)")
+	flag.Parse()
+
+	if strings.TrimSpace(*email) == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *role != storage.RoleAdmin && *role != storage.RoleUser {
+		log.Fatalf("invalid role %q, should be either %s or %s", *role, storage.RoleUser, storage.RoleAdmin)
+	}
+
+	db, _, err := storage.Open(os.Getenv("DB_CONN"))
+	if err != nil {
+		log.Fatalf("DB CONNECTION FAILED:- %v", err.Error())
+	}
+	defer db.Close()
+
+	store := storage.NewStorage(db, 0)
+	ctx := context.Background()
+	user, err := store.Users.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(*email)))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Fatalf("no user registered with email %s", *email)
+		}
+		log.Fatalf("failed to fetch user :- %v", err.Error())
+	}
+
+	if _, err = store.Users.SetUserRole(ctx, user.Id, *role); err != nil {
+		log.Fatalf("failed to update role :- %v", err.Error())
+	}
+
+	log.Printf("user %s (id %d) is now %s", user.Email, user.Id, *role)
+}
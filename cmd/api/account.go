@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dhruv15803/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type UpdateProfileRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// Forms is either "delete" (authored forms are deleted with the account)
+// or "transfer" (authored forms are handed over to the user with TransferToEmail)
+type DeleteAccountRequest struct {
+	Password        string `json:"password"`
+	Forms           string `json:"forms"`
+	TransferToEmail string `json:"transfer_to_email"`
+}
+
+func (s *APIServer) updateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	var payload UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.storage.Users.GetUserById(r.Context(), userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// empty fields are left as they are
+	username := strings.TrimSpace(payload.Username)
+	email := strings.ToLower(strings.TrimSpace(payload.Email))
+	if username == "" {
+		username = user.Username
+	}
+	if email == "" {
+		email = user.Email
+	}
+
+	if ok := s.validateEmail(email); !ok {
+		s.writeFieldProblem(w, r, "email", "Invalid email")
+		return
+	}
+
+	// the new username or email can't belong to anybody else
+	users, err := s.storage.Users.GetUsersByUsernameOrEmail(r.Context(), username, email)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	for _, other := range users {
+		if other.Id != user.Id {
+			s.writeProblem(w, r, "username or email already taken", http.StatusBadRequest)
+			return
+		}
+	}
+
+	updatedUser, err := s.storage.Users.UpdateUserProfile(r.Context(), user.Id, username, email)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err = s.writeJSON(w, updatedUser, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	var payload ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	currentPassword := strings.TrimSpace(payload.CurrentPassword)
+	newPassword := strings.TrimSpace(payload.NewPassword)
+
+	if currentPassword == "" || newPassword == "" {
+		var fieldErrors []FieldError
+		if currentPassword == "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: "current_password", Message: "current_password is required"})
+		}
+		if newPassword == "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: "new_password", Message: "new_password is required"})
+		}
+		s.writeValidationProblem(w, r, "current_password and new_password are required fields", fieldErrors...)
+		return
+	}
+
+	user, err := s.storage.Users.GetUserById(r.Context(), userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		s.writeProblem(w, r, "current password is incorrect", http.StatusUnauthorized)
+		return
+	}
+
+	if violations := s.config.Password.Validate(newPassword, user.Username, user.Email); len(violations) > 0 {
+		s.writePasswordPolicyError(w, r, violations)
+		return
+	}
+
+	hashedByte, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// changing the password signs out every session, a stolen token stops working here
+	if err = s.storage.Users.UpdateUserPassword(r.Context(), user.Id, string(hashedByte)); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// except the session that made the change, it gets a token for the new version
+	user, err = s.storage.Users.GetUserById(r.Context(), user.Id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	tokenString, err := s.GenerateJWT(user.Id, user.TokenVersion)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+	cookie := http.Cookie{
+		Name:     "auth_token",
+		Value:    tokenString,
+		Path:     "/",
+		Expires:  time.Now().Add(time.Hour * 48),
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+		Secure:   true,
+	}
+	http.SetCookie(w, &cookie)
+
+	type Envelope struct {
+		Message string `json:"message"`
+	}
+	if err = s.writeJSON(w, Envelope{Message: "password changed successfully"}, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) deleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	var payload DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	formsAction := strings.ToLower(strings.TrimSpace(payload.Forms))
+	if formsAction != "delete" && formsAction != "transfer" {
+		s.writeFieldProblem(w, r, "forms", "forms should be either delete or transfer")
+		return
+	}
+
+	user, err := s.storage.Users.GetUserById(r.Context(), userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// deleting an account is irreversible so the password is asked for again
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(strings.TrimSpace(payload.Password))); err != nil {
+		s.writeProblem(w, r, "password is incorrect", http.StatusUnauthorized)
+		return
+	}
+
+	if formsAction == "delete" {
+		err = s.storage.Users.DeleteUserById(r.Context(), user.Id)
+	} else {
+		transferToEmail := strings.ToLower(strings.TrimSpace(payload.TransferToEmail))
+		if transferToEmail == "" {
+			s.writeFieldProblem(w, r, "transfer_to_email", "transfer_to_email is required when transferring forms")
+			return
+		}
+		var newOwner *storage.User
+		newOwner, err = s.storage.Users.GetUserByEmail(r.Context(), transferToEmail)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				s.writeProblem(w, r, "user to transfer forms to not found", http.StatusNotFound)
+				return
+			}
+			s.writeError(w, r, err)
+			return
+		}
+		if newOwner.Id == user.Id {
+			s.writeFieldProblem(w, r, "transfer_to_email", "cannot transfer forms to the account being deleted")
+			return
+		}
+		if newOwner.DisabledAt != nil {
+			s.writeFieldProblem(w, r, "transfer_to_email", "cannot transfer forms to a disabled account")
+			return
+		}
+		err = s.storage.Users.DeleteUserAndTransferForms(r.Context(), user.Id, newOwner.Id)
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// the account is gone, so is the session
+	cookie := http.Cookie{
+		Name:     "auth_token",
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+	}
+	http.SetCookie(w, &cookie)
+
+	type Envelope struct {
+		Message string `json:"message"`
+	}
+	if err = s.writeJSON(w, Envelope{Message: "account deleted"}, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+type TransferFormOwnerRequest struct {
+	UserId int `json:"user_id"`
+}
+
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 200
+)
+
+func (s *APIServer) adminListUsers(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	limit := defaultAdminPageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			s.writeFieldProblem(w, r, "limit", "invalid limit")
+			return
+		}
+		limit = min(parsed, maxAdminPageSize)
+	}
+	offset := 0
+	if v := r.URL.Query().Get("offset"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			s.writeFieldProblem(w, r, "offset", "invalid offset")
+			return
+		}
+		offset = parsed
+	}
+
+	users, err := s.storage.Users.SearchUsers(r.Context(), query, limit, offset)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err = s.writeJSON(w, users, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) adminGetUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseInt(r.PathValue("userId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.storage.Users.GetUserById(r.Context(), int(userId))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("user with id %d not found", userId), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err = s.writeJSON(w, user, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) adminDisableUser(w http.ResponseWriter, r *http.Request) {
+	s.adminSetUserDisabled(w, r, true)
+}
+
+func (s *APIServer) adminEnableUser(w http.ResponseWriter, r *http.Request) {
+	s.adminSetUserDisabled(w, r, false)
+}
+
+func (s *APIServer) adminSetUserDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	adminId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := strconv.ParseInt(r.PathValue("userId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	// an admin disabling themselves would lock the console out
+	if int(userId) == adminId {
+		s.writeProblem(w, r, "admins cannot disable or enable their own account", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.storage.Users.SetUserDisabled(r.Context(), int(userId), disabled)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("user with id %d not found", userId), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err = s.writeJSON(w, user, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) adminTransferFormOwner(w http.ResponseWriter, r *http.Request) {
+	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	var payload TransferFormOwnerRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	newOwner, err := s.storage.Users.GetUserById(r.Context(), payload.UserId)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("user with id %d not found", payload.UserId), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	form, err := s.storage.Forms.UpdateFormOwner(r.Context(), int(formId), newOwner.Id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("form with id %d not found", formId), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err = s.writeJSON(w, form, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) adminDeleteForm(w http.ResponseWriter, r *http.Request) {
+	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	form, err := s.storage.Forms.GetFormById(r.Context(), int(formId))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("form with id %d not found", formId), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err = s.storage.Forms.DeleteFormById(r.Context(), form.Id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	type Envelope struct {
+		Message string `json:"message"`
+	}
+	if err = s.writeJSON(w, Envelope{Message: fmt.Sprintf("form with id %d moved to the trash", form.Id)}, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) adminStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.storage.Stats.GetSystemStats(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err = s.writeJSON(w, stats, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
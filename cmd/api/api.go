@@ -1,44 +1,164 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
+	"sync/atomic"
 	"time"
 
+	"github.com/dhruv15803/internal/config"
+	"github.com/dhruv15803/internal/migrate"
 	"github.com/dhruv15803/internal/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// shutdownTimeout bounds how long in-flight requests get to finish once SIGTERM arrives
+const shutdownTimeout = 30 * time.Second
+
 type APIServer struct {
-	addr    string
+	config  *config.Config
 	storage *storage.Storage
+	metrics *metrics
+	// openAPIJSON is the embedded openapi.yaml converted once at startup
+	openAPIJSON []byte
+
+	// db and migrator are only used by /readyz, they are nil for the in-memory backend
+	db           *sql.DB
+	migrator     *migrate.Migrator
+	shuttingDown atomic.Bool
+
+	accountThrottle *loginThrottle
+	ipThrottle      *loginThrottle
+	// compared against when a login email doesn't exist so both paths cost one bcrypt comparison
+	dummyPasswordHash []byte
 }
 
-func NewAPIServer(addr string, storage *storage.Storage) *APIServer {
+// db is the connection pool behind storage and migrator knows its schema, both are nil for the in-memory backend
+func NewAPIServer(cfg *config.Config, storage *storage.Storage, db *sql.DB, migrator *migrate.Migrator) (*APIServer, error) {
+	dummyPasswordHash, err := bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	openAPIJSON, err := loadOpenAPI()
+	if err != nil {
+		return nil, err
+	}
+	metrics := newMetrics()
+	if db != nil {
+		metrics.registerDB(db, metricsNamespace)
+	}
 	return &APIServer{
-		addr:    addr,
-		storage: storage,
+		config:            cfg,
+		storage:           storage,
+		metrics:           metrics,
+		openAPIJSON:       openAPIJSON,
+		db:                db,
+		migrator:          migrator,
+		accountThrottle:   newLoginThrottle(cfg.LoginThrottle.Account),
+		ipThrottle:        newLoginThrottle(cfg.LoginThrottle.IP),
+		dummyPasswordHash: dummyPasswordHash,
+	}, nil
+}
+
+// Run serves until ctx is cancelled, then fails readiness for the configured shutdown delay, stops accepting
+// connections and waits up to shutdownTimeout for the requests already in flight to finish
+func (s *APIServer) Run(ctx context.Context) error {
+	router := s.routes()
+	if s.config.TrashRetention > 0 {
+		go s.purgeTrash(ctx)
+	}
+	if s.config.DraftExpiry > 0 {
+		go s.purgeDrafts(ctx)
+	}
+	// a drifted spec is worth shouting about but not worth refusing to start over
+	if mismatches, err := openAPIMismatches(router, s.openAPIJSON); err == nil {
+		for _, mismatch := range mismatches {
+			slog.Warn("openapi spec mismatch", slog.String("route", mismatch))
+		}
+	}
+
+	server := http.Server{
+		Addr:         s.config.Addr,
+		Handler:      router,
+		ReadTimeout:  time.Second * 15,
+		WriteTimeout: time.Second * 15,
+		ErrorLog:     slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server listening", slog.String("addr", s.config.Addr))
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	// readyz fails from here on, keep serving long enough for the load balancer to notice and stop routing here
+	s.shuttingDown.Store(true)
+	if s.config.ShutdownDelay > 0 {
+		slog.Info("shutting down, waiting for the load balancer to stop sending traffic", slog.Duration("delay", s.config.ShutdownDelay))
+		time.Sleep(s.config.ShutdownDelay)
 	}
+	slog.Info("shutting down, draining in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
-func (s *APIServer) Run() error {
+func (s *APIServer) routes() *chi.Mux {
 	router := chi.NewRouter()
 
 	corsOptions := cors.Options{
-		AllowedOrigins:   []string{os.Getenv("CLIENT_URL")}, // Add your frontend URLs here
+		AllowedOrigins:   []string{s.config.ClientURL}, // Add your frontend URLs here
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", requestIDHeader},
+		ExposedHeaders:   []string{"Link", requestIDHeader},
 		AllowCredentials: true, // Enable cookies or credentials if needed
 		MaxAge:           300,  // Cache preflight requests for 5 minutes
 	}
 
+	// behind trusted proxies the client ip is taken from X-Forwarded-For before anything logs or throttles on it
+	router.Use(realIPMiddleware(s.config.TrustedProxyPrefixes()))
+	// every request gets an id (or keeps the X-Request-ID it came with), log lines and error responses carry it
+	router.Use(requestIDMiddleware)
+	router.Use(accessLogMiddleware)
+	router.Use(s.metrics.middleware)
+	router.Use(s.recoverMiddleware)
 	router.Use(cors.Handler(corsOptions))
 
+	// unknown routes get a problem document like every other error
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		s.writeProblem(w, r, fmt.Sprintf("no route for %s", r.URL.Path), http.StatusNotFound)
+	})
+	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		s.writeProblem(w, r, fmt.Sprintf("method %s is not allowed on %s", r.Method, r.URL.Path), http.StatusMethodNotAllowed)
+	})
+
+	router.Method(http.MethodGet, "/metrics", s.metrics.handler())
+	router.Get("/healthz", s.healthzHandler)
+	router.Get("/readyz", s.readyzHandler)
+
 	router.Route("/api/v1", func(r chi.Router) {
 
 		r.Get("/test", s.testHandler)
+		r.Get("/openapi.json", s.openAPIHandler)
+		r.Get("/docs", s.docsHandler)
 
 		r.Route("/user", func(r chi.Router) {
 			r.Post("/register", s.registerUserHandler)
@@ -48,6 +168,10 @@ func (s *APIServer) Run() error {
 				r.Use(s.AuthMiddleware)
 				r.Get("/authenticated", s.getAuthenticatedUser)
 				r.Get("/logout", s.logoutHandler)
+				r.Get("/lockouts", s.getLoginLockouts)
+				r.Put("/profile", s.updateProfileHandler)
+				r.Put("/password", s.changePasswordHandler)
+				r.Delete("/", s.deleteAccountHandler)
 			})
 		})
 
@@ -56,8 +180,25 @@ func (s *APIServer) Run() error {
 			r.Post("/", s.createForm)
 			r.Get("/", s.getAllForms)
 			r.Get("/my-forms", s.myForms)
+			r.Post("/plan", s.planFormHandler)
+			r.Post("/apply", s.applyFormHandler)
+			r.Get("/templates", s.getTemplates)
+			r.Get("/trash", s.getTrash)
+			r.Post("/templates/{formId}/instantiate", s.instantiateTemplate)
 			r.Get("/{formId}", s.getFormWithFields)
 			r.Delete("/{formId}", s.deleteFormHandler)
+			r.Post("/{formId}/restore", s.restoreForm)
+			r.Get("/{formId}/collaborators", s.getFormCollaborators)
+			r.Post("/{formId}/collaborators", s.addFormCollaborator)
+			r.Delete("/{formId}/collaborators/{userId}", s.removeFormCollaborator)
+			r.Post("/{formId}/duplicate", s.duplicateForm)
+			r.Put("/{formId}/template", s.setFormTemplate)
+			r.Delete("/{formId}/template", s.unsetFormTemplate)
+			r.Post("/{formId}/publish", s.publishForm)
+			r.Get("/{formId}/versions", s.getFormVersions)
+			r.Get("/{formId}/versions/{version}", s.getFormVersion)
+			r.Get("/{formId}/response-settings", s.getResponseSettings)
+			r.Put("/{formId}/response-settings", s.setResponseSettings)
 			r.Route("/fields", func(r chi.Router) {
 				r.Use(s.AuthMiddleware)
 				r.Post("/", s.createFormField)
@@ -66,24 +207,46 @@ func (s *APIServer) Run() error {
 			})
 		})
 
+		r.Route("/workspaces", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Post("/", s.createWorkspace)
+			r.Get("/", s.myWorkspaces)
+			r.Get("/{workspaceId}", s.getWorkspace)
+			r.Post("/{workspaceId}/members", s.addWorkspaceMember)
+			r.Delete("/{workspaceId}/members/{userId}", s.removeWorkspaceMember)
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(s.AuthMiddleware)
+			r.Use(s.AdminMiddleware)
+			r.Get("/users", s.adminListUsers)
+			r.Get("/users/{userId}", s.adminGetUser)
+			r.Put("/users/{userId}/disable", s.adminDisableUser)
+			r.Put("/users/{userId}/enable", s.adminEnableUser)
+			r.Put("/forms/{formId}/owner", s.adminTransferFormOwner)
+			r.Delete("/forms/{formId}", s.adminDeleteForm)
+			r.Get("/stats", s.adminStats)
+		})
+
 		r.Route("/form-responses", func(r chi.Router) {
 			r.Use(s.AuthMiddleware)
 			r.Post("/", s.createFormResponse)
+			r.Get("/drafts", s.getMyDrafts)
+			r.Get("/drafts/{formId}", s.getDraft)
+			r.Put("/drafts/{formId}", s.saveDraft)
+			r.Delete("/drafts/{formId}", s.deleteDraft)
+			r.Post("/drafts/{formId}/submit", s.submitDraft)
 			r.Get("/{formId}", s.getFormResponses)
 			r.Get("/", s.getMyResponses) // get authenticated user's responses to form's he/she has responded to
 			r.Get("/response-fields/{formResponseId}", s.getResponseFields)
+			r.Get("/response-fields/{formResponseId}/edits", s.getResponseEdits)
+			r.Put("/{formResponseId}", s.updateFormResponse)
+			r.Delete("/{formResponseId}", s.withdrawFormResponse)
 		})
 
 	})
 
-	server := http.Server{
-		Addr:         s.addr,
-		Handler:      router,
-		ReadTimeout:  time.Second * 15,
-		WriteTimeout: time.Second * 15,
-	}
-
-	return server.ListenAndServe()
+	return router
 }
 
 func (s *APIServer) testHandler(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dhruv15803/internal/config"
+	"github.com/dhruv15803/internal/storage"
+)
+
+// decodeProblem fails the test unless the response is a problem document with the given status
+func decodeProblem(t *testing.T, res *http.Response, status int) Problem {
+	t.Helper()
+	defer res.Body.Close()
+	if res.StatusCode != status || res.Header.Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("got %d %s, want a %d problem document", res.StatusCode, res.Header.Get("Content-Type"), status)
+	}
+	var problem Problem
+	if err := json.NewDecoder(res.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Status != status || problem.RequestId == "" || res.Header.Get(requestIDHeader) != problem.RequestId {
+		t.Fatalf("problem %+v doesn't carry the status and the request id", problem)
+	}
+	return problem
+}
+
+func TestRoutesAnswerProblems(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	for _, path := range []string{"/nope", "/api/v1/nope"} {
+		res, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decodeProblem(t, res, http.StatusNotFound)
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, server.URL+"/api/v1/test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problem := decodeProblem(t, res, http.StatusMethodNotAllowed)
+	if problem.Type != problemTypePrefix+"method-not-allowed" {
+		t.Fatalf("method not allowed problem has type %q", problem.Type)
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	s, err := NewAPIServer(&config.Config{}, storage.NewMemoryStorage(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := requestIDMiddleware(s.recoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/test", nil))
+	problem := decodeProblem(t, w.Result(), http.StatusInternalServerError)
+	if problem.Detail != "something went wrong" {
+		t.Fatalf("a panic answered with detail %q, it shouldn't leak", problem.Detail)
+	}
+
+	// an aborted response is passed on for net/http to drop the connection
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler", rec)
+		}
+	}()
+	s.recoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
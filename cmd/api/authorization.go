@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+// a role grants everything the roles ranked below it grant
+var formRoleRank = map[string]int{
+	storage.CollaboratorViewer: 1,
+	storage.CollaboratorEditor: 2,
+	storage.CollaboratorOwner:  3,
+}
+
+// workspace members get access to every form of the workspace
+var workspaceFormRole = map[string]string{
+	storage.WorkspaceRoleMember: storage.CollaboratorViewer,
+	storage.WorkspaceRoleAdmin:  storage.CollaboratorEditor,
+	storage.WorkspaceRoleOwner:  storage.CollaboratorOwner,
+}
+
+var workspaceRoleRank = map[string]int{
+	storage.WorkspaceRoleMember: 1,
+	storage.WorkspaceRoleAdmin:  2,
+	storage.WorkspaceRoleOwner:  3,
+}
+
+// formRole returns the role userId has on form, "" when they have none.
+// the user who created the form is always its owner, otherwise the
+// higher of their collaborator role and their workspace role wins.
+func (s *APIServer) formRole(ctx context.Context, form *storage.Form, userId int) (string, error) {
+	if form.UserId == userId {
+		return storage.CollaboratorOwner, nil
+	}
+
+	role := ""
+	collaborator, err := s.storage.FormCollaborators.GetFormCollaborator(ctx, form.Id, userId)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return "", err
+	}
+	if collaborator != nil {
+		role = collaborator.Role
+	}
+
+	if form.WorkspaceId != nil {
+		workspaceRole, err := s.workspaceRole(ctx, *form.WorkspaceId, userId)
+		if err != nil {
+			return "", err
+		}
+		if fromWorkspace := workspaceFormRole[workspaceRole]; formRoleRank[fromWorkspace] > formRoleRank[role] {
+			role = fromWorkspace
+		}
+	}
+
+	return role, nil
+}
+
+// workspaceRole returns the role userId has in the workspace, "" when they aren't a member
+func (s *APIServer) workspaceRole(ctx context.Context, workspaceId int, userId int) (string, error) {
+	member, err := s.storage.Workspaces.GetWorkspaceMember(ctx, workspaceId, userId)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// authorizeForm reports whether userId has at least the required role on form
+//
+//	viewer -> read responses
+//	editor -> edit fields
+//	owner  -> manage sharing and delete the form
+func (s *APIServer) authorizeForm(ctx context.Context, form *storage.Form, userId int, required string) (bool, error) {
+	role, err := s.formRole(ctx, form, userId)
+	if err != nil {
+		return false, err
+	}
+	if role == "" {
+		return false, nil
+	}
+	return formRoleRank[role] >= formRoleRank[required], nil
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/dhruv15803/client"
+	"github.com/dhruv15803/internal/config"
+	"github.com/dhruv15803/internal/storage"
+)
+
+// the client tests run the client against the real router on the memory backend
+
+const testPassword = "Sup3r-secret-pw!"
+
+// TestMain keeps the access log of every test request out of the test output
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *storage.Storage) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.JWTSecret = "test-secret-test-secret-test-secret-00"
+	cfg.DB.Conn = "memory://"
+
+	store := storage.NewMemoryStorage()
+	s, err := NewAPIServer(&cfg, store, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s.routes())
+	t.Cleanup(server.Close)
+	return server, store
+}
+
+// registered returns a client logged in as a freshly registered user
+func registered(t *testing.T, server *httptest.Server, name string) (*client.Client, *client.User) {
+	t.Helper()
+	c := client.New(server.URL)
+	user, err := c.Register(context.Background(), name, name+"@example.com", testPassword)
+	if err != nil {
+		t.Fatalf("registering %s: %v", name, err)
+	}
+	return c, user
+}
+
+// apiError fails the test unless err is an *client.APIError matching target
+func apiError(t *testing.T, what string, err error, target error) *client.APIError {
+	t.Helper()
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, target) {
+		t.Fatalf("%s: expected %v, got %v", what, target, err)
+	}
+	return apiErr
+}
+
+func TestClientAuth(t *testing.T) {
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	c, user := registered(t, server, "alice")
+	if c.Token() == "" {
+		t.Fatal("Register didn't leave the client logged in")
+	}
+	me, err := c.Me(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if me.Id != user.Id || me.Email != "alice@example.com" {
+		t.Fatalf("Me returned %+v, want alice", me)
+	}
+
+	_, err = c.Register(ctx, "alice", "alice@example.com", testPassword)
+	apiError(t, "registering the same email twice", err, client.ErrValidation)
+
+	if err := c.Logout(ctx); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Me(ctx)
+	apiError(t, "Me after logging out", err, client.ErrUnauthorized)
+
+	if _, err := c.Login(ctx, "alice@example.com", testPassword); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Me(ctx); err != nil {
+		t.Fatalf("Me after logging in: %v", err)
+	}
+
+	// a token alone authenticates, the way services use the api
+	withToken := client.New(server.URL, client.WithToken(c.Token()))
+	if _, err := withToken.Me(ctx); err != nil {
+		t.Fatalf("Me with a token: %v", err)
+	}
+
+	// changing the password ends every other session, the one that changed it carries on
+	if err := c.ChangePassword(ctx, testPassword, testPassword+"2"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = withToken.Me(ctx)
+	apiError(t, "Me with a token from before the password change", err, client.ErrUnauthorized)
+	if _, err := c.Me(ctx); err != nil {
+		t.Fatalf("Me after changing the password: %v", err)
+	}
+	if err := c.ChangePassword(ctx, testPassword+"2", testPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	lockouts, err := c.LoginLockouts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lockouts == nil || len(lockouts) != 0 {
+		t.Fatalf("LoginLockouts returned %#v, want an empty list", lockouts)
+	}
+
+	anonymous := client.New(server.URL)
+	_, err = anonymous.Login(ctx, "", "")
+	apiErr := apiError(t, "logging in without credentials", err, client.ErrValidation)
+	if apiErr.FieldError("email") == nil || apiErr.FieldError("password") == nil {
+		t.Fatalf("login problem has field errors %+v, want email and password", apiErr.Errors)
+	}
+	if apiErr.RequestID == "" {
+		t.Fatal("problem came back without a request id")
+	}
+
+	// the first failures are free, then the account is throttled
+	var limited error
+	for range 5 {
+		_, limited = anonymous.Login(ctx, "alice@example.com", "wrong-password")
+		if errors.Is(limited, client.ErrRateLimited) {
+			break
+		}
+		apiError(t, "logging in with the wrong password", limited, client.ErrUnauthorized)
+	}
+	apiErr = apiError(t, "logging in over and over", limited, client.ErrRateLimited)
+	if apiErr.RetryAfter <= 0 {
+		t.Fatal("a rate limited login has no Retry-After")
+	}
+}
+
+func TestClientFormsAndResponses(t *testing.T) {
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	owner, _ := registered(t, server, "owner")
+	respondent, _ := registered(t, server, "respondent")
+
+	form, err := owner.CreateForm(ctx, client.CreateFormRequest{FormTitle: "retro", FormDescription: "how did it go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.IsReady {
+		t.Fatal("a form without fields is ready")
+	}
+	_, err = owner.CreateForm(ctx, client.CreateFormRequest{})
+	apiError(t, "creating a form without a title", err, client.ErrValidation)
+
+	went, err := owner.CreateFormField(ctx, form.Id, client.FormFieldRequest{FieldTitle: "what went well", Required: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	score, err := owner.CreateFormField(ctx, form.Id, client.FormFieldRequest{FieldTitle: "score", FieldType: client.FieldTypeNumber})
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra, err := owner.CreateFormField(ctx, form.Id, client.FormFieldRequest{FieldTitle: "anything else"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := owner.UpdateFormField(ctx, went.Id, client.FormFieldRequest{FieldTitle: "what went well?", Required: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := owner.DeleteFormField(ctx, extra.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := owner.Form(ctx, form.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsReady || len(got.FormFields) != 2 || got.FormFields[0].FieldTitle != "what went well?" {
+		t.Fatalf("Form returned %+v, want a ready form with 2 fields", got)
+	}
+	_, err = owner.Form(ctx, 4242)
+	apiError(t, "getting a missing form", err, client.ErrNotFound)
+
+	mine, err := owner.MyForms(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mine) != 1 || mine[0].Id != form.Id {
+		t.Fatalf("MyForms returned %d forms, want the one created", len(mine))
+	}
+
+	if _, err := owner.PublishForm(ctx, form.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = respondent.SubmitResponse(ctx, form.Id, []client.Answer{{FormFieldId: score.Id, FieldValue: "4"}})
+	apiErr := apiError(t, "leaving out a required answer", err, client.ErrValidation)
+	if fieldErr := apiErr.FieldError("response_fields"); fieldErr == nil || fieldErr.Rule != "required" {
+		t.Fatalf("missing answer problem has field errors %+v, want a required rule", apiErr.Errors)
+	}
+	_, err = respondent.SubmitResponse(ctx, form.Id, []client.Answer{{FormFieldId: went.Id, FieldValue: "shipping"}, {FormFieldId: score.Id, FieldValue: "lots"}})
+	apiErr = apiError(t, "answering a number field with words", err, client.ErrValidation)
+	if apiErr.FieldError("response_fields[1].field_value") == nil {
+		t.Fatalf("bad answer problem has field errors %+v, want response_fields[1].field_value", apiErr.Errors)
+	}
+
+	submitted, err := respondent.SubmitResponse(ctx, form.Id, []client.Answer{{FormFieldId: went.Id, FieldValue: "shipping"}, {FormFieldId: score.Id, FieldValue: "4"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submitted.ResponseFields) != 2 {
+		t.Fatalf("SubmitResponse saved %d answers, want 2", len(submitted.ResponseFields))
+	}
+
+	responses, err := respondent.MyResponses(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 1 || responses[0].Id != submitted.FormResponseId {
+		t.Fatalf("MyResponses returned %d responses, want 1", len(responses))
+	}
+	_, err = respondent.FormResponses(ctx, form.Id)
+	apiError(t, "a respondent reading every response", err, client.ErrForbidden)
+
+	_, exported, err := owner.ExportResponses(ctx, form.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 1 || exported[0].Respondent != "respondent@example.com" || len(exported[0].Answers) != 2 || exported[0].Answers[1].FieldValue != "4" {
+		t.Fatalf("ExportResponses returned %+v", exported)
+	}
+	var out bytes.Buffer
+	if err := owner.ExportResponsesCSV(ctx, form.Id, &out); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{fmt.Sprint(submitted.FormResponseId), "respondent@example.com", exported[0].SubmittedAt, "shipping", "4"}
+	if len(rows) != 2 || rows[0][3] != "what went well?" || fmt.Sprint(rows[1]) != fmt.Sprint(want) {
+		t.Fatalf("ExportResponsesCSV wrote %q, want a header and %q", rows, want)
+	}
+
+	// editing the fields after publishing only changes the draft, the published version keeps taking responses
+	if err := owner.DeleteFormField(ctx, score.Id); err != nil {
+		t.Fatal(err)
+	}
+	if err := owner.DeleteFormField(ctx, went.Id); err != nil {
+		t.Fatal(err)
+	}
+	got, err = owner.Form(ctx, form.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsReady || got.PublishedVersion == nil || *got.PublishedVersion != 1 {
+		t.Fatalf("Form returned %+v after emptying the draft, want ready with version 1 published", got)
+	}
+	late, _ := registered(t, server, "late")
+	if _, err := late.SubmitResponse(ctx, form.Id, []client.Answer{{FormFieldId: went.Id, FieldValue: "still open"}}); err != nil {
+		t.Fatalf("responding after the draft was emptied: %v", err)
+	}
+	_, err = owner.PublishForm(ctx, form.Id)
+	apiError(t, "publishing a draft without fields", err, client.ErrValidation)
+}
+
+func TestClientDrafts(t *testing.T) {
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+
+	owner, _ := registered(t, server, "owner")
+	respondent, _ := registered(t, server, "respondent")
+
+	form, err := owner.CreateForm(ctx, client.CreateFormRequest{FormTitle: "retro", FormDescription: "how did it go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	went, err := owner.CreateFormField(ctx, form.Id, client.FormFieldRequest{FieldTitle: "what went well", Required: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra, err := owner.CreateFormField(ctx, form.Id, client.FormFieldRequest{FieldTitle: "anything else"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := owner.PublishForm(ctx, form.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	draft, err := respondent.SaveDraft(ctx, form.Id, []client.Answer{{FormFieldId: extra.Id, FieldValue: "more retros"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(draft.ResponseFields) != 1 {
+		t.Fatalf("SaveDraft kept %d answers, want 1", len(draft.ResponseFields))
+	}
+	_, err = respondent.SubmitDraft(ctx, form.Id)
+	apiError(t, "submitting a draft without a required answer", err, client.ErrValidation)
+	if _, err := respondent.Draft(ctx, form.Id); err != nil {
+		t.Fatalf("a draft that failed to submit wasn't kept: %v", err)
+	}
+
+	if _, err := respondent.SaveDraft(ctx, form.Id, []client.Answer{{FormFieldId: went.Id, FieldValue: "shipping"}, {FormFieldId: extra.Id, FieldValue: "more retros"}}); err != nil {
+		t.Fatal(err)
+	}
+	// the owner drops a field the draft answered before it is submitted
+	if err := owner.DeleteFormField(ctx, extra.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := owner.PublishForm(ctx, form.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	submitted, err := respondent.SubmitDraft(ctx, form.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submitted.ResponseFields) != 1 || submitted.ResponseFields[0].FormFieldId != went.Id {
+		t.Fatalf("SubmitDraft saved %+v, want the answer to %d", submitted.ResponseFields, went.Id)
+	}
+	if len(submitted.DroppedAnswers) != 1 || submitted.DroppedAnswers[0] != (client.Answer{FormFieldId: extra.Id, FieldValue: "more retros"}) {
+		t.Fatalf("SubmitDraft dropped %+v, want the answer to %d", submitted.DroppedAnswers, extra.Id)
+	}
+	_, err = respondent.Draft(ctx, form.Id)
+	apiError(t, "getting a submitted draft", err, client.ErrNotFound)
+}
+
+func TestClientWorkspaceForms(t *testing.T) {
+	server, _ := newTestServer(t)
+	ctx := context.Background()
+	owner, _ := registered(t, server, "owner")
+	member, memberUser := registered(t, server, "member")
+	outsider, _ := registered(t, server, "outsider")
+
+	workspace, err := owner.CreateWorkspace(ctx, "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := owner.AddWorkspaceMember(ctx, workspace.Id, memberUser.Email, ""); err != nil {
+		t.Fatal(err)
+	}
+	form, err := owner.CreateForm(ctx, client.CreateFormRequest{FormTitle: "internal", FormDescription: "team only", WorkspaceId: &workspace.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a member lists the forms of the workspace they didn't create
+	forms, err := member.MyForms(ctx, &workspace.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forms) != 1 || forms[0].Id != form.Id {
+		t.Fatalf("MyForms in the workspace returned %d forms, want the owner's form", len(forms))
+	}
+	if forms, err = member.MyForms(ctx, nil); err != nil || len(forms) != 0 {
+		t.Fatalf("MyForms returned %d forms, %v, want none of the member's own", len(forms), err)
+	}
+	_, err = outsider.MyForms(ctx, &workspace.Id)
+	apiError(t, "an outsider listing the workspace's forms", err, client.ErrForbidden)
+}
+
+func TestClientUserPagination(t *testing.T) {
+	server, store := newTestServer(t)
+	ctx := context.Background()
+
+	admin, adminUser := registered(t, server, "admin")
+	if _, err := store.Users.SetUserRole(ctx, adminUser.Id, storage.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	var user *client.Client
+	for i := range 6 {
+		user, _ = registered(t, server, fmt.Sprintf("user%d", i))
+	}
+
+	users, err := admin.AdminListUsers(ctx, client.ListUsersOptions{PageSize: 3}).All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 7 {
+		t.Fatalf("AdminListUsers went through %d users, want 7", len(users))
+	}
+	seen := make(map[int]bool)
+	for _, u := range users {
+		if seen[u.Id] {
+			t.Fatalf("user %d came up on two pages", u.Id)
+		}
+		seen[u.Id] = true
+	}
+
+	matching, err := admin.AdminListUsers(ctx, client.ListUsersOptions{Query: "user", PageSize: 2}).All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matching) != 6 {
+		t.Fatalf("AdminListUsers matched %d users, want 6", len(matching))
+	}
+
+	_, err = user.AdminListUsers(ctx, client.ListUsersOptions{}).All()
+	apiError(t, "listing users without being an admin", err, client.ErrForbidden)
+}
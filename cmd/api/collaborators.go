@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+type AddCollaboratorRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// formForRequest loads the form from the {formId} path parameter and checks the user has atleast the required role on it,
+// it writes the error response itself and returns nil when the handler should stop
+func (s *APIServer) formForRequest(w http.ResponseWriter, r *http.Request, userId int, required string) *storage.Form {
+	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return nil
+	}
+
+	form, err := s.storage.Forms.GetFormById(r.Context(), int(formId))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("form with id %d not found", formId), http.StatusNotFound)
+			return nil
+		}
+		s.writeError(w, r, err)
+		return nil
+	}
+
+	allowed, err := s.authorizeForm(r.Context(), form, userId, required)
+	if err != nil {
+		s.writeError(w, r, err)
+		return nil
+	}
+	if !allowed {
+		s.writeProblem(w, r, fmt.Sprintf("user not authorized, %s access to the form required", required), http.StatusForbidden)
+		return nil
+	}
+
+	return form
+}
+
+func (s *APIServer) getFormCollaborators(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	form := s.formForRequest(w, r, userId, storage.CollaboratorViewer)
+	if form == nil {
+		return
+	}
+
+	collaborators, err := s.storage.FormCollaborators.GetFormCollaboratorsByFormId(r.Context(), form.Id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err = s.writeJSON(w, collaborators, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) addFormCollaborator(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	var payload AddCollaboratorRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(payload.Email))
+	role := strings.ToLower(strings.TrimSpace(payload.Role))
+
+	if email == "" {
+		s.writeFieldProblem(w, r, "email", "email is required")
+		return
+	}
+	if _, ok := formRoleRank[role]; !ok {
+		s.writeFieldProblem(w, r, "role", "role should be one of viewer, editor or owner")
+		return
+	}
+
+	form := s.formForRequest(w, r, userId, storage.CollaboratorOwner)
+	if form == nil {
+		return
+	}
+
+	invitee, err := s.storage.Users.GetUserByEmail(r.Context(), email)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("no user registered with email %s", email), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	// the creator is always the owner, there's nothing to share with them
+	if invitee.Id == form.UserId {
+		s.writeFieldProblem(w, r, "email", "user already owns this form")
+		return
+	}
+
+	collaborator, err := s.storage.FormCollaborators.AddFormCollaborator(r.Context(), form.Id, invitee.Id, role)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	collaborator.User = invitee
+
+	if err = s.writeJSON(w, collaborator, http.StatusCreated); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) removeFormCollaborator(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	collaboratorId, err := strconv.ParseInt(r.PathValue("userId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	// collaborators can always remove themselves, anybody else needs to be an owner
+	required := storage.CollaboratorOwner
+	if int(collaboratorId) == userId {
+		required = storage.CollaboratorViewer
+	}
+	form := s.formForRequest(w, r, userId, required)
+	if form == nil {
+		return
+	}
+
+	if _, err = s.storage.FormCollaborators.GetFormCollaborator(r.Context(), form.Id, int(collaboratorId)); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("user with id %d is not a collaborator on this form", collaboratorId), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err = s.storage.FormCollaborators.DeleteFormCollaborator(r.Context(), form.Id, int(collaboratorId)); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	type Envelope struct {
+		Message string `json:"message"`
+	}
+	if err = s.writeJSON(w, Envelope{Message: fmt.Sprintf("user with id %d removed from form %d", collaboratorId, form.Id)}, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
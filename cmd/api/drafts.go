@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+// draftPurgeInterval is how often expired drafts are looked for
+const draftPurgeInterval = time.Hour
+
+type SaveDraftRequest struct {
+	ResponseFields []ResponseField `json:"response_fields"`
+}
+
+// purgeDrafts deletes expired drafts until ctx is cancelled, they are already hidden once they expire
+// so this only keeps the table from growing. like purgeTrash every replica runs it
+func (s *APIServer) purgeDrafts(ctx context.Context) {
+	ticker := time.NewTicker(draftPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.storage.ResponseDrafts.PurgeExpiredResponseDrafts(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			slog.Error("purging expired drafts failed", slog.Any("error", err))
+		}
+		if purged > 0 {
+			s.metrics.draftsPurged.Add(float64(purged))
+			slog.Info("purged expired drafts", slog.Int("drafts", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// draftFormForRequest loads the form in the formId path parameter, drafts can only be kept for forms taking responses
+func (s *APIServer) draftFormForRequest(w http.ResponseWriter, r *http.Request) *storage.Form {
+	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return nil
+	}
+
+	form, err := s.storage.Forms.GetFormById(r.Context(), int(formId))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("form with id %d not found", formId), http.StatusNotFound)
+			return nil
+		}
+		s.writeError(w, r, err)
+		return nil
+	}
+	if !form.IsReady {
+		s.writeProblem(w, r, "form is not ready to accept responses", http.StatusBadRequest)
+		return nil
+	}
+	return form
+}
+
+// getMyDrafts lists the drafts the authenticated user can resume
+func (s *APIServer) getMyDrafts(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	drafts, err := s.storage.ResponseDrafts.GetResponseDraftsByRespondentId(r.Context(), userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if drafts == nil {
+		drafts = []storage.ResponseDraft{}
+	}
+
+	if err := s.writeJSON(w, drafts, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// getDraft returns the authenticated user's draft for a form, so it can be resumed from any device
+func (s *APIServer) getDraft(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	draft, err := s.storage.ResponseDrafts.GetResponseDraft(r.Context(), int(formId), userId)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("no draft of form %d", formId), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.writeJSON(w, draft, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// saveDraft replaces the authenticated user's draft for a form. answers have to fit the fields they answer
+// but required fields can be left out until the draft is submitted
+func (s *APIServer) saveDraft(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	var req SaveDraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	form := s.draftFormForRequest(w, r)
+	if form == nil {
+		return
+	}
+	version, err := s.publishedVersion(r.Context(), form)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+	if !s.validAnswers(w, r, version.FormFields, req.ResponseFields) {
+		return
+	}
+
+	answers := make([]storage.DraftAnswer, 0, len(req.ResponseFields))
+	for _, answer := range req.ResponseFields {
+		answers = append(answers, storage.DraftAnswer{FormFieldId: answer.FormFieldId, FieldValue: answer.FieldValue})
+	}
+	var expiresAt *time.Time
+	if s.config.DraftExpiry > 0 {
+		expiry := time.Now().Add(s.config.DraftExpiry)
+		expiresAt = &expiry
+	}
+
+	draft, err := s.storage.ResponseDrafts.SaveResponseDraft(r.Context(), form.Id, userId, answers, expiresAt)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.writeJSON(w, draft, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// deleteDraft discards the authenticated user's draft for a form
+func (s *APIServer) deleteDraft(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.storage.ResponseDrafts.DeleteResponseDraft(r.Context(), int(formId), userId); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("no draft of form %d", formId), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	type Envelope struct {
+		Message string `json:"message"`
+	}
+	if err := s.writeJSON(w, Envelope{Message: fmt.Sprintf("draft of form %d discarded", formId)}, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// submitDraft turns the authenticated user's draft into a response, the draft is checked like any
+// submitted response first and stays around to be fixed when it doesn't pass. answers to fields the
+// published version no longer has come back as dropped_answers
+func (s *APIServer) submitDraft(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	form := s.draftFormForRequest(w, r)
+	if form == nil {
+		return
+	}
+
+	draft, err := s.storage.ResponseDrafts.GetResponseDraft(r.Context(), form.Id, userId)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("no draft of form %d", form.Id), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	version, err := s.publishedVersion(r.Context(), form)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	// a version published since the draft was saved may have dropped fields it answered,
+	// those answers can't be submitted so they are left out and handed back in the response
+	fieldIds := make(map[int]bool, len(version.FormFields))
+	for _, field := range version.FormFields {
+		fieldIds[field.Id] = true
+	}
+	var answers []ResponseField
+	droppedAnswers := []storage.DraftAnswer{}
+	for _, answer := range draft.ResponseFields {
+		if !fieldIds[answer.FormFieldId] {
+			droppedAnswers = append(droppedAnswers, answer)
+			continue
+		}
+		answers = append(answers, ResponseField{FieldValue: answer.FieldValue, FormFieldId: answer.FormFieldId})
+	}
+	if !s.validAnswers(w, r, version.FormFields, answers) || !s.answeredRequired(w, r, version.FormFields, answers) {
+		return
+	}
+
+	formResponse, createdFields, err := s.storage.ResponseDrafts.SubmitResponseDraft(r.Context(), form.Id, userId, &version.Version, answerRows(answers))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("no draft of form %d", form.Id), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	if createdFields == nil {
+		createdFields = []storage.ResponseField{}
+	}
+	s.metrics.responsesSubmitted.Inc()
+
+	resp := struct {
+		FormResponseId int                     `json:"form_response_id"`
+		ResponseFields []storage.ResponseField `json:"response_fields"`
+		DroppedAnswers []storage.DraftAnswer   `json:"dropped_answers"`
+	}{
+		FormResponseId: formResponse.Id,
+		ResponseFields: createdFields,
+		DroppedAnswers: droppedAnswers,
+	}
+
+	if err := s.writeJSON(w, resp, http.StatusCreated); err != nil {
+		s.serverError(w, r, err)
+	}
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dhruv15803/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// a form definition describes a form in a yaml or json document that can live in git. the form it
+// describes is found by its key, planning compares the two and applying creates the form or brings it
+// in line with the definition, so applying the same definition twice changes nothing the second time.
+//
+//	key: platform/sprint-retro
+//	title: Sprint retro
+//	description: What should we keep doing?
+//	fields:
+//	  - key: went-well
+//	    title: What went well?
+//	    type: long_text
+//	    required: true
+//	  - key: mood
+//	    title: How did the sprint feel?
+//	    type: choice
+//	    options: [great, fine, rough]
+//
+// fields are matched by key and listed in the order of the definition, a field of the form without a
+// key yet is matched by its title and gets the key. fields missing from the definition are deleted,
+// answers given to a published version of the form keep them.
+
+// maxDefinitionSize bounds the body of the plan and apply requests
+const maxDefinitionSize = 1 << 20
+
+// keys are lowercase so they read the same in urls, file names and yaml, form keys can be namespaced with a /
+var definitionKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._/-]{0,99}$`)
+
+type FormDefinition struct {
+	Key         string            `json:"key" yaml:"key"`
+	Title       string            `json:"title" yaml:"title"`
+	Description string            `json:"description" yaml:"description"`
+	WorkspaceId *int              `json:"workspace_id" yaml:"workspace_id"`
+	Fields      []FieldDefinition `json:"fields" yaml:"fields"`
+}
+
+type FieldDefinition struct {
+	Key      string   `json:"key" yaml:"key"`
+	Title    string   `json:"title" yaml:"title"`
+	Type     string   `json:"type" yaml:"type"`
+	Required bool     `json:"required" yaml:"required"`
+	Options  []string `json:"options" yaml:"options"`
+}
+
+const (
+	planCreateForm  = "create_form"
+	planUpdateForm  = "update_form"
+	planCreateField = "create_field"
+	planUpdateField = "update_field"
+	planDeleteField = "delete_field"
+)
+
+// FormPlan lists the changes applying a definition makes, no changes means the form already matches it
+type FormPlan struct {
+	FormKey string       `json:"form_key"`
+	FormId  *int         `json:"form_id"`
+	Changes []PlanChange `json:"changes"`
+	Applied bool         `json:"applied"`
+	// Form is the form as it is after applying
+	Form *storage.Form `json:"form,omitempty"`
+}
+
+type PlanChange struct {
+	Action   string          `json:"action"`
+	FieldKey string          `json:"field_key,omitempty"`
+	FieldId  *int            `json:"field_id,omitempty"`
+	Diffs    []AttributeDiff `json:"diffs,omitempty"`
+
+	spec storage.FieldSpec
+}
+
+// AttributeDiff is one attribute a change sets, From is null for what doesn't exist yet
+type AttributeDiff struct {
+	Attribute string `json:"attribute"`
+	From      any    `json:"from"`
+	To        any    `json:"to"`
+}
+
+// decodeFormDefinition reads a yaml or json definition going by the Content-Type, unknown attributes
+// are rejected so a typo in a definition doesn't go unnoticed
+func decodeFormDefinition(r *http.Request) (*FormDefinition, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxDefinitionSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxDefinitionSize {
+		return nil, fmt.Errorf("definition is larger than %d bytes", maxDefinitionSize)
+	}
+
+	var definition FormDefinition
+	if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		decoder := yaml.NewDecoder(bytes.NewReader(body))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&definition); err != nil {
+			return nil, err
+		}
+		return &definition, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&definition); err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// normalize trims the definition and fills in the default field type
+func (d *FormDefinition) normalize() {
+	d.Key = strings.TrimSpace(d.Key)
+	d.Title = strings.TrimSpace(d.Title)
+	d.Description = strings.TrimSpace(d.Description)
+	for i := range d.Fields {
+		field := &d.Fields[i]
+		field.Key = strings.TrimSpace(field.Key)
+		field.Title = strings.TrimSpace(field.Title)
+		field.Type = strings.TrimSpace(field.Type)
+		if field.Type == "" {
+			field.Type = storage.FieldTypeText
+		}
+		field.Options = trimOptions(field.Options)
+	}
+}
+
+func (d *FormDefinition) validate() []FieldError {
+	var fieldErrors []FieldError
+	if !definitionKeyPattern.MatchString(d.Key) {
+		fieldErrors = append(fieldErrors, FieldError{Field: "key", Message: "key is required and may only hold lowercase letters, digits and . _ / -"})
+	}
+	if d.Title == "" {
+		fieldErrors = append(fieldErrors, FieldError{Field: "title", Message: "title is required"})
+	}
+	if d.Description == "" {
+		fieldErrors = append(fieldErrors, FieldError{Field: "description", Message: "description is required"})
+	}
+	if len(d.Fields) == 0 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "fields", Message: "a form needs at least one field"})
+	}
+
+	seen := make(map[string]bool, len(d.Fields))
+	for i, field := range d.Fields {
+		path := fmt.Sprintf("fields[%d].", i)
+		switch {
+		case !definitionKeyPattern.MatchString(field.Key) || strings.Contains(field.Key, "/"):
+			fieldErrors = append(fieldErrors, FieldError{Field: path + "key", Message: "key is required and may only hold lowercase letters, digits and . _ -"})
+		case seen[field.Key]:
+			fieldErrors = append(fieldErrors, FieldError{Field: path + "key", Message: fmt.Sprintf("key %q is used by another field", field.Key)})
+		}
+		seen[field.Key] = true
+		if field.Title == "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: path + "title", Message: "title is required"})
+		}
+		fieldErrors = append(fieldErrors, fieldTypeErrors(path+"type", path+"options", field.Type, field.Options)...)
+	}
+	return fieldErrors
+}
+
+// fieldTypeErrors checks a field type and the options that go with it, typeField and optionsField
+// name the attributes in the errors
+func fieldTypeErrors(typeField string, optionsField string, fieldType string, options []string) []FieldError {
+	if !slices.Contains(storage.FieldTypes, fieldType) {
+		return []FieldError{{Field: typeField, Message: fmt.Sprintf("type should be one of %s", strings.Join(storage.FieldTypes, ", "))}}
+	}
+	if fieldType != storage.FieldTypeChoice {
+		if len(options) > 0 {
+			return []FieldError{{Field: optionsField, Message: "only choice fields have options"}}
+		}
+		return nil
+	}
+
+	if len(options) == 0 {
+		return []FieldError{{Field: optionsField, Message: "a choice field needs at least one option"}}
+	}
+	seen := make(map[string]bool, len(options))
+	for _, option := range options {
+		if option == "" {
+			return []FieldError{{Field: optionsField, Message: "options cannot be empty"}}
+		}
+		if seen[option] {
+			return []FieldError{{Field: optionsField, Message: fmt.Sprintf("option %q is listed twice", option)}}
+		}
+		seen[option] = true
+	}
+	return nil
+}
+
+func trimOptions(options []string) []string {
+	if len(options) == 0 {
+		return nil
+	}
+	trimmed := make([]string, len(options))
+	for i, option := range options {
+		trimmed[i] = strings.TrimSpace(option)
+	}
+	return trimmed
+}
+
+// planFormDefinition works out what applying the definition in the request would change.
+// ok is false when an error response has already been written.
+func (s *APIServer) planFormDefinition(w http.ResponseWriter, r *http.Request) (plan *FormPlan, definition *FormDefinition, ok bool) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "unauthorized: unable to retrieve user from context", http.StatusUnauthorized)
+		return nil, nil, false
+	}
+
+	definition, err := decodeFormDefinition(r)
+	if err != nil {
+		s.writeProblem(w, r, "invalid form definition: "+err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+	definition.normalize()
+	if fieldErrors := definition.validate(); len(fieldErrors) > 0 {
+		s.writeValidationProblem(w, r, "invalid form definition: "+fieldErrors[0].Message, fieldErrors...)
+		return nil, nil, false
+	}
+
+	form, err := s.storage.Forms.GetFormByKey(r.Context(), definition.Key)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.serverError(w, r, err)
+		return nil, nil, false
+	}
+
+	if form == nil {
+		// the form gets created, in a workspace the user has to be a member of
+		if definition.WorkspaceId != nil {
+			role, err := s.workspaceRole(r.Context(), *definition.WorkspaceId, userId)
+			if err != nil {
+				s.writeError(w, r, err)
+				return nil, nil, false
+			}
+			if role == "" {
+				s.writeProblem(w, r, "user is not a member of this workspace", http.StatusForbidden)
+				return nil, nil, false
+			}
+		}
+		return newFormPlan(definition), definition, true
+	}
+
+	allowed, err := s.authorizeForm(r.Context(), form, userId, storage.CollaboratorEditor)
+	if err != nil {
+		s.serverError(w, r, err)
+		return nil, nil, false
+	}
+	if !allowed {
+		// the key is taken by a form the user can't edit
+		s.writeProblem(w, r, fmt.Sprintf("form key %q belongs to a form you cannot edit", definition.Key), http.StatusConflict)
+		return nil, nil, false
+	}
+	if definition.WorkspaceId != nil && (form.WorkspaceId == nil || *form.WorkspaceId != *definition.WorkspaceId) {
+		s.writeFieldProblem(w, r, "workspace_id", "a form cannot be moved to another workspace")
+		return nil, nil, false
+	}
+
+	fields, err := s.storage.FormFields.GetFormFieldsByFormId(r.Context(), form.Id)
+	if err != nil {
+		s.serverError(w, r, err)
+		return nil, nil, false
+	}
+	return diffFormPlan(definition, form, fields), definition, true
+}
+
+// newFormPlan is the plan for a definition without a form yet
+func newFormPlan(definition *FormDefinition) *FormPlan {
+	plan := &FormPlan{FormKey: definition.Key, Changes: []PlanChange{{
+		Action: planCreateForm,
+		Diffs: []AttributeDiff{
+			{Attribute: "title", To: definition.Title},
+			{Attribute: "description", To: definition.Description},
+		},
+	}}}
+	for i, field := range definition.Fields {
+		spec := fieldSpec(field, i)
+		plan.Changes = append(plan.Changes, PlanChange{
+			Action:   planCreateField,
+			FieldKey: field.Key,
+			Diffs:    fieldDiffs(nil, spec),
+			spec:     spec,
+		})
+	}
+	return plan
+}
+
+// diffFormPlan compares the definition with the form and its fields
+func diffFormPlan(definition *FormDefinition, form *storage.Form, fields []storage.FormField) *FormPlan {
+	formId := form.Id
+	plan := &FormPlan{FormKey: definition.Key, FormId: &formId, Changes: []PlanChange{}}
+
+	var formDiffs []AttributeDiff
+	if form.FormTitle != definition.Title {
+		formDiffs = append(formDiffs, AttributeDiff{Attribute: "title", From: form.FormTitle, To: definition.Title})
+	}
+	if form.FormDescription != definition.Description {
+		formDiffs = append(formDiffs, AttributeDiff{Attribute: "description", From: form.FormDescription, To: definition.Description})
+	}
+	if len(formDiffs) > 0 {
+		plan.Changes = append(plan.Changes, PlanChange{Action: planUpdateForm, Diffs: formDiffs})
+	}
+
+	matched := make(map[int]bool, len(fields))
+	match := func(field FieldDefinition) *storage.FormField {
+		for i := range fields {
+			if !matched[fields[i].Id] && fields[i].FieldKey != nil && *fields[i].FieldKey == field.Key {
+				return &fields[i]
+			}
+		}
+		// fields added by hand are adopted by their title
+		for i := range fields {
+			if !matched[fields[i].Id] && fields[i].FieldKey == nil && fields[i].FieldTitle == field.Title {
+				return &fields[i]
+			}
+		}
+		return nil
+	}
+
+	var fieldChanges []PlanChange
+	for i, definitionField := range definition.Fields {
+		spec := fieldSpec(definitionField, i)
+		existing := match(definitionField)
+		if existing == nil {
+			fieldChanges = append(fieldChanges, PlanChange{Action: planCreateField, FieldKey: definitionField.Key, Diffs: fieldDiffs(nil, spec), spec: spec})
+			continue
+		}
+		matched[existing.Id] = true
+		if diffs := fieldDiffs(existing, spec); len(diffs) > 0 {
+			fieldId := existing.Id
+			fieldChanges = append(fieldChanges, PlanChange{Action: planUpdateField, FieldKey: definitionField.Key, FieldId: &fieldId, Diffs: diffs, spec: spec})
+		}
+	}
+
+	// deletes go first so they are easy to spot in the plan
+	for _, field := range fields {
+		if matched[field.Id] {
+			continue
+		}
+		fieldId := field.Id
+		change := PlanChange{Action: planDeleteField, FieldId: &fieldId, Diffs: []AttributeDiff{{Attribute: "title", From: field.FieldTitle}}}
+		if field.FieldKey != nil {
+			change.FieldKey = *field.FieldKey
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	plan.Changes = append(plan.Changes, fieldChanges...)
+	return plan
+}
+
+// fieldSpec is the spec of the definition's field at index, positions follow the order of the definition
+func fieldSpec(field FieldDefinition, index int) storage.FieldSpec {
+	key := field.Key
+	return storage.FieldSpec{
+		FieldKey:   &key,
+		FieldTitle: field.Title,
+		FieldType:  field.Type,
+		Required:   field.Required,
+		Position:   index + 1,
+		Options:    field.Options,
+	}
+}
+
+// fieldDiffs lists the attributes spec changes on field, every attribute when field is nil
+func fieldDiffs(field *storage.FormField, spec storage.FieldSpec) []AttributeDiff {
+	if field == nil {
+		diffs := []AttributeDiff{
+			{Attribute: "title", To: spec.FieldTitle},
+			{Attribute: "type", To: spec.FieldType},
+			{Attribute: "required", To: spec.Required},
+			{Attribute: "position", To: spec.Position},
+		}
+		if len(spec.Options) > 0 {
+			diffs = append(diffs, AttributeDiff{Attribute: "options", To: spec.Options})
+		}
+		return diffs
+	}
+
+	var diffs []AttributeDiff
+	if field.FieldKey == nil {
+		diffs = append(diffs, AttributeDiff{Attribute: "key", To: *spec.FieldKey})
+	}
+	if field.FieldTitle != spec.FieldTitle {
+		diffs = append(diffs, AttributeDiff{Attribute: "title", From: field.FieldTitle, To: spec.FieldTitle})
+	}
+	if field.FieldType != spec.FieldType {
+		diffs = append(diffs, AttributeDiff{Attribute: "type", From: field.FieldType, To: spec.FieldType})
+	}
+	if field.Required != spec.Required {
+		diffs = append(diffs, AttributeDiff{Attribute: "required", From: field.Required, To: spec.Required})
+	}
+	if field.Position != spec.Position {
+		diffs = append(diffs, AttributeDiff{Attribute: "position", From: field.Position, To: spec.Position})
+	}
+	if !slices.Equal(field.Options, spec.Options) {
+		diffs = append(diffs, AttributeDiff{Attribute: "options", From: field.Options, To: spec.Options})
+	}
+	return diffs
+}
+
+func (s *APIServer) planFormHandler(w http.ResponseWriter, r *http.Request) {
+	plan, _, ok := s.planFormDefinition(w, r)
+	if !ok {
+		return
+	}
+	if err := s.writeJSON(w, plan, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// applyFormHandler carries out the plan for the definition. the field changes are made in one transaction,
+// a new form is created before them so when they fail applying the same definition again fills it in.
+func (s *APIServer) applyFormHandler(w http.ResponseWriter, r *http.Request) {
+	plan, definition, ok := s.planFormDefinition(w, r)
+	if !ok {
+		return
+	}
+	userId := r.Context().Value(userIDKey).(int)
+
+	status := http.StatusOK
+	var changes storage.FieldChanges
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case planCreateForm:
+			form, err := s.storage.Forms.CreateForm(r.Context(), definition.Title, definition.Description, &definition.Key, userId, definition.WorkspaceId)
+			if err != nil {
+				if errors.Is(err, storage.ErrConflict) {
+					// trashed forms keep their key so restoring them doesn't break their definition
+					s.writeProblem(w, r, fmt.Sprintf("a form with key %q already exists, restore it from the trash or pick another key", definition.Key), http.StatusConflict)
+					return
+				}
+				s.writeError(w, r, err)
+				return
+			}
+			s.metrics.formsCreated.Inc()
+			plan.FormId = &form.Id
+			status = http.StatusCreated
+		case planUpdateForm:
+			if _, err := s.storage.Forms.UpdateForm(r.Context(), *plan.FormId, definition.Title, definition.Description); err != nil {
+				s.writeError(w, r, err)
+				return
+			}
+		case planCreateField:
+			changes.Create = append(changes.Create, change.spec)
+		case planUpdateField:
+			changes.Update = append(changes.Update, storage.FieldUpdate{FieldId: *change.FieldId, Spec: change.spec})
+		case planDeleteField:
+			changes.Delete = append(changes.Delete, *change.FieldId)
+		}
+	}
+
+	if err := s.storage.FormFields.ApplyFieldChanges(r.Context(), *plan.FormId, changes); err != nil {
+		if errors.Is(err, storage.ErrConflict) || errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, "the form changed while the definition was applied, apply it again", http.StatusConflict)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+	form, err := s.storage.Forms.GetFormByIdWithFieldsAndUser(r.Context(), *plan.FormId)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+	plan.Applied = true
+	plan.Form = form
+
+	if err = s.writeJSON(w, plan, status); err != nil {
+		s.serverError(w, r, err)
+	}
+}
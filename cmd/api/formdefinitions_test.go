@@ -0,0 +1,213 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+// summarize writes a change as "action key attributes", e.g. "update_field mood position,required",
+// the key is left out when the change has none
+func summarize(changes []PlanChange) []string {
+	summaries := make([]string, 0, len(changes))
+	for _, change := range changes {
+		attributes := make([]string, 0, len(change.Diffs))
+		for _, diff := range change.Diffs {
+			attributes = append(attributes, diff.Attribute)
+		}
+		parts := []string{change.Action}
+		if change.FieldKey != "" {
+			parts = append(parts, change.FieldKey)
+		}
+		summaries = append(summaries, strings.Join(append(parts, strings.Join(attributes, ",")), " "))
+	}
+	return summaries
+}
+
+func keyed(key string) *string {
+	return &key
+}
+
+func TestDiffFormPlan(t *testing.T) {
+	form := &storage.Form{Id: 7, FormTitle: "Sprint retro", FormDescription: "How did it go?"}
+	definition := func(fields ...FieldDefinition) *FormDefinition {
+		return &FormDefinition{Key: "team/retro", Title: form.FormTitle, Description: form.FormDescription, Fields: fields}
+	}
+	wentWell := FieldDefinition{Key: "went-well", Title: "What went well?", Type: storage.FieldTypeLongText, Required: true}
+	mood := FieldDefinition{Key: "mood", Title: "Mood", Type: storage.FieldTypeChoice, Options: []string{"good", "bad"}}
+
+	tests := []struct {
+		name       string
+		definition *FormDefinition
+		fields     []storage.FormField
+		want       []string
+	}{
+		{
+			name:       "matching form",
+			definition: definition(wentWell, mood),
+			fields: []storage.FormField{
+				{Id: 1, FieldKey: keyed("went-well"), FieldTitle: "What went well?", FieldType: storage.FieldTypeLongText, Required: true, Position: 1},
+				{Id: 2, FieldKey: keyed("mood"), FieldTitle: "Mood", FieldType: storage.FieldTypeChoice, Position: 2, Options: []string{"good", "bad"}},
+			},
+			want: []string{},
+		},
+		{
+			name:       "form attributes",
+			definition: &FormDefinition{Key: "team/retro", Title: "Retro", Description: "New", Fields: []FieldDefinition{wentWell}},
+			fields: []storage.FormField{
+				{Id: 1, FieldKey: keyed("went-well"), FieldTitle: "What went well?", FieldType: storage.FieldTypeLongText, Required: true, Position: 1},
+			},
+			want: []string{"update_form title,description"},
+		},
+		{
+			name:       "a field added by hand is adopted by its title",
+			definition: definition(wentWell),
+			fields: []storage.FormField{
+				{Id: 1, FieldTitle: "What went well?", FieldType: storage.FieldTypeLongText, Required: true, Position: 1},
+			},
+			want: []string{"update_field went-well key"},
+		},
+		{
+			name:       "a keyed field is matched before one with the same title",
+			definition: definition(wentWell),
+			fields: []storage.FormField{
+				{Id: 1, FieldTitle: "What went well?", FieldType: storage.FieldTypeLongText, Required: true, Position: 1},
+				{Id: 2, FieldKey: keyed("went-well"), FieldTitle: "Old title", FieldType: storage.FieldTypeLongText, Required: true, Position: 2},
+			},
+			want: []string{"delete_field title", "update_field went-well title,position"},
+		},
+		{
+			name:       "a keyed field isn't adopted by another key with its title",
+			definition: definition(FieldDefinition{Key: "renamed", Title: "Mood", Type: storage.FieldTypeText}),
+			fields: []storage.FormField{
+				{Id: 1, FieldKey: keyed("mood"), FieldTitle: "Mood", FieldType: storage.FieldTypeText, Position: 1},
+			},
+			want: []string{"delete_field mood title", "create_field renamed title,type,required,position"},
+		},
+		{
+			name:       "reordering only moves positions",
+			definition: definition(mood, wentWell),
+			fields: []storage.FormField{
+				{Id: 1, FieldKey: keyed("went-well"), FieldTitle: "What went well?", FieldType: storage.FieldTypeLongText, Required: true, Position: 1},
+				{Id: 2, FieldKey: keyed("mood"), FieldTitle: "Mood", FieldType: storage.FieldTypeChoice, Position: 2, Options: []string{"good", "bad"}},
+			},
+			want: []string{"update_field mood position", "update_field went-well position"},
+		},
+		{
+			name:       "deletes go first",
+			definition: definition(wentWell, mood),
+			fields: []storage.FormField{
+				{Id: 1, FieldKey: keyed("went-well"), FieldTitle: "What went well?", FieldType: storage.FieldTypeText, Position: 1},
+				{Id: 2, FieldKey: keyed("gone"), FieldTitle: "Gone", FieldType: storage.FieldTypeText, Position: 2},
+				{Id: 3, FieldTitle: "Unkeyed", FieldType: storage.FieldTypeText, Position: 3},
+			},
+			want: []string{
+				"delete_field gone title",
+				"delete_field title",
+				"update_field went-well type,required",
+				"create_field mood title,type,required,position,options",
+			},
+		},
+		{
+			name:       "changed options",
+			definition: definition(mood),
+			fields: []storage.FormField{
+				{Id: 2, FieldKey: keyed("mood"), FieldTitle: "Mood", FieldType: storage.FieldTypeChoice, Position: 1, Options: []string{"bad", "good"}},
+			},
+			want: []string{"update_field mood options"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := diffFormPlan(tt.definition, form, tt.fields)
+			if plan.FormId == nil || *plan.FormId != form.Id || plan.FormKey != tt.definition.Key {
+				t.Fatalf("plan is for form %v %q", plan.FormId, plan.FormKey)
+			}
+			if plan.Changes == nil {
+				t.Fatal("plan has nil changes, they should encode as an empty list")
+			}
+			if got := summarize(plan.Changes); !slices.Equal(got, tt.want) {
+				t.Fatalf("plan changes are\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffFormPlanFieldIds(t *testing.T) {
+	definition := &FormDefinition{Key: "retro", Title: "Retro", Description: "How did it go?", Fields: []FieldDefinition{
+		{Key: "b", Title: "B", Type: storage.FieldTypeText},
+		{Key: "a", Title: "A", Type: storage.FieldTypeText},
+	}}
+	fields := []storage.FormField{
+		{Id: 10, FieldKey: keyed("a"), FieldTitle: "A", FieldType: storage.FieldTypeText, Position: 1},
+		{Id: 11, FieldTitle: "B", FieldType: storage.FieldTypeText, Position: 2},
+		{Id: 12, FieldKey: keyed("c"), FieldTitle: "C", FieldType: storage.FieldTypeText, Position: 3},
+	}
+	plan := diffFormPlan(definition, &storage.Form{Id: 1, FormTitle: "Retro", FormDescription: "How did it go?"}, fields)
+
+	want := map[string]int{"delete_field c": 12, "update_field b": 11, "update_field a": 10}
+	if len(plan.Changes) != len(want) {
+		t.Fatalf("plan has %d changes, want %d", len(plan.Changes), len(want))
+	}
+	for _, change := range plan.Changes {
+		id, ok := want[change.Action+" "+change.FieldKey]
+		if !ok || change.FieldId == nil || *change.FieldId != id {
+			t.Fatalf("%s %s points at field %v, want %d", change.Action, change.FieldKey, change.FieldId, id)
+		}
+		if change.Action == planUpdateField && (change.spec.FieldKey == nil || *change.spec.FieldKey != change.FieldKey) {
+			t.Fatalf("update of %s carries spec %+v", change.FieldKey, change.spec)
+		}
+	}
+	// the adopted field keeps what the definition says about it, including its new position
+	for _, change := range plan.Changes {
+		if change.FieldKey == "b" && change.spec.Position != 1 {
+			t.Fatalf("b is moved to position %d, want 1", change.spec.Position)
+		}
+	}
+}
+
+func TestFieldDiffs(t *testing.T) {
+	spec := fieldSpec(FieldDefinition{Key: "mood", Title: "Mood", Type: storage.FieldTypeChoice, Required: true, Options: []string{"good", "bad"}}, 2)
+	if spec.Position != 3 || spec.FieldKey == nil || *spec.FieldKey != "mood" {
+		t.Fatalf("fieldSpec returned %+v, want position 3 and key mood", spec)
+	}
+
+	created := fieldDiffs(nil, spec)
+	if got := summarize([]PlanChange{{Action: planCreateField, Diffs: created}}); got[0] != "create_field title,type,required,position,options" {
+		t.Fatalf("a new field has diffs %q", got)
+	}
+	for _, diff := range created {
+		if diff.From != nil {
+			t.Fatalf("a new field's %s has a from value %v", diff.Attribute, diff.From)
+		}
+	}
+	if diffs := fieldDiffs(nil, fieldSpec(FieldDefinition{Key: "notes", Title: "Notes", Type: storage.FieldTypeText}, 0)); len(diffs) != 4 {
+		t.Fatalf("a new field without options has %d diffs, want 4", len(diffs))
+	}
+
+	field := &storage.FormField{Id: 1, FieldKey: keyed("mood"), FieldTitle: "Mood", FieldType: storage.FieldTypeChoice, Required: true, Position: 3, Options: []string{"good", "bad"}}
+	if diffs := fieldDiffs(field, spec); len(diffs) != 0 {
+		t.Fatalf("a matching field has diffs %+v", diffs)
+	}
+	field.Required = false
+	diffs := fieldDiffs(field, spec)
+	if len(diffs) != 1 || diffs[0] != (AttributeDiff{Attribute: "required", From: false, To: true}) {
+		t.Fatalf("fieldDiffs returned %+v, want required from false to true", diffs)
+	}
+}
+
+func TestNewFormPlan(t *testing.T) {
+	plan := newFormPlan(&FormDefinition{Key: "retro", Title: "Retro", Description: "How did it go?", Fields: []FieldDefinition{
+		{Key: "a", Title: "A", Type: storage.FieldTypeText},
+		{Key: "b", Title: "B", Type: storage.FieldTypeText},
+	}})
+	want := []string{"create_form title,description", "create_field a title,type,required,position", "create_field b title,type,required,position"}
+	if got := summarize(plan.Changes); plan.FormId != nil || !slices.Equal(got, want) {
+		t.Fatalf("plan for form %v has changes %q, want %q", plan.FormId, got, want)
+	}
+	if plan.Changes[2].spec.Position != 2 {
+		t.Fatalf("the second field is created at position %d", plan.Changes[2].spec.Position)
+	}
+}
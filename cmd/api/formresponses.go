@@ -1,12 +1,15 @@
 package main
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"net/mail"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dhruv15803/internal/storage"
 )
@@ -24,74 +27,54 @@ type ResponseField struct {
 func (s *APIServer) createFormResponse(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "invalid user id", http.StatusUnauthorized)
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
 		return
 	}
 	var req CreateFormResponseRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeJSONError(w, "invalid request body", http.StatusBadRequest)
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	form, err := s.storage.Forms.GetFormById(req.FormId)
+	form, err := s.storage.Forms.GetFormById(r.Context(), req.FormId)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.writeJSONError(w, "form not found", http.StatusNotFound)
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, "form not found", http.StatusNotFound)
 			return
 		}
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
 	if !form.IsReady {
-		s.writeJSONError(w, "form is not ready to accept responses", http.StatusBadRequest)
+		s.writeProblem(w, r, "form is not ready to accept responses", http.StatusBadRequest)
 		return
 	}
 
-	formFields, err := s.storage.FormFields.GetFormFieldsByFormId(form.Id)
+	// answers are checked against the published version, edits to the fields since are only a draft
+	version, err := s.publishedVersion(r.Context(), form)
 	if err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
-	validFieldIDs := make(map[int]bool)
-	for _, field := range formFields {
-		validFieldIDs[field.Id] = true
-	}
-
-	for _, respField := range req.ResponseFields {
-		if _, exists := validFieldIDs[respField.FormFieldId]; !exists {
-			s.writeJSONError(w, "invalid field id", http.StatusBadRequest)
-			return
-		}
-	}
-
-	responseFields := []struct {
-		FieldValue  string
-		FormFieldId int
-	}{}
-
-	for _, respField := range req.ResponseFields {
-		responseFields = append(responseFields, struct {
-			FieldValue  string
-			FormFieldId int
-		}{
-			FieldValue:  respField.FieldValue,
-			FormFieldId: respField.FormFieldId,
-		})
+	if !s.validAnswers(w, r, version.FormFields, req.ResponseFields) || !s.answeredRequired(w, r, version.FormFields, req.ResponseFields) {
+		return
 	}
+	responseFields := answerRows(req.ResponseFields)
 
-	formResponse, err := s.storage.FormResponse.CreateFormResponse(form.Id, userId)
+	formResponse, err := s.storage.FormResponse.CreateFormResponse(r.Context(), form.Id, userId, &version.Version)
 	if err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
-	createdFields, err := s.storage.FormResponse.CreateResponseFields(formResponse.Id, responseFields)
+	createdFields, err := s.storage.FormResponse.CreateResponseFields(r.Context(), formResponse.Id, responseFields)
 	if err != nil {
-		s.writeJSONError(w, "something went wrong while saving response fields", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
+	s.metrics.responsesSubmitted.Inc()
 
 	resp := struct {
 		FormResponseId int                     `json:"form_response_id"`
@@ -102,117 +85,250 @@ func (s *APIServer) createFormResponse(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err = s.writeJSON(w, resp, http.StatusCreated); err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
 }
 
 func (s *APIServer) getFormResponses(w http.ResponseWriter, r *http.Request) {
-	// can only read responses if u created the form
+	// can only read responses if u are atleast a viewer on the form
 	// /{formId}
 	// parse form id from r.PathValue
 	// get form
-	// check if  userId==form.UserId
+	// check the user's role on the form
 	// query for responses
 	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
 	if err != nil {
-		s.writeJSONError(w, "invalid form ID", http.StatusBadRequest)
+		s.writeProblem(w, r, "invalid form ID", http.StatusBadRequest)
 		return
 	}
 
 	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "invalid user ID", http.StatusUnauthorized)
+		s.writeProblem(w, r, "invalid user ID", http.StatusUnauthorized)
 		return
 	}
 
-	form, err := s.storage.Forms.GetFormById(int(formId))
+	form, err := s.storage.Forms.GetFormById(r.Context(), int(formId))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.writeJSONError(w, "form not found", http.StatusNotFound)
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, "form not found", http.StatusNotFound)
 			return
 		}
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
-	if form.UserId != userId {
-		s.writeJSONError(w, "unauthrorized access to form responses", http.StatusUnauthorized)
+	allowed, err := s.authorizeForm(r.Context(), form, userId, storage.CollaboratorViewer)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+	if !allowed {
+		s.writeProblem(w, r, "user not authorized to read form responses", http.StatusForbidden)
 		return
 	}
 
-	formResponses, err := s.storage.FormResponse.GetFormResponsesByFormId(form.Id)
+	formResponses, err := s.storage.FormResponse.GetFormResponsesByFormId(r.Context(), form.Id)
 	if err != nil {
-		s.writeJSONError(w, "something went wrong while retrieving form responses", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
 	if err := s.writeJSON(w, formResponses, http.StatusOK); err != nil {
-		s.writeJSONError(w, "something went wrong while writing response", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 	}
 }
 
-func (s *APIServer) getResponseFields(w http.ResponseWriter, r *http.Request) {
-
+// responseForRequest loads the response from the {formResponseId} path parameter with the form it answers,
+// it writes the error response itself and returns nil when the handler should stop
+func (s *APIServer) responseForRequest(w http.ResponseWriter, r *http.Request) (*storage.FormResponse, *storage.Form) {
 	formResponseId, err := strconv.ParseInt(r.PathValue("formResponseId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid form response id", http.StatusBadRequest)
+		return nil, nil
+	}
 
+	formResponse, err := s.storage.FormResponse.GetFormResponseById(r.Context(), int(formResponseId))
 	if err != nil {
-		s.writeJSONError(w, "invalid form response id", http.StatusBadRequest)
-		return
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("response with id %d not found", formResponseId), http.StatusNotFound)
+			return nil, nil
+		}
+		s.writeError(w, r, err)
+		return nil, nil
 	}
+	form, err := s.storage.Forms.GetFormById(r.Context(), formResponse.FormId)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, "form that you're responding to not found", http.StatusNotFound)
+			return nil, nil
+		}
+		s.writeError(w, r, err)
+		return nil, nil
+	}
+
+	return formResponse, form
+}
 
+// canReadResponse is true for the respondent and for users who can view the form's responses,
+// it writes the error response itself when it isn't
+func (s *APIServer) canReadResponse(w http.ResponseWriter, r *http.Request, formResponse *storage.FormResponse, form *storage.Form, userId int) bool {
+	if formResponse.RespondentId == userId {
+		return true
+	}
+	allowed, err := s.authorizeForm(r.Context(), form, userId, storage.CollaboratorViewer)
+	if err != nil {
+		s.serverError(w, r, err)
+		return false
+	}
+	if !allowed {
+		s.writeProblem(w, r, "user not authorized to read form responses", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (s *APIServer) getResponseFields(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "invalid user ID", http.StatusUnauthorized)
+		s.writeProblem(w, r, "invalid user ID", http.StatusUnauthorized)
 		return
 	}
 
-	// can only read  form responses if the form belongs to u or if you were the respondent
+	// can only read  form responses if u can view the form's responses or if you were the respondent
 	// so can only read form response fields for the same
-	formResponse, err := s.storage.FormResponse.GetFormResponseById(int(formResponseId))
-	if err != nil {
-		s.writeJSONError(w, fmt.Sprintf("response with id %d not found", formResponseId), http.StatusNotFound)
+	formResponse, form := s.responseForRequest(w, r)
+	if formResponse == nil {
 		return
 	}
-	formId := formResponse.FormId
-	form, err := s.storage.Forms.GetFormById(formId)
-	if err != nil {
-		s.writeJSONError(w, "form that you're responding to not found", http.StatusNotFound)
+	if !s.canReadResponse(w, r, formResponse, form, userId) {
 		return
 	}
 
-	if form.UserId != userId && formResponse.RespondentId != userId {
-		s.writeJSONError(w, "user not authorized to read form responses", http.StatusUnauthorized)
-		return
-	}
-
-	responseFields, err := s.storage.FormResponse.GetResponseFieldsByFormResponseId(formResponse.Id)
+	responseFields, err := s.storage.FormResponse.GetResponseFieldsByFormResponseId(r.Context(), formResponse.Id)
 	if err != nil {
-		s.writeJSONError(w, "failed to fetch response fields", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
 	if err := s.writeJSON(w, responseFields, http.StatusOK); err != nil {
-		s.writeJSONError(w, "failed to write response", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 	}
 }
 
 func (s *APIServer) getMyResponses(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "invalid user ID", http.StatusUnauthorized)
+		s.writeProblem(w, r, "invalid user ID", http.StatusUnauthorized)
 		return
 	}
 
-	formResponses, err := s.storage.FormResponse.GetFormResponsesByRespondentId(userId)
+	formResponses, err := s.storage.FormResponse.GetFormResponsesByRespondentId(r.Context(), userId)
 	if err != nil {
-		log.Println(err.Error())
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 
 	if err = s.writeJSON(w, formResponses, http.StatusOK); err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
+	}
+}
+
+// validAnswers checks every answer against the field it answers out of fields,
+// it writes the problem itself and returns false when an answer doesn't pass
+func (s *APIServer) validAnswers(w http.ResponseWriter, r *http.Request, fields []storage.FormField, answers []ResponseField) bool {
+	validFields := make(map[int]storage.FormField)
+	for _, field := range fields {
+		validFields[field.Id] = field
+	}
+
+	var fieldErrors []FieldError
+	for i, respField := range answers {
+		field, exists := validFields[respField.FormFieldId]
+		if !exists {
+			s.writeFieldProblem(w, r, "response_fields", "invalid field id")
+			return false
+		}
+		if message := answerError(field, respField.FieldValue); message != "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: fmt.Sprintf("response_fields[%d].field_value", i), Rule: field.FieldType, Message: message})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		s.writeValidationProblem(w, r, fieldErrors[0].Message, fieldErrors...)
+		return false
+	}
+	return true
+}
+
+// answeredRequired checks every required field out of fields has an answer that isn't blank, it writes
+// the problem itself and returns false when one hasn't. drafts skip it, submitting a response doesn't
+func (s *APIServer) answeredRequired(w http.ResponseWriter, r *http.Request, fields []storage.FormField, answers []ResponseField) bool {
+	answered := make(map[int]bool)
+	for _, answer := range answers {
+		if strings.TrimSpace(answer.FieldValue) != "" {
+			answered[answer.FormFieldId] = true
+		}
+	}
+
+	var fieldErrors []FieldError
+	for _, field := range fields {
+		if field.Required && !answered[field.Id] {
+			fieldErrors = append(fieldErrors, FieldError{Field: "response_fields", Rule: "required", Message: fmt.Sprintf("%s is required", field.FieldTitle)})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		s.writeValidationProblem(w, r, fieldErrors[0].Message, fieldErrors...)
+		return false
+	}
+	return true
+}
+
+// answerRows turns the answers of a request into the rows the response store writes
+func answerRows(answers []ResponseField) []struct {
+	FieldValue  string
+	FormFieldId int
+} {
+	rows := []struct {
+		FieldValue  string
+		FormFieldId int
+	}{}
+	for _, answer := range answers {
+		rows = append(rows, struct {
+			FieldValue  string
+			FormFieldId int
+		}{
+			FieldValue:  answer.FieldValue,
+			FormFieldId: answer.FormFieldId,
+		})
+	}
+	return rows
+}
+
+// answerError checks an answer against the type of its field, "" means the answer is fine.
+// empty answers are left alone, whether a field has to be answered is up to required.
+func answerError(field storage.FormField, value string) string {
+	if strings.TrimSpace(value) == "" {
+		return ""
+	}
+	switch field.FieldType {
+	case storage.FieldTypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Sprintf("%s should be a number", field.FieldTitle)
+		}
+	case storage.FieldTypeEmail:
+		if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+			return fmt.Sprintf("%s should be an email address", field.FieldTitle)
+		}
+	case storage.FieldTypeDate:
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return fmt.Sprintf("%s should be a date like 2006-01-02", field.FieldTitle)
+		}
+	case storage.FieldTypeChoice:
+		if !slices.Contains(field.Options, value) {
+			return fmt.Sprintf("%s should be one of %s", field.FieldTitle, strings.Join(field.Options, ", "))
+		}
 	}
+	return ""
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dhruv15803/internal/config"
+	"github.com/dhruv15803/internal/storage"
+)
+
+// only a missing response is a 404, any other storage error is the server's fault
+func TestResponseForRequestErrors(t *testing.T) {
+	s, err := NewAPIServer(&config.Config{}, storage.NewMemoryStorage(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		ctx    func() context.Context
+		status int
+	}{
+		{name: "missing response", ctx: context.Background, status: http.StatusNotFound},
+		{name: "storage failure", ctx: func() context.Context {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return ctx
+		}, status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/form-responses/response-fields/42", nil).WithContext(tt.ctx())
+			r.SetPathValue("formResponseId", "42")
+			w := httptest.NewRecorder()
+
+			var formResponse *storage.FormResponse
+			var form *storage.Form
+			requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				formResponse, form = s.responseForRequest(w, r)
+			})).ServeHTTP(w, r)
+			if formResponse != nil || form != nil {
+				t.Fatal("responseForRequest returned a response that doesn't exist")
+			}
+			decodeProblem(t, w.Result(), tt.status)
+		})
+	}
+}
@@ -1,46 +1,89 @@
 package main
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
+
+	"github.com/dhruv15803/internal/storage"
 )
 
 type CreateFormRequest struct {
-	FormTitle       string `json:"form_title"`
-	FormDescription string `json:"form_description"`
+	FormTitle       string  `json:"form_title"`
+	FormDescription string  `json:"form_description"`
+	WorkspaceId     *int    `json:"workspace_id"`
+	FormKey         *string `json:"form_key"`
 }
 
 type CreateFormFieldRequest struct {
-	FieldTitle string `json:"field_title"`
-	Required   bool   `json:"required"`
-	FormId     int    `json:"form_id"`
+	FieldTitle string   `json:"field_title"`
+	Required   bool     `json:"required"`
+	FormId     int      `json:"form_id"`
+	FieldType  string   `json:"field_type"`
+	Options    []string `json:"options"`
 }
 
+// UpdateFormFieldRequest leaves the type and options as they are when they're left out
 type UpdateFormFieldRequest struct {
-	FieldTitle string `json:"field_title"`
-	Required   bool   `json:"required"`
+	FieldTitle string   `json:"field_title"`
+	Required   bool     `json:"required"`
+	FieldType  string   `json:"field_type"`
+	Options    []string `json:"options"`
+}
+
+// workspaceFromQuery reads the optional ?workspace_id= filter and checks the user is a member of that workspace,
+// ok is false when an error response has already been written
+func (s *APIServer) workspaceFromQuery(w http.ResponseWriter, r *http.Request, userId int) (workspaceId *int, ok bool) {
+	v := r.URL.Query().Get("workspace_id")
+	if v == "" {
+		return nil, true
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil {
+		s.writeFieldProblem(w, r, "workspace_id", "invalid workspace_id")
+		return nil, false
+	}
+	role, err := s.workspaceRole(r.Context(), id, userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return nil, false
+	}
+	if role == "" {
+		s.writeProblem(w, r, "user is not a member of this workspace", http.StatusForbidden)
+		return nil, false
+	}
+	return &id, true
 }
 
 func (s *APIServer) getAllForms(w http.ResponseWriter, r *http.Request) {
-	_, ok := r.Context().Value(userIDKey).(int)
+	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "unauthorized: unable to retrieve user from context", http.StatusUnauthorized)
+		s.writeProblem(w, r, "unauthorized: unable to retrieve user from context", http.StatusUnauthorized)
 		return
 	}
 
-	forms, err := s.storage.Forms.GetAllForms()
+	workspaceId, ok := s.workspaceFromQuery(w, r, userId)
+	if !ok {
+		return
+	}
+
+	var forms []storage.Form
+	var err error
+	if workspaceId != nil {
+		forms, err = s.storage.Forms.GetFormsByWorkspaceId(r.Context(), *workspaceId)
+	} else {
+		forms, err = s.storage.Forms.GetAllForms(r.Context(), userId)
+	}
 	if err != nil {
-		s.writeJSONError(w, fmt.Sprintf("internal server error: %v", err), http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 
 	if err = s.writeJSON(w, forms, http.StatusOK); err != nil {
-		s.writeJSONError(w, fmt.Sprintf("internal server error: %v", err), http.StatusInternalServerError)
+		s.writeError(w, r, err)
 	}
 }
 
@@ -48,58 +91,92 @@ func (s *APIServer) createForm(w http.ResponseWriter, r *http.Request) {
 	// Retrieve the authenticated user's userId from the request context
 	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "unauthorized: unable to retrieve user from context", http.StatusUnauthorized)
+		s.writeProblem(w, r, "unauthorized: unable to retrieve user from context", http.StatusUnauthorized)
 		return
 	}
 
 	// Decode the JSON body into the CreateFormRequest struct
 	var req CreateFormRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeJSONError(w, "bad request: invalid JSON payload", http.StatusBadRequest)
+		s.writeProblem(w, r, "bad request: invalid JSON payload", http.StatusBadRequest)
 		return
 	}
 
 	// Validate the request payload
+	var fieldErrors []FieldError
 	if strings.TrimSpace(req.FormTitle) == "" {
-		s.writeJSONError(w, "bad request: form title is required", http.StatusBadRequest)
-		return
+		fieldErrors = append(fieldErrors, FieldError{Field: "form_title", Message: "form title is required"})
 	}
-
 	if strings.TrimSpace(req.FormDescription) == "" {
-		s.writeJSONError(w, "bad request: form description is required", http.StatusBadRequest)
+		fieldErrors = append(fieldErrors, FieldError{Field: "form_description", Message: "form description is required"})
+	}
+	if req.FormKey != nil && !definitionKeyPattern.MatchString(*req.FormKey) {
+		fieldErrors = append(fieldErrors, FieldError{Field: "form_key", Message: "form key may only hold lowercase letters, digits and . _ / -"})
+	}
+	if len(fieldErrors) > 0 {
+		s.writeValidationProblem(w, r, "bad request: "+fieldErrors[0].Message, fieldErrors...)
 		return
 	}
 
+	// forms can only be created in workspaces the user is a member of
+	if req.WorkspaceId != nil {
+		role, err := s.workspaceRole(r.Context(), *req.WorkspaceId, userId)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		if role == "" {
+			s.writeProblem(w, r, "user is not a member of this workspace", http.StatusForbidden)
+			return
+		}
+	}
+
 	// Create the form using the storage layer
-	form, err := s.storage.Forms.CreateForm(req.FormTitle, req.FormDescription, userId)
+	form, err := s.storage.Forms.CreateForm(r.Context(), req.FormTitle, req.FormDescription, req.FormKey, userId, req.WorkspaceId)
 	if err != nil {
-		s.writeJSONError(w, fmt.Sprintf("internal server error: %v", err), http.StatusInternalServerError)
+		if errors.Is(err, storage.ErrConflict) {
+			s.writeProblem(w, r, fmt.Sprintf("form key %q is taken", *req.FormKey), http.StatusConflict)
+			return
+		}
+		s.writeError(w, r, err)
 		return
 	}
+	s.metrics.formsCreated.Inc()
 
 	// Respond with the created form
 	if err := s.writeJSON(w, form, http.StatusCreated); err != nil {
-		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 	}
 }
 
 func (s *APIServer) myForms(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "unauthorized: unable to retrieve user from context", http.StatusUnauthorized)
+		s.writeProblem(w, r, "unauthorized: unable to retrieve user from context", http.StatusUnauthorized)
 		return
 	}
 
-	// Retrieve the forms for the authenticated user from the storage layer
-	forms, err := s.storage.Forms.GetFormsByUserId(userId)
+	workspaceId, ok := s.workspaceFromQuery(w, r, userId)
+	if !ok {
+		return
+	}
+
+	// a member sees every form in the workspace, not only the ones they created
+	var forms []storage.Form
+	var err error
+	if workspaceId != nil {
+		forms, err = s.storage.Forms.GetFormsByWorkspaceId(r.Context(), *workspaceId)
+	} else {
+		forms, err = s.storage.Forms.GetFormsByUserId(r.Context(), userId)
+	}
 	if err != nil {
-		s.writeJSONError(w, fmt.Sprintf("internal server error: %v", err), http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 
 	// Respond with the list of forms
 	if err := s.writeJSON(w, forms, http.StatusOK); err != nil {
-		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 	}
 }
 
@@ -107,111 +184,132 @@ func (s *APIServer) createFormField(w http.ResponseWriter, r *http.Request) {
 	// an authenticated user is making the request
 	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "invalid user id", http.StatusUnauthorized)
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
 		return
 	}
 	var payload CreateFormFieldRequest
 	err := json.NewDecoder(r.Body).Decode(&payload)
 	if err != nil {
-		s.writeJSONError(w, "invalid request body", http.StatusBadRequest)
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
 		return
 	}
 	fieldTitle := strings.TrimSpace(payload.FieldTitle)
 	formId := payload.FormId
 	isFieldRequired := payload.Required
+	fieldType := strings.TrimSpace(payload.FieldType)
+	if fieldType == "" {
+		fieldType = storage.FieldTypeText
+	}
+	options := trimOptions(payload.Options)
 
 	if fieldTitle == "" {
-		s.writeJSONError(w, "field title cannot be empty", http.StatusBadRequest)
+		s.writeFieldProblem(w, r, "field_title", "field title cannot be empty")
+		return
+	}
+	if fieldErrors := fieldTypeErrors("field_type", "options", fieldType, options); len(fieldErrors) > 0 {
+		s.writeValidationProblem(w, r, fieldErrors[0].Message, fieldErrors...)
 		return
 	}
 
 	// get form and check if form.user_id = userId , if not then logged in user cannot create field on this for
-	form, err := s.storage.Forms.GetFormById(formId)
+	form, err := s.storage.Forms.GetFormById(r.Context(), formId)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.writeJSONError(w, fmt.Sprintf("Form with id %d not found", formId), http.StatusNotFound)
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("Form with id %d not found", formId), http.StatusNotFound)
 			return
 		} else {
-			log.Println(err.Error())
-			s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+			s.writeError(w, r, err)
 			return
 		}
 	}
 
-	if userId != form.UserId {
-		s.writeJSONError(w, "user unauthorized to make field on form", http.StatusUnauthorized)
+	allowed, err := s.authorizeForm(r.Context(), form, userId, storage.CollaboratorEditor)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if !allowed {
+		s.writeProblem(w, r, "user unauthorized to make field on form", http.StatusForbidden)
 		return
 	}
 
-	// ok so the form belongs to user making the request , and form exists
+	// ok so the user making the request can edit the form , and form exists
 	// can create field for form now
-	field, err := s.storage.FormFields.CreateFormField(fieldTitle, isFieldRequired, form.Id)
+	field, err := s.storage.FormFields.CreateFormField(r.Context(), form.Id, storage.FieldSpec{
+		FieldTitle: fieldTitle,
+		FieldType:  fieldType,
+		Required:   isFieldRequired,
+		Options:    options,
+	})
 	if err != nil {
-		log.Println(err.Error())
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 	// once a field on a form is created or deleted , the is_ready fiels is updated if the count(*) from form_fields is > 0 where form_id=form.Id
-	if err = s.storage.FormFields.UpdateFormIsReady(form.Id); err != nil {
-		log.Println(err.Error())
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+	if err = s.storage.FormFields.UpdateFormIsReady(r.Context(), form.Id); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
 	if err = s.writeJSON(w, field, http.StatusCreated); err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 	}
 }
 
 func (s *APIServer) deleteFormField(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "invalid user id", http.StatusUnauthorized)
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
 		return
 	}
 	fieldId, err := strconv.ParseInt(r.PathValue("fieldId"), 10, 64)
 	if err != nil {
-		s.writeJSONError(w, "invalid request parameter", http.StatusBadRequest)
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
 		return
 	}
 
-	formField, err := s.storage.FormFields.GetFormFieldById(int(fieldId))
+	formField, err := s.storage.FormFields.GetFormFieldById(r.Context(), int(fieldId))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.writeJSONError(w, fmt.Sprintf("Field with id %d not found", fieldId), http.StatusNotFound)
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("Field with id %d not found", fieldId), http.StatusNotFound)
 			return
 		} else {
-			s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+			s.serverError(w, r, err)
 			return
 		}
 	}
 
 	// form which we're trying to delete a field from
-	form, err := s.storage.Forms.GetFormById(formField.FormId)
+	form, err := s.storage.Forms.GetFormById(r.Context(), formField.FormId)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.writeJSONError(w, fmt.Sprintf("Form with id %d not found", formField.FormId), http.StatusNotFound)
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("Form with id %d not found", formField.FormId), http.StatusNotFound)
 			return
 		} else {
-			s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+			s.serverError(w, r, err)
 			return
 		}
 	}
 
-	if userId != form.UserId {
-		s.writeJSONError(w, "user not authorized to delete field on this form", http.StatusUnauthorized)
+	allowed, err := s.authorizeForm(r.Context(), form, userId, storage.CollaboratorEditor)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+	if !allowed {
+		s.writeProblem(w, r, "user not authorized to delete field on this form", http.StatusForbidden)
 		return
 	}
 
-	err = s.storage.FormFields.DeleteFormFieldById(formField.Id)
+	err = s.storage.FormFields.DeleteFormFieldById(r.Context(), formField.Id)
 	if err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 	// update is_ready field
-	err = s.storage.FormFields.UpdateFormIsReady(form.Id)
+	err = s.storage.FormFields.UpdateFormIsReady(r.Context(), form.Id)
 	if err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
@@ -219,7 +317,7 @@ func (s *APIServer) deleteFormField(w http.ResponseWriter, r *http.Request) {
 		Message string `json:"message"`
 	}
 	if err = s.writeJSON(w, Envelope{Message: fmt.Sprintf("field with id %d deleted", fieldId)}, http.StatusOK); err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 	}
 }
 
@@ -227,21 +325,21 @@ func (s *APIServer) updateFormField(w http.ResponseWriter, r *http.Request) {
 	// Extract the authenticated user's ID
 	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "invalid user id", http.StatusUnauthorized)
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
 		return
 	}
 
 	// Parse `fieldId` from the URL path
 	fieldId, err := strconv.ParseInt(r.PathValue("fieldId"), 10, 64)
 	if err != nil {
-		s.writeJSONError(w, "invalid request parameter", http.StatusBadRequest)
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
 		return
 	}
 
 	// Decode the JSON payload
 	var payload UpdateFormFieldRequest
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		s.writeJSONError(w, "invalid request body", http.StatusBadRequest)
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
@@ -250,45 +348,69 @@ func (s *APIServer) updateFormField(w http.ResponseWriter, r *http.Request) {
 
 	// Validate the `fieldTitle` if it's part of the update
 	if fieldTitle == "" {
-		s.writeJSONError(w, "field title cannot be empty", http.StatusBadRequest)
+		s.writeFieldProblem(w, r, "field_title", "field title cannot be empty")
 		return
 	}
 
 	// Fetch the form field and its associated form
-	formField, err := s.storage.FormFields.GetFormFieldById(int(fieldId))
+	formField, err := s.storage.FormFields.GetFormFieldById(r.Context(), int(fieldId))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.writeJSONError(w, fmt.Sprintf("field with id %d not found", fieldId), http.StatusNotFound)
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("field with id %d not found", fieldId), http.StatusNotFound)
 			return
 		}
-		s.writeJSONError(w, "something went wrong while fetching form field", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
-	form, err := s.storage.Forms.GetFormById(formField.FormId)
+	form, err := s.storage.Forms.GetFormById(r.Context(), formField.FormId)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.writeJSONError(w, fmt.Sprintf("form with id %d not found", formField.FormId), http.StatusNotFound)
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("form with id %d not found", formField.FormId), http.StatusNotFound)
 			return
 		}
-		s.writeJSONError(w, "something went wrong while fetching form", http.StatusInternalServerError)
+		s.serverError(w, r, err)
+		return
+	}
+
+	// Verify the authenticated user can edit the form
+	allowed, err := s.authorizeForm(r.Context(), form, userId, storage.CollaboratorEditor)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+	if !allowed {
+		s.writeProblem(w, r, "user not authorized to update this field", http.StatusForbidden)
 		return
 	}
 
-	// Verify the authenticated user owns the form
-	if userId != form.UserId {
-		s.writeJSONError(w, "user not authorized to update this field", http.StatusUnauthorized)
+	fieldType := strings.TrimSpace(payload.FieldType)
+	options := trimOptions(payload.Options)
+	if fieldType == "" {
+		fieldType = formField.FieldType
+		if payload.Options == nil {
+			options = formField.Options
+		}
+	}
+	if fieldErrors := fieldTypeErrors("field_type", "options", fieldType, options); len(fieldErrors) > 0 {
+		s.writeValidationProblem(w, r, fieldErrors[0].Message, fieldErrors...)
 		return
 	}
 
-	updatedFormField, err := s.storage.FormFields.UpdateFormField(formField.Id, fieldTitle, isRequired)
+	updatedFormField, err := s.storage.FormFields.UpdateFormField(r.Context(), formField.Id, storage.FieldSpec{
+		FieldKey:   formField.FieldKey,
+		FieldTitle: fieldTitle,
+		FieldType:  fieldType,
+		Required:   isRequired,
+		Options:    options,
+	})
 	if err != nil {
-		s.writeJSONError(w, "failed to update form field", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
 	if err = s.writeJSON(w, updatedFormField, http.StatusOK); err != nil {
-		s.writeJSONError(w, "something went wrong while sending response", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 	}
 }
 
@@ -297,73 +419,98 @@ func (s *APIServer) getFormWithFields(w http.ResponseWriter, r *http.Request) {
 	// get form with the form fields using join between forms and form_fields
 	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
 	if err != nil {
-		s.writeJSONError(w, "invalid request parameter", http.StatusBadRequest)
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
 		return
 	}
 
-	form, err := s.storage.Forms.GetFormById(int(formId))
+	form, err := s.storage.Forms.GetFormById(r.Context(), int(formId))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.writeJSONError(w, fmt.Sprintf("form with id %d not found", formId), http.StatusNotFound)
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("form with id %d not found", formId), http.StatusNotFound)
 			return
 		} else {
-			s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+			s.serverError(w, r, err)
 			return
 		}
 	}
 
 	// form that we're trying to get exists
-	formWithFields, err := s.storage.Forms.GetFormByIdWithFieldsAndUser(form.Id)
+	formWithFields, err := s.storage.Forms.GetFormByIdWithFieldsAndUser(r.Context(), form.Id)
 	if err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
+	// respondents see the published version, only editors see the draft being worked on
+	if form.PublishedVersion != nil {
+		userId, _ := r.Context().Value(userIDKey).(int)
+		editor, err := s.authorizeForm(r.Context(), form, userId, storage.CollaboratorEditor)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		if !editor {
+			version, err := s.storage.FormVersions.GetFormVersion(r.Context(), form.Id, *form.PublishedVersion)
+			if err != nil {
+				s.serverError(w, r, err)
+				return
+			}
+			formWithFields.FormTitle = version.FormTitle
+			formWithFields.FormDescription = version.FormDescription
+			formWithFields.FormFields = version.FormFields
+		}
+	}
+
 	if err = s.writeJSON(w, formWithFields, http.StatusOK); err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 	}
 }
 
 func (s *APIServer) deleteFormHandler(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "user not authorized", http.StatusUnauthorized)
+		s.writeProblem(w, r, "user not authorized", http.StatusUnauthorized)
 		return
 	}
 
 	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
 	if err != nil {
-		s.writeJSONError(w, "invalid path parameter", http.StatusBadRequest)
+		s.writeProblem(w, r, "invalid path parameter", http.StatusBadRequest)
 		return
 	}
 
-	form, err := s.storage.Forms.GetFormById(int(formId))
+	form, err := s.storage.Forms.GetFormById(r.Context(), int(formId))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.writeJSONError(w, fmt.Sprintf("form with id %d not found", formId), http.StatusNotFound)
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("form with id %d not found", formId), http.StatusNotFound)
 			return
 		} else {
-			s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+			s.serverError(w, r, err)
 			return
 		}
 	}
 
-	// before deleting , check if form.UserId ==userId
-	if form.UserId != userId {
-		s.writeJSONError(w, "user not authorized to delete form", http.StatusUnauthorized)
+	// before deleting , check that the user owns the form
+	allowed, err := s.authorizeForm(r.Context(), form, userId, storage.CollaboratorOwner)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if !allowed {
+		s.writeProblem(w, r, "user not authorized to delete form", http.StatusForbidden)
 		return
 	}
 
-	if err = s.storage.Forms.DeleteFormById(form.Id); err != nil {
-		s.writeJSONError(w, err.Error(), http.StatusInternalServerError)
+	if err = s.storage.Forms.DeleteFormById(r.Context(), form.Id); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
 	type Envelope struct {
 		Message string `json:"message"`
 	}
-	if err = s.writeJSON(w, Envelope{Message: fmt.Sprintf("form with id %d deleted", form.Id)}, http.StatusOK); err != nil {
-		s.writeJSON(w, "something went wrong", http.StatusInternalServerError)
+	if err = s.writeJSON(w, Envelope{Message: fmt.Sprintf("form with id %d moved to the trash", form.Id)}, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
 		return
 	}
 }
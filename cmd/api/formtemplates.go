@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+// CopyFormRequest is the optional body of duplicating a form or creating one from a template,
+// the title defaults to the source's and the workspace to the source's when the user is a member of it
+type CopyFormRequest struct {
+	FormTitle   *string `json:"form_title"`
+	WorkspaceId *int    `json:"workspace_id"`
+}
+
+type SetTemplateRequest struct {
+	Visibility string `json:"visibility"`
+}
+
+var templateVisibilities = []string{storage.TemplatePrivate, storage.TemplateWorkspace, storage.TemplatePublic}
+
+// copyForm deep copies source with its fields into a new form owned by userId
+func (s *APIServer) copyForm(w http.ResponseWriter, r *http.Request, userId int, source *storage.Form, defaultTitle string) {
+	var req CopyFormRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		s.writeProblem(w, r, "bad request: invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	title := defaultTitle
+	if req.FormTitle != nil {
+		title = strings.TrimSpace(*req.FormTitle)
+		if title == "" {
+			s.writeFieldProblem(w, r, "form_title", "form title can't be empty")
+			return
+		}
+	}
+
+	workspaceId := req.WorkspaceId
+	if workspaceId != nil {
+		// copies can only go into workspaces the user is a member of, like new forms
+		role, err := s.workspaceRole(r.Context(), *workspaceId, userId)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		if role == "" {
+			s.writeProblem(w, r, "user is not a member of this workspace", http.StatusForbidden)
+			return
+		}
+	} else if source.WorkspaceId != nil {
+		role, err := s.workspaceRole(r.Context(), *source.WorkspaceId, userId)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		if role != "" {
+			workspaceId = source.WorkspaceId
+		}
+	}
+
+	form, err := s.storage.Forms.DuplicateForm(r.Context(), source.Id, title, userId, workspaceId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.metrics.formsCreated.Inc()
+
+	if err := s.writeJSON(w, form, http.StatusCreated); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// duplicateForm copies a form the user can view, the copy is theirs and starts without responses
+func (s *APIServer) duplicateForm(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	form := s.formForRequest(w, r, userId, storage.CollaboratorViewer)
+	if form == nil {
+		return
+	}
+	s.copyForm(w, r, userId, form, "Copy of "+form.FormTitle)
+}
+
+func (s *APIServer) setFormTemplate(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	// publishing a template shows the form to people it isn't shared with, so only owners can
+	form := s.formForRequest(w, r, userId, storage.CollaboratorOwner)
+	if form == nil {
+		return
+	}
+
+	var req SetTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeProblem(w, r, "bad request: invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	valid := false
+	for _, visibility := range templateVisibilities {
+		valid = valid || req.Visibility == visibility
+	}
+	if !valid {
+		s.writeFieldProblem(w, r, "visibility", "visibility should be one of "+strings.Join(templateVisibilities, ", "))
+		return
+	}
+	if req.Visibility == storage.TemplateWorkspace && form.WorkspaceId == nil {
+		s.writeFieldProblem(w, r, "visibility", "only forms in a workspace can be workspace templates")
+		return
+	}
+
+	template, err := s.storage.Forms.SetFormTemplate(r.Context(), form.Id, &req.Visibility)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.writeJSON(w, template, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// unsetFormTemplate makes the template a regular form again, forms created from it are left alone
+func (s *APIServer) unsetFormTemplate(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	form := s.formForRequest(w, r, userId, storage.CollaboratorOwner)
+	if form == nil {
+		return
+	}
+
+	updated, err := s.storage.Forms.SetFormTemplate(r.Context(), form.Id, nil)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.writeJSON(w, updated, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) getTemplates(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	templates, err := s.storage.Forms.GetTemplates(r.Context(), userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if templates == nil {
+		templates = []storage.Form{}
+	}
+
+	if err := s.writeJSON(w, templates, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// instantiateTemplate creates a form from a template the user can see, templates they can't see are not found
+func (s *APIServer) instantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	templates, err := s.storage.Forms.GetTemplates(r.Context(), userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	for _, template := range templates {
+		if template.Id == int(formId) {
+			s.copyForm(w, r, userId, &template, template.FormTitle)
+			return
+		}
+	}
+	s.writeProblem(w, r, fmt.Sprintf("template with id %d not found", formId), http.StatusNotFound)
+}
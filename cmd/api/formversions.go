@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+// publishedVersion returns the version respondents answer. a form that was never published gets
+// its current fields published as version 1 first, so every new response references a version.
+func (s *APIServer) publishedVersion(ctx context.Context, form *storage.Form) (*storage.FormVersion, error) {
+	if form.PublishedVersion != nil {
+		return s.storage.FormVersions.GetFormVersion(ctx, form.Id, *form.PublishedVersion)
+	}
+
+	version, err := s.storage.FormVersions.PublishFormVersion(ctx, form.Id, nil)
+	if errors.Is(err, storage.ErrConflict) {
+		// another response published it first
+		current, err := s.storage.Forms.GetFormById(ctx, form.Id)
+		if err != nil {
+			return nil, err
+		}
+		if current.PublishedVersion == nil {
+			return nil, fmt.Errorf("form %d has no published version after a publish conflict", form.Id)
+		}
+		return s.storage.FormVersions.GetFormVersion(ctx, form.Id, *current.PublishedVersion)
+	}
+	return version, err
+}
+
+// publishForm freezes the form's current fields as its next version, new responses are submitted against it
+func (s *APIServer) publishForm(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	form := s.formForRequest(w, r, userId, storage.CollaboratorEditor)
+	if form == nil {
+		return
+	}
+	// a published form stays ready while its fields are edited, the fields themselves have to be there to publish them
+	fields, err := s.storage.FormFields.GetFormFieldsByFormId(r.Context(), form.Id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if len(fields) == 0 {
+		s.writeProblem(w, r, "form needs at least one field to be published", http.StatusBadRequest)
+		return
+	}
+
+	version, err := s.storage.FormVersions.PublishFormVersion(r.Context(), form.Id, &userId)
+	if err != nil {
+		if errors.Is(err, storage.ErrConflict) {
+			s.writeProblem(w, r, "the form is being published by someone else, try again", http.StatusConflict)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.writeJSON(w, version, http.StatusCreated); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) getFormVersions(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	form := s.formForRequest(w, r, userId, storage.CollaboratorViewer)
+	if form == nil {
+		return
+	}
+
+	versions, err := s.storage.FormVersions.GetFormVersionsByFormId(r.Context(), form.Id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if versions == nil {
+		versions = []storage.FormVersion{}
+	}
+
+	if err := s.writeJSON(w, versions, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// getFormVersion returns a version as it was published, what the respondents of that version saw
+func (s *APIServer) getFormVersion(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	versionNumber, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	form := s.formForRequest(w, r, userId, storage.CollaboratorViewer)
+	if form == nil {
+		return
+	}
+
+	version, err := s.storage.FormVersions.GetFormVersion(r.Context(), form.Id, versionNumber)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("form %d has no version %d", form.Id, versionNumber), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.writeJSON(w, version, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// a readiness probe that hangs is as bad as one that fails, the db gets this long to answer
+const readinessTimeout = 2 * time.Second
+
+const (
+	checkOK      = "ok"
+	checkFailing = "failing"
+)
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// healthzHandler is the liveness probe, it only says the process is up and serving
+func (s *APIServer) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, healthResponse{Status: "ok"}, http.StatusOK)
+}
+
+// readyzHandler is the readiness probe, it fails while the db can't be reached, while the schema is
+// behind the migrations this build was shipped with and once the server has started shutting down
+// so the load balancer stops sending it traffic. the probe is public, so each check only says ok or
+// failing and the reason goes to the log
+func (s *APIServer) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]string{}
+	ready := true
+	fail := func(check string, reason string, attrs ...any) {
+		checks[check] = checkFailing
+		ready = false
+		requestLogger(r).Warn("readiness check failed", append([]any{slog.String("check", check), slog.String("reason", reason)}, attrs...)...)
+	}
+
+	if s.shuttingDown.Load() {
+		fail("server", "shutting down")
+	}
+
+	if s.db != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		if err := s.db.PingContext(ctx); err != nil {
+			fail("database", "ping failed", slog.Any("error", err))
+		} else {
+			checks["database"] = checkOK
+		}
+
+		if s.migrator != nil && checks["database"] == checkOK {
+			version, dirty, err := s.migrator.Version(ctx)
+			switch {
+			case err != nil:
+				fail("migrations", "reading the schema version failed", slog.Any("error", err))
+			case dirty:
+				fail("migrations", "schema version is dirty", slog.Int("version", version))
+			case version < s.migrator.Latest():
+				fail("migrations", "schema is behind", slog.Int("version", version), slog.Int("expected", s.migrator.Latest()))
+			default:
+				checks["migrations"] = checkOK
+			}
+		}
+	}
+
+	if !ready {
+		s.writeJSON(w, healthResponse{Status: "unavailable", Checks: checks}, http.StatusServiceUnavailable)
+		return
+	}
+	s.writeJSON(w, healthResponse{Status: "ready", Checks: checks}, http.StatusOK)
+}
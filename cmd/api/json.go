@@ -10,12 +10,3 @@ func (s *APIServer) writeJSON(w http.ResponseWriter, payload any, status int) er
 	w.WriteHeader(status)
 	return json.NewEncoder(w).Encode(payload)
 }
-
-func (s *APIServer) writeJSONError(w http.ResponseWriter, message string, status int) {
-	type Envelope struct {
-		Message string `json:"message"`
-	}
-	if err := s.writeJSON(w, Envelope{Message: message}, status); err != nil {
-		http.Error(w, "something went wrong", http.StatusInternalServerError)
-	}
-}
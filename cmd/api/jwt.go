@@ -1,19 +1,20 @@
 package main
 
 import (
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func (s *APIServer) GenerateJWT(userId int) (string, error) {
+// GenerateJWT signs a session token for the user, it is revoked once their token version moves on
+func (s *APIServer) GenerateJWT(userId int, tokenVersion int) (string, error) {
 	claims := jwt.MapClaims{
-		"userId": userId,
-		"exp":    time.Now().Add(time.Hour * 48).Unix(),
-		"iat":    time.Now().Unix(),
+		"userId":       userId,
+		"tokenVersion": tokenVersion,
+		"exp":          time.Now().Add(time.Hour * 48).Unix(),
+		"iat":          time.Now().Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	return token.SignedString([]byte(s.config.JWTSecret))
 }
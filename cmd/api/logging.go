@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+const (
+	requestIDKey  contextKey = "requestID"
+	requestLogKey contextKey = "requestLog"
+)
+
+// an incoming X-Request-ID longer than this is replaced instead of being copied into every log line
+const maxRequestIDLength = 128
+
+// newLogger logs JSON to stdout, anything below level is dropped
+func newLogger(level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+}
+
+// requestIDMiddleware gives every request an id, a well formed X-Request-ID sent by the client (or a proxy
+// in front of us) is kept so the request can be followed across services. the id is echoed back in the
+// response header, written on every log line about the request and put in problem documents.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestId) {
+			requestId = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestId)
+		ctx := context.WithValue(r.Context(), requestIDKey, requestId)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func validRequestID(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestId); i++ {
+		if requestId[i] < '!' || requestId[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIDKey).(string)
+	return requestId
+}
+
+// requestLog is filled in while the request is handled, the access log is written from it at the end.
+// it's a pointer in the context because AuthMiddleware only learns the user id further down the chain.
+type requestLog struct {
+	userId int
+}
+
+// setRequestUserId records who made the request for the access log
+func setRequestUserId(ctx context.Context, userId int) {
+	if requestLog, ok := ctx.Value(requestLogKey).(*requestLog); ok {
+		requestLog.userId = userId
+	}
+}
+
+// accessLogMiddleware writes one log line per request with its status, size and latency.
+// it has to run after requestIDMiddleware.
+func accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		info := &requestLog{}
+		ctx := context.WithValue(r.Context(), requestLogKey, info)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		attrs := []slog.Attr{
+			slog.String("request_id", requestIDFromContext(ctx)),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", ww.BytesWritten()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote_ip", clientIP(r)),
+		}
+		if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil && routeCtx.RoutePattern() != "" {
+			attrs = append(attrs, slog.String("route", routeCtx.RoutePattern()))
+		}
+		if info.userId != 0 {
+			attrs = append(attrs, slog.Int("user_id", info.userId))
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(ctx, level, "request", attrs...)
+	})
+}
+
+// requestLogger is the default logger with the request id attached
+func requestLogger(r *http.Request) *slog.Logger {
+	return slog.Default().With(slog.String("request_id", requestIDFromContext(r.Context())))
+}
+
+// serverError logs err and answers 500, the client only ever sees a generic message
+func (s *APIServer) serverError(w http.ResponseWriter, r *http.Request, err error) {
+	requestLogger(r).Error("request failed",
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.Any("error", err),
+	)
+	s.writeProblem(w, r, "something went wrong", http.StatusInternalServerError)
+}
+
+// recoverMiddleware turns a panicking handler into a logged 500 problem instead of a dropped connection.
+// it has to run after requestIDMiddleware and accessLogMiddleware so both still see the request.
+func (s *APIServer) recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// net/http aborts a response on purpose with this one, it isn't a bug
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			s.serverError(w, r, fmt.Errorf("panic: %v\n%s", rec, debug.Stack()))
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
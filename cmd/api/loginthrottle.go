@@ -0,0 +1,184 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhruv15803/internal/config"
+)
+
+// loginThrottle keeps track of failed login attempts per key (an account email or a client ip).
+// the first few failures are free, after that every failure blocks the key for an exponentially
+// growing delay, and once lockoutThreshold failures pile up the key is locked out entirely.
+type loginThrottle struct {
+	mu       sync.Mutex
+	attempts map[string]*loginAttempts
+
+	freeAttempts     int
+	baseDelay        time.Duration
+	maxDelay         time.Duration
+	lockoutThreshold int
+	lockoutDuration  time.Duration
+	// failures older than this are forgotten
+	window time.Duration
+}
+
+type loginAttempts struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// maxTrackedKeys bounds the attempts map, stale entries are swept once it grows past this
+const maxTrackedKeys = 10000
+
+func newLoginThrottle(cfg config.ThrottleConfig) *loginThrottle {
+	return &loginThrottle{
+		attempts:         make(map[string]*loginAttempts),
+		freeAttempts:     cfg.FreeAttempts,
+		baseDelay:        time.Second,
+		maxDelay:         time.Minute,
+		lockoutThreshold: cfg.LockoutThreshold,
+		lockoutDuration:  cfg.LockoutDuration,
+		window:           time.Hour,
+	}
+}
+
+// retryAfter returns how long the key has to wait before it may try again, 0 if it isn't blocked
+func (t *loginThrottle) retryAfter(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	a, ok := t.attempts[key]
+	if !ok {
+		return 0
+	}
+	wait := time.Until(a.blockedUntil)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// fail records a failed attempt for key. lockedUntil is non zero when this failure
+// pushed the key over the lockout threshold.
+func (t *loginThrottle) fail(key string) (failures int, lockedUntil time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if len(t.attempts) > maxTrackedKeys {
+		t.sweep(now)
+	}
+
+	a, ok := t.attempts[key]
+	if !ok || now.Sub(a.lastFailure) > t.window {
+		a = &loginAttempts{}
+		t.attempts[key] = a
+	}
+	a.failures++
+	a.lastFailure = now
+	failures = a.failures
+
+	if a.failures >= t.lockoutThreshold {
+		a.blockedUntil = now.Add(t.lockoutDuration)
+		// once the lockout expires the key goes back to the delayed state instead of getting free attempts again
+		a.failures = t.freeAttempts
+		return failures, a.blockedUntil
+	}
+
+	if a.failures > t.freeAttempts {
+		delay := t.baseDelay << (a.failures - t.freeAttempts - 1)
+		if delay > t.maxDelay || delay <= 0 {
+			delay = t.maxDelay
+		}
+		a.blockedUntil = now.Add(delay)
+	}
+	return failures, time.Time{}
+}
+
+func (t *loginThrottle) reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.attempts, key)
+}
+
+func (t *loginThrottle) sweep(now time.Time) {
+	for key, a := range t.attempts {
+		if now.Sub(a.lastFailure) > t.window && now.After(a.blockedUntil) {
+			delete(t.attempts, key)
+		}
+	}
+}
+
+// clientIP is the address the per ip login throttle and the access log key on, behind trusted proxies
+// realIPMiddleware has already replaced RemoteAddr with the client's address
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// realIPMiddleware replaces RemoteAddr with the client address a trusted proxy passed on. X-Forwarded-For
+// is read from the right and the first hop that isn't a trusted proxy is the client, anything left of it
+// could have been made up by the client. X-Real-IP is used when there is no X-Forwarded-For. without
+// trusted proxies the headers are ignored, any client could set them to dodge the per ip throttle
+func realIPMiddleware(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(trustedProxies) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip, ok := forwardedIP(r, trustedProxies); ok {
+				r.RemoteAddr = net.JoinHostPort(ip.String(), "0")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedIP is the client address in the proxy headers, ok is false when the request didn't come
+// through a trusted proxy or the headers don't hold a usable address
+func forwardedIP(r *http.Request, trustedProxies []netip.Prefix) (ip netip.Addr, ok bool) {
+	trusted := func(addr netip.Addr) bool {
+		for _, prefix := range trustedProxies {
+			if prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	peer, err := netip.ParseAddr(clientIP(r))
+	if err != nil || !trusted(peer) {
+		return netip.Addr{}, false
+	}
+
+	if forwardedFor := r.Header.Values("X-Forwarded-For"); len(forwardedFor) > 0 {
+		hops := strings.Split(strings.Join(forwardedFor, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// a hop we can't read could have been written by anyone, stop at the last good one
+				return ip, ip.IsValid()
+			}
+			ip = hop.Unmap()
+			if !trusted(ip) {
+				return ip, true
+			}
+		}
+		// every hop is a proxy of ours, the leftmost one is where the request started
+		return ip, true
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
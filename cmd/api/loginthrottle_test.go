@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/dhruv15803/internal/config"
+)
+
+func newTestThrottle() *loginThrottle {
+	return newLoginThrottle(config.ThrottleConfig{FreeAttempts: 2, LockoutThreshold: 5, LockoutDuration: 15 * time.Minute})
+}
+
+func TestLoginThrottleDelaysAfterFreeAttempts(t *testing.T) {
+	throttle := newTestThrottle()
+
+	for i := 1; i <= 2; i++ {
+		if failures, lockedUntil := throttle.fail("alice"); failures != i || !lockedUntil.IsZero() {
+			t.Fatalf("failure %d: got %d failures, locked until %v", i, failures, lockedUntil)
+		}
+		if wait := throttle.retryAfter("alice"); wait != 0 {
+			t.Fatalf("free failure %d has to wait %v", i, wait)
+		}
+	}
+
+	// every failure past the free ones doubles the delay
+	for i, want := range []time.Duration{time.Second, 2 * time.Second} {
+		throttle.fail("alice")
+		if wait := throttle.retryAfter("alice"); wait <= want-100*time.Millisecond || wait > want {
+			t.Fatalf("delayed failure %d has to wait %v, want about %v", i+1, wait, want)
+		}
+	}
+
+	if wait := throttle.retryAfter("bob"); wait != 0 {
+		t.Fatalf("a key without failures has to wait %v", wait)
+	}
+}
+
+func TestLoginThrottleLockout(t *testing.T) {
+	throttle := newTestThrottle()
+
+	var failures int
+	var lockedUntil time.Time
+	for range 5 {
+		failures, lockedUntil = throttle.fail("alice")
+	}
+	if failures != 5 || lockedUntil.IsZero() {
+		t.Fatalf("the threshold failure returned %d failures, locked until %v", failures, lockedUntil)
+	}
+	if wait := throttle.retryAfter("alice"); wait <= 14*time.Minute || wait > 15*time.Minute {
+		t.Fatalf("a locked out key has to wait %v, want the 15m lockout", wait)
+	}
+
+	// once the lockout is over the key is delayed again straight away rather than getting free attempts
+	throttle.attempts["alice"].blockedUntil = time.Now()
+	if failures, lockedUntil := throttle.fail("alice"); failures != 3 || !lockedUntil.IsZero() {
+		t.Fatalf("the first failure after a lockout returned %d failures, locked until %v", failures, lockedUntil)
+	}
+	if wait := throttle.retryAfter("alice"); wait == 0 {
+		t.Fatal("the first failure after a lockout isn't delayed")
+	}
+}
+
+func TestLoginThrottleReset(t *testing.T) {
+	throttle := newTestThrottle()
+	for range 4 {
+		throttle.fail("alice")
+	}
+	throttle.fail("bob")
+
+	throttle.reset("alice")
+	if wait := throttle.retryAfter("alice"); wait != 0 {
+		t.Fatalf("a reset key has to wait %v", wait)
+	}
+	if failures, _ := throttle.fail("alice"); failures != 1 {
+		t.Fatalf("a reset key starts at %d failures, want 1", failures)
+	}
+	if failures, _ := throttle.fail("bob"); failures != 2 {
+		t.Fatalf("resetting alice left bob at %d failures, want 2", failures)
+	}
+}
+
+func TestLoginThrottleWindow(t *testing.T) {
+	throttle := newTestThrottle()
+	for range 4 {
+		throttle.fail("alice")
+	}
+
+	// failures older than the window are forgotten
+	attempts := throttle.attempts["alice"]
+	attempts.lastFailure = time.Now().Add(-throttle.window - time.Second)
+	attempts.blockedUntil = attempts.lastFailure
+	if failures, _ := throttle.fail("alice"); failures != 1 {
+		t.Fatalf("a failure after the window counts %d failures, want 1", failures)
+	}
+
+	throttle.attempts["stale"] = &loginAttempts{failures: 4, lastFailure: time.Now().Add(-2 * throttle.window)}
+	throttle.sweep(time.Now())
+	if _, ok := throttle.attempts["stale"]; ok {
+		t.Fatal("sweep kept a key whose failures are past the window")
+	}
+	if _, ok := throttle.attempts["alice"]; !ok {
+		t.Fatal("sweep dropped a key with a recent failure")
+	}
+}
+
+func TestRealIPMiddleware(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:5123", want: "203.0.113.7"},
+		{name: "untrusted peer's headers are ignored", remoteAddr: "203.0.113.7:5123", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:80", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: "198.51.100.1"},
+		{name: "spoofed hops left of the client", remoteAddr: "10.1.2.3:80", headers: map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.1, 10.9.9.9"}, want: "198.51.100.1"},
+		{name: "every hop trusted", remoteAddr: "192.0.2.1:80", headers: map[string]string{"X-Forwarded-For": "10.0.0.5, 10.0.0.6"}, want: "10.0.0.5"},
+		{name: "unreadable hop", remoteAddr: "10.1.2.3:80", headers: map[string]string{"X-Forwarded-For": "not-an-ip"}, want: "10.1.2.3"},
+		{name: "x-real-ip", remoteAddr: "10.1.2.3:80", headers: map[string]string{"X-Real-IP": "198.51.100.1"}, want: "198.51.100.1"},
+		{name: "ipv6 client", remoteAddr: "10.1.2.3:80", headers: map[string]string{"X-Forwarded-For": "2001:db8::1"}, want: "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/v1/user/login", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+
+			var got string
+			realIPMiddleware(proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = clientIP(r)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Fatalf("client ip is %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
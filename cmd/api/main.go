@@ -1,31 +1,123 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"github.com/dhruv15803/cmd/migrate/migrations"
+	"github.com/dhruv15803/internal/config"
+	"github.com/dhruv15803/internal/migrate"
 	"github.com/dhruv15803/internal/storage"
-	_ "github.com/lib/pq"
 )
 
 func main() {
-	db_conn := os.Getenv("DB_CONN")
-	port := os.Getenv("PORT")
-	db, err := sql.Open("postgres", db_conn)
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "optional YAML config file, environment variables override it")
+	checkSpec := flag.Bool("check-openapi", false, "check openapi.yaml documents every route and exit")
+	flag.Parse()
+
+	if *checkSpec {
+		if err := checkOpenAPI(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("openapi.yaml documents every route")
+		return
+	}
+
+	slog.SetDefault(newLogger(slog.LevelInfo))
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	slog.SetDefault(newLogger(cfg.SlogLevel()))
+
+	storage, db, migrator, err := openStorage(cfg.DB)
+	if err != nil {
+		fatal("db connection failed", err)
+	}
+
+	server, err := NewAPIServer(cfg, storage, db, migrator)
 	if err != nil {
-		log.Fatalf("DB CONNECTION OPENING FAILED :- %v", err.Error())
+		fatal("server setup failed", err)
+	}
+
+	// SIGTERM is what the orchestrator sends during a deploy, in-flight requests are drained before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err = server.Run(ctx); err != nil {
+		fatal("server failed", err)
 	}
 
-	if err = db.Ping(); err != nil {
-		log.Fatalf("DB CONNECTION FAILED:- %v", err.Error())
+	if db != nil {
+		if err = db.Close(); err != nil {
+			slog.Error("closing db failed", slog.Any("error", err))
+		}
 	}
+	slog.Info("server stopped")
+}
 
-	log.Println("DB CONNECTION SUCCESSFULL")
-	storage := storage.NewStorage(db)
-	server := NewAPIServer(port, storage)
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
+}
 
-	if err = server.Run(); err != nil {
-		log.Fatalf("server failed to start :- %v", err.Error())
+// openStorage picks the backend from DB_CONN, memory:// keeps everything in process
+// (handy for local development, nothing survives a restart), sqlite://path/to/file.db uses
+// a sqlite file and anything else is handed to postgres.
+// with AUTO_MIGRATE=true pending migrations are applied before the server starts.
+// the pool and its migrator are returned as well for metrics and readiness checks, both are nil with memory://.
+func openStorage(dbConfig config.DBConfig) (*storage.Storage, *sql.DB, *migrate.Migrator, error) {
+	if strings.HasPrefix(dbConfig.Conn, "memory://") {
+		slog.Info("using in-memory storage")
+		return storage.NewMemoryStorage(), nil, nil, nil
+	}
+
+	db, driver, err := storage.Open(dbConfig.Conn)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	db.SetMaxOpenConns(dbConfig.MaxOpenConns)
+	db.SetMaxIdleConns(dbConfig.MaxIdleConns)
+	db.SetConnMaxLifetime(dbConfig.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(dbConfig.ConnMaxIdleTime)
+	slog.Info("db connection successful", slog.String("driver", driver))
+
+	migrator, err := newMigrator(db, driver)
+	if err != nil {
+		db.Close()
+		return nil, nil, nil, err
+	}
+
+	if dbConfig.AutoMigrate {
+		if err := migrator.Up(context.Background()); err != nil {
+			db.Close()
+			return nil, nil, nil, fmt.Errorf("auto migration failed :- %v", err)
+		}
+	}
+
+	return storage.NewStorage(db, dbConfig.QueryTimeout), db, migrator, nil
+}
+
+func newMigrator(db *sql.DB, driver string) (*migrate.Migrator, error) {
+	source, err := migrations.FS(driver)
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := migrate.New(db, driver, source)
+	if err != nil {
+		return nil, err
+	}
+	migrator.Logf = func(format string, args ...any) {
+		slog.Info(fmt.Sprintf(format, args...))
 	}
+	return migrator, nil
 }
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "feedback"
+
+// metrics are served on /metrics from their own registry, so nothing registered globally by a dependency leaks in
+type metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+
+	usersRegistered    prometheus.Counter
+	formsCreated       prometheus.Counter
+	formsPurged        prometheus.Counter
+	responsesSubmitted prometheus.Counter
+	responsesEdited    prometheus.Counter
+	responsesWithdrawn prometheus.Counter
+	draftsPurged       prometheus.Counter
+	failedLogins       prometheus.Counter
+	loginLockouts      prometheus.Counter
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to handle HTTP requests, by method and route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		usersRegistered: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "users_registered_total",
+			Help:      "Users that registered an account.",
+		}),
+		formsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "forms_created_total",
+			Help:      "Forms created.",
+		}),
+		formsPurged: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "forms_purged_total",
+			Help:      "Forms deleted for good after their time in the trash.",
+		}),
+		responsesSubmitted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "form_responses_submitted_total",
+			Help:      "Form responses submitted.",
+		}),
+		responsesEdited: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "form_responses_edited_total",
+			Help:      "Form responses edited by their respondent.",
+		}),
+		responsesWithdrawn: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "form_responses_withdrawn_total",
+			Help:      "Form responses withdrawn by their respondent.",
+		}),
+		draftsPurged: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "response_drafts_purged_total",
+			Help:      "Draft responses deleted after they expired.",
+		}),
+		failedLogins: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "failed_logins_total",
+			Help:      "Login attempts rejected because of a wrong email or password.",
+		}),
+		loginLockouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "login_lockouts_total",
+			Help:      "Accounts locked after too many failed logins.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.usersRegistered,
+		m.formsCreated,
+		m.formsPurged,
+		m.responsesSubmitted,
+		m.responsesEdited,
+		m.responsesWithdrawn,
+		m.draftsPurged,
+		m.failedLogins,
+		m.loginLockouts,
+	)
+	return m
+}
+
+// registerDB exports the connection pool stats of db, there is no pool with the in-memory backend
+func (m *metrics) registerDB(db *sql.DB, dbName string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// middleware counts and times every request. requests are labelled with the chi route pattern
+// (/api/v1/form/{formId}) rather than the path so ids don't blow up the number of series.
+func (m *metrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil && routeCtx.RoutePattern() != "" {
+			route = routeCtx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		m.httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		m.httpRequestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/dhruv15803/internal/config"
+	"github.com/go-chi/chi/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// the spec is kept by hand in YAML (easier to review than JSON) and served as JSON,
+// checkOpenAPI makes sure it doesn't drift from the router
+//
+//go:embed openapi.yaml
+var openAPIYAML []byte
+
+//go:embed docs.html
+var docsHTML []byte
+
+// routes that are operational rather than part of the api, they are not in the spec
+var undocumentedRoutes = map[string]bool{
+	"GET /metrics": true,
+	"GET /healthz": true,
+	"GET /readyz":  true,
+}
+
+type openAPISpec struct {
+	Paths map[string]map[string]any `json:"paths"`
+}
+
+// loadOpenAPI parses the embedded spec and returns it as JSON
+func loadOpenAPI() ([]byte, error) {
+	var spec any
+	if err := yaml.Unmarshal(openAPIYAML, &spec); err != nil {
+		return nil, fmt.Errorf("parsing openapi.yaml :- %v", err)
+	}
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("converting openapi.yaml to json :- %v", err)
+	}
+	return specJSON, nil
+}
+
+func (s *APIServer) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.openAPIJSON)
+}
+
+func (s *APIServer) docsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsHTML)
+}
+
+// openAPIMismatches walks the router and lists every route missing from the spec and every
+// operation in the spec that no route serves
+func openAPIMismatches(router chi.Routes, specJSON []byte) ([]string, error) {
+	var spec openAPISpec
+	if err := json.Unmarshal(specJSON, &spec); err != nil {
+		return nil, err
+	}
+
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options":
+				documented[strings.ToUpper(method)+" "+normalizeRoute(path)] = true
+			}
+		}
+	}
+
+	served := make(map[string]bool)
+	err := chi.Walk(router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		served[method+" "+normalizeRoute(route)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []string
+	for route := range served {
+		if !documented[route] && !undocumentedRoutes[route] {
+			mismatches = append(mismatches, "not in openapi.yaml: "+route)
+		}
+	}
+	for route := range documented {
+		if !served[route] {
+			mismatches = append(mismatches, "in openapi.yaml but not served: "+route)
+		}
+	}
+	sort.Strings(mismatches)
+	return mismatches, nil
+}
+
+// normalizeRoute makes chi patterns and spec paths comparable, a sub router's "/" route
+// walks as /api/v1/user/ while the spec says /api/v1/user
+func normalizeRoute(route string) string {
+	if len(route) > 1 {
+		route = strings.TrimSuffix(route, "/")
+	}
+	return route
+}
+
+// checkOpenAPI builds the router without any backend and compares it against the spec for
+// `go run ./cmd/api -check-openapi`, go test runs the same comparison in openapi_test.go
+func checkOpenAPI() error {
+	server, err := NewAPIServer(&config.Config{}, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	mismatches, err := openAPIMismatches(server.routes(), server.openAPIJSON)
+	if err != nil {
+		return err
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("openapi.yaml is out of date:\n  %s", strings.Join(mismatches, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dhruv15803/internal/config"
+	"github.com/dhruv15803/internal/storage"
+)
+
+// TestOpenAPIDocumentsEveryRoute fails the build when a route is added or removed without updating openapi.yaml
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	s, err := NewAPIServer(&config.Config{}, storage.NewMemoryStorage(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mismatches, err := openAPIMismatches(s.routes(), s.openAPIJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mismatch := range mismatches {
+		t.Error(mismatch)
+	}
+	if len(mismatches) > 0 {
+		t.Fatal("openapi.yaml is out of date")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/dhruv15803/internal/password"
+	"github.com/dhruv15803/internal/storage"
+)
+
+// every error response is an RFC 7807 problem document, the type tells clients what kind of
+// problem it is without parsing the detail message
+const problemTypePrefix = "urn:feedback-app:problem:"
+
+var problemTypes = map[int]string{
+	http.StatusBadRequest:          "bad-request",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not-found",
+	http.StatusMethodNotAllowed:    "method-not-allowed",
+	http.StatusConflict:            "conflict",
+	http.StatusTooManyRequests:     "too-many-requests",
+	http.StatusInternalServerError: "internal-error",
+}
+
+// validationProblemType is used instead of bad-request when the problem lists field errors
+const validationProblemType = problemTypePrefix + "validation"
+
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	RequestId string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError points at one invalid field of the request body, rule is set when the field broke a named rule
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+func newProblem(r *http.Request, detail string, status int) Problem {
+	problemType := "about:blank"
+	if slug, ok := problemTypes[status]; ok {
+		problemType = problemTypePrefix + slug
+	}
+	return Problem{
+		Type:      problemType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		RequestId: requestIDFromContext(r.Context()),
+	}
+}
+
+func (s *APIServer) writeProblemDocument(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		slog.Error("writing problem document failed", slog.String("request_id", problem.RequestId), slog.Any("error", err))
+	}
+}
+
+// writeProblem answers with a problem document, detail is the human readable explanation
+func (s *APIServer) writeProblem(w http.ResponseWriter, r *http.Request, detail string, status int) {
+	s.writeProblemDocument(w, newProblem(r, detail, status))
+}
+
+// writeValidationProblem answers 400 listing every field that failed validation
+func (s *APIServer) writeValidationProblem(w http.ResponseWriter, r *http.Request, detail string, fieldErrors ...FieldError) {
+	problem := newProblem(r, detail, http.StatusBadRequest)
+	problem.Type = validationProblemType
+	problem.Errors = fieldErrors
+	s.writeProblemDocument(w, problem)
+}
+
+// writeError answers with the status the storage error err maps to. the detail is generic on purpose,
+// handlers that want to say more check errors.Is themselves first. anything unrecognised is logged and
+// answered as a 500 so raw database errors never reach the response body.
+func (s *APIServer) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		s.writeProblem(w, r, "resource not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrConflict):
+		s.writeProblem(w, r, "resource already exists", http.StatusConflict)
+	case errors.Is(err, storage.ErrValidation):
+		s.writeProblem(w, r, "invalid request", http.StatusBadRequest)
+	default:
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) writePasswordPolicyError(w http.ResponseWriter, r *http.Request, violations []password.Violation) {
+	fieldErrors := make([]FieldError, 0, len(violations))
+	for _, violation := range violations {
+		fieldErrors = append(fieldErrors, FieldError{Field: "password", Rule: violation.Rule, Message: violation.Message})
+	}
+	s.writeValidationProblem(w, r, "password does not meet the password policy", fieldErrors...)
+}
+
+// writeFieldProblem is writeValidationProblem for a single invalid field
+func (s *APIServer) writeFieldProblem(w http.ResponseWriter, r *http.Request, field string, message string) {
+	s.writeValidationProblem(w, r, message, FieldError{Field: field, Message: message})
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+// ResponseSettingsRequest.EditWindow is in seconds, nil keeps responses editable for good
+type ResponseSettingsRequest struct {
+	AllowEdits      bool `json:"allow_edits"`
+	EditWindow      *int `json:"edit_window"`
+	AllowWithdrawal bool `json:"allow_withdrawal"`
+}
+
+type UpdateFormResponseRequest struct {
+	ResponseFields []ResponseField `json:"response_fields"`
+}
+
+// getResponseSettings tells respondents whether they can still edit or withdraw their response
+func (s *APIServer) getResponseSettings(w http.ResponseWriter, r *http.Request) {
+	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := s.storage.FormResponse.GetResponseSettings(r.Context(), int(formId))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("form with id %d not found", formId), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.writeJSON(w, settings, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// setResponseSettings lets the form's owners decide whether respondents can edit or withdraw their responses
+func (s *APIServer) setResponseSettings(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	var req ResponseSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.EditWindow != nil && *req.EditWindow <= 0 {
+		s.writeFieldProblem(w, r, "edit_window", "edit_window should be a number of seconds above 0, leave it out to allow edits for good")
+		return
+	}
+
+	form := s.formForRequest(w, r, userId, storage.CollaboratorOwner)
+	if form == nil {
+		return
+	}
+
+	settings, err := s.storage.FormResponse.SetResponseSettings(r.Context(), form.Id, req.AllowEdits, req.EditWindow, req.AllowWithdrawal)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.writeJSON(w, settings, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// editWindowClosed is true once the form's edit window has passed since the response was submitted
+func editWindowClosed(settings *storage.ResponseSettings, formResponse *storage.FormResponse, now time.Time) (bool, error) {
+	if settings.EditWindow == nil {
+		return false, nil
+	}
+	submittedAt, err := time.Parse(time.RFC3339Nano, formResponse.SubmittedAt)
+	if err != nil {
+		return false, fmt.Errorf("parsing the submission time of response %d:- %w", formResponse.Id, err)
+	}
+	return now.After(submittedAt.Add(time.Duration(*settings.EditWindow) * time.Second)), nil
+}
+
+// updateFormResponse replaces the respondent's answers, the answers it replaces are kept as the response's edits
+func (s *APIServer) updateFormResponse(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	formResponse, form := s.responseForRequest(w, r)
+	if formResponse == nil {
+		return
+	}
+	if formResponse.RespondentId != userId {
+		s.writeProblem(w, r, "user not authorized, only the respondent can edit a response", http.StatusForbidden)
+		return
+	}
+
+	settings, err := s.storage.FormResponse.GetResponseSettings(r.Context(), form.Id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if !settings.AllowEdits {
+		s.writeProblem(w, r, "the form doesn't allow editing responses", http.StatusForbidden)
+		return
+	}
+	closed, err := editWindowClosed(settings, formResponse, time.Now())
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+	if closed {
+		window := time.Duration(*settings.EditWindow) * time.Second
+		s.writeProblem(w, r, fmt.Sprintf("responses to this form can only be edited for %s after they are submitted", window), http.StatusForbidden)
+		return
+	}
+
+	var req UpdateFormResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// the answers still answer the version the response was submitted against
+	var fields []storage.FormField
+	if formResponse.FormVersion != nil {
+		version, err := s.storage.FormVersions.GetFormVersion(r.Context(), form.Id, *formResponse.FormVersion)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		fields = version.FormFields
+	} else {
+		fields, err = s.storage.FormFields.GetFormFieldsByFormId(r.Context(), form.Id)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+	}
+	if !s.validAnswers(w, r, fields, req.ResponseFields) || !s.answeredRequired(w, r, fields, req.ResponseFields) {
+		return
+	}
+
+	responseFields, err := s.storage.FormResponse.UpdateResponseFields(r.Context(), formResponse.Id, answerRows(req.ResponseFields))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if responseFields == nil {
+		responseFields = []storage.ResponseField{}
+	}
+	s.metrics.responsesEdited.Inc()
+
+	resp := struct {
+		FormResponseId int                     `json:"form_response_id"`
+		ResponseFields []storage.ResponseField `json:"response_fields"`
+	}{
+		FormResponseId: formResponse.Id,
+		ResponseFields: responseFields,
+	}
+
+	if err := s.writeJSON(w, resp, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// withdrawFormResponse deletes the respondent's response with its answers and edits
+func (s *APIServer) withdrawFormResponse(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	formResponse, form := s.responseForRequest(w, r)
+	if formResponse == nil {
+		return
+	}
+	if formResponse.RespondentId != userId {
+		s.writeProblem(w, r, "user not authorized, only the respondent can withdraw a response", http.StatusForbidden)
+		return
+	}
+
+	settings, err := s.storage.FormResponse.GetResponseSettings(r.Context(), form.Id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if !settings.AllowWithdrawal {
+		s.writeProblem(w, r, "the form doesn't allow withdrawing responses", http.StatusForbidden)
+		return
+	}
+
+	if err := s.storage.FormResponse.DeleteFormResponseById(r.Context(), formResponse.Id); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.metrics.responsesWithdrawn.Inc()
+
+	type Envelope struct {
+		Message string `json:"message"`
+	}
+	if err := s.writeJSON(w, Envelope{Message: fmt.Sprintf("response with id %d withdrawn", formResponse.Id)}, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// getResponseEdits lists the answers a response had before it was edited, to whoever can read the response
+func (s *APIServer) getResponseEdits(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user ID", http.StatusUnauthorized)
+		return
+	}
+
+	formResponse, form := s.responseForRequest(w, r)
+	if formResponse == nil {
+		return
+	}
+	if !s.canReadResponse(w, r, formResponse, form, userId) {
+		return
+	}
+
+	edits, err := s.storage.FormResponse.GetResponseFieldEdits(r.Context(), formResponse.Id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if edits == nil {
+		edits = []storage.ResponseFieldEdit{}
+	}
+
+	if err := s.writeJSON(w, edits, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
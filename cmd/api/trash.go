@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+// trashPurgeInterval is how often forms past the trash retention period are looked for
+const trashPurgeInterval = time.Hour
+
+// purgeTrash deletes forms that have been in the trash longer than the configured retention
+// for good, until ctx is cancelled. every replica runs it, purging the same rows twice is harmless.
+func (s *APIServer) purgeTrash(ctx context.Context) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.storage.Forms.PurgeTrashedForms(ctx, time.Now().Add(-s.config.TrashRetention))
+		if err != nil && ctx.Err() == nil {
+			slog.Error("purging the trash failed", slog.Any("error", err))
+		}
+		if purged > 0 {
+			s.metrics.formsPurged.Add(float64(purged))
+			slog.Info("purged forms from the trash", slog.Int("forms", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// getTrash lists the deleted forms the user could restore
+func (s *APIServer) getTrash(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	forms, err := s.storage.Forms.GetTrashedForms(r.Context(), userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if forms == nil {
+		forms = []storage.Form{}
+	}
+
+	if err := s.writeJSON(w, forms, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// restoreForm takes a form out of the trash with its fields and responses, only its owners can
+func (s *APIServer) restoreForm(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	formId, err := strconv.ParseInt(r.PathValue("formId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	form, err := s.storage.Forms.GetTrashedFormById(r.Context(), int(formId))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("form with id %d not found in the trash", formId), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	allowed, err := s.authorizeForm(r.Context(), form, userId, storage.CollaboratorOwner)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if !allowed {
+		s.writeProblem(w, r, "user not authorized to restore form", http.StatusForbidden)
+		return
+	}
+
+	restored, err := s.storage.Forms.RestoreForm(r.Context(), form.Id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.writeJSON(w, restored, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
@@ -2,11 +2,12 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,62 +30,65 @@ type LoginRequest struct {
 func (s *APIServer) registerUserHandler(w http.ResponseWriter, r *http.Request) {
 	var payload RegisterUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		s.writeJSONError(w, "invalid request body", http.StatusBadRequest)
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
 		return
 	}
 	email := strings.ToLower(strings.TrimSpace(payload.Email))
 	username := strings.TrimSpace(payload.Username)
 	password := strings.TrimSpace(payload.Password)
 
-	if email == "" || username == "" || password == "" {
-		s.writeJSONError(w, "email,username and password are compulsory fields", http.StatusBadRequest)
-		return
+	var fieldErrors []FieldError
+	if email == "" {
+		fieldErrors = append(fieldErrors, FieldError{Field: "email", Message: "email is required"})
+	} else if ok := s.validateEmail(email); !ok {
+		fieldErrors = append(fieldErrors, FieldError{Field: "email", Message: "Invalid email"})
+	}
+	if username == "" {
+		fieldErrors = append(fieldErrors, FieldError{Field: "username", Message: "username is required"})
+	}
+	if password == "" {
+		fieldErrors = append(fieldErrors, FieldError{Field: "password", Message: "password is required"})
 	}
-	// extracted email,username and password and checked that they are not empty
-	// validate email , //
-	if ok := s.validateEmail(email); !ok {
-		s.writeJSONError(w, "Invalid email", http.StatusBadRequest)
+	if len(fieldErrors) > 0 {
+		s.writeValidationProblem(w, r, "invalid registration details", fieldErrors...)
 		return
 	}
 
-	if ok := s.validatePassword(password); !ok {
-		s.writeJSONError(w, "password should have atleast 6 characters,password should have atleast 1 special character,password should have atleast 1 uppercase character", http.StatusBadRequest)
+	if violations := s.config.Password.Validate(password, username, email); len(violations) > 0 {
+		s.writePasswordPolicyError(w, r, violations)
 		return
 	}
 
 	// email and password are valid
 	// check if any users with above email or username already exists
-	users, err := s.storage.Users.GetUsersByUsernameOrEmail(username, email)
+	users, err := s.storage.Users.GetUsersByUsernameOrEmail(r.Context(), username, email)
 	if err != nil {
-		log.Println(err.Error())
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 	if len(users) > 0 {
-		s.writeJSONError(w, "user already exists", http.StatusBadRequest)
+		s.writeProblem(w, r, "user already exists", http.StatusBadRequest)
 		return
 	}
 
 	// generate password hash
 	hashedByte, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Println(err.Error())
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 	hashedPassword := string(hashedByte)
-	user, err := s.storage.Users.CreateUser(username, email, hashedPassword)
+	user, err := s.storage.Users.CreateUser(r.Context(), username, email, hashedPassword)
 	if err != nil {
-		log.Println(err.Error())
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
+	s.metrics.usersRegistered.Inc()
 	// generate jwt token and use payload user.Id , set token in cookie for persisting
 
-	tokenString, err := s.GenerateJWT(user.Id)
+	tokenString, err := s.GenerateJWT(user.Id, user.TokenVersion)
 	if err != nil {
-		log.Println(err.Error())
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 	cookie := http.Cookie{
@@ -102,14 +106,14 @@ func (s *APIServer) registerUserHandler(w http.ResponseWriter, r *http.Request)
 		User    storage.User `json:"user"`
 	}
 	if err = s.writeJSON(w, Envelope{Message: "user registered succesfully", User: *user}, http.StatusCreated); err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 	}
 }
 
 func (s *APIServer) loginUserHandler(w http.ResponseWriter, r *http.Request) {
 	var payload LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		s.writeJSONError(w, "invalid request body", http.StatusBadRequest)
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
@@ -119,31 +123,57 @@ func (s *APIServer) loginUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Validate input fields
 	if email == "" || password == "" {
-		s.writeJSONError(w, "email and password are required fields", http.StatusBadRequest)
+		var fieldErrors []FieldError
+		if email == "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: "email", Message: "email is required"})
+		}
+		if password == "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: "password", Message: "password is required"})
+		}
+		s.writeValidationProblem(w, r, "email and password are required fields", fieldErrors...)
+		return
+	}
+
+	accountKey := "email:" + email
+	ipKey := "ip:" + clientIP(r)
+
+	// refuse straight away while either the account or the client ip is being throttled
+	retryAfter := max(s.accountThrottle.retryAfter(accountKey), s.ipThrottle.retryAfter(ipKey))
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		s.writeProblem(w, r, "too many failed login attempts, try again later", http.StatusTooManyRequests)
 		return
 	}
 
 	// Fetch the user by email
-	user, err := s.storage.Users.GetUserByEmail(email)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			s.writeJSONError(w, "invalid email or password", http.StatusUnauthorized)
-		} else {
-			s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
-		}
+	user, err := s.storage.Users.GetUserByEmail(r.Context(), email)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.serverError(w, r, err)
 		return
 	}
 
-	// Compare the provided password with the stored hashed password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		s.writeJSONError(w, "invalid email or password", http.StatusUnauthorized)
+	// Compare the provided password with the stored hashed password,
+	// when the email doesn't exist compare against a dummy hash so the response takes just as long
+	hashedPassword := s.dummyPasswordHash
+	if user != nil {
+		hashedPassword = []byte(user.Password)
+	}
+	if err := bcrypt.CompareHashAndPassword(hashedPassword, []byte(password)); err != nil || user == nil {
+		s.recordFailedLogin(r, user, accountKey, ipKey)
+		s.writeProblem(w, r, "invalid email or password", http.StatusUnauthorized)
+		return
+	}
+	s.accountThrottle.reset(accountKey)
+
+	if user.DisabledAt != nil {
+		s.writeProblem(w, r, "account disabled", http.StatusForbidden)
 		return
 	}
 
 	// Generate a JWT token
-	tokenString, err := s.GenerateJWT(user.Id)
+	tokenString, err := s.GenerateJWT(user.Id, user.TokenVersion)
 	if err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
@@ -171,26 +201,66 @@ func (s *APIServer) loginUserHandler(w http.ResponseWriter, r *http.Request) {
 	}, http.StatusOK)
 }
 
+// recordFailedLogin counts the failure against both the account and the ip, and records a lockout
+// event for the account owner when the account gets locked
+func (s *APIServer) recordFailedLogin(r *http.Request, user *storage.User, accountKey string, ipKey string) {
+	s.metrics.failedLogins.Inc()
+	s.ipThrottle.fail(ipKey)
+	failures, lockedUntil := s.accountThrottle.fail(accountKey)
+	if lockedUntil.IsZero() {
+		return
+	}
+	s.metrics.loginLockouts.Inc()
+	if user == nil {
+		return
+	}
+	// the lockout is recorded even if the client hangs up mid request
+	ctx := context.WithoutCancel(r.Context())
+	if _, err := s.storage.LoginLockouts.CreateLoginLockout(ctx, user.Id, clientIP(r), failures, lockedUntil); err != nil {
+		requestLogger(r).Error("recording login lockout failed", slog.Int("user_id", user.Id), slog.Any("error", err))
+	}
+}
+
+func (s *APIServer) getLoginLockouts(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	lockouts, err := s.storage.LoginLockouts.GetLoginLockoutsByUserId(r.Context(), userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if lockouts == nil {
+		lockouts = []storage.LoginLockout{}
+	}
+
+	if err = s.writeJSON(w, lockouts, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
 func (s *APIServer) getAuthenticatedUser(w http.ResponseWriter, r *http.Request) {
 	userId, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		log.Println("asdk;laskd")
-		s.writeJSONError(w, "invalid user id", http.StatusUnauthorized)
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
 		return
 	}
 
-	user, err := s.storage.Users.GetUserById(userId)
+	user, err := s.storage.Users.GetUserById(r.Context(), userId)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.writeJSONError(w, "user not found", http.StatusBadRequest)
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, "user not found", http.StatusBadRequest)
 			return
 		}
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 		return
 	}
 
 	if err = s.writeJSON(w, user, http.StatusOK); err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 	}
 }
 
@@ -201,16 +271,15 @@ const userIDKey contextKey = "userID"
 // AuthMiddleware is the authentication middleware
 func (s *APIServer) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Read the JWT token from the cookie
-		cookie, err := r.Cookie("auth_token")
-		if err != nil {
-			http.Error(w, "unauthorized: missing or invalid token", http.StatusUnauthorized)
-			return
+		// Read the JWT token from the Authorization header (api clients) or the cookie (browsers)
+		tokenString := bearerToken(r)
+		if tokenString == "" {
+			if cookie, err := r.Cookie("auth_token"); err == nil {
+				tokenString = cookie.Value
+			}
 		}
-
-		tokenString := cookie.Value
 		if strings.TrimSpace(tokenString) == "" {
-			http.Error(w, "unauthorized: missing or invalid token", http.StatusUnauthorized)
+			s.writeProblem(w, r, "unauthorized: missing or invalid token", http.StatusUnauthorized)
 			return
 		}
 
@@ -221,35 +290,55 @@ func (s *APIServer) AuthMiddleware(next http.Handler) http.Handler {
 				return nil, http.ErrAbortHandler
 			}
 			// Return the secret key for validation
-			return []byte(os.Getenv("JWT_SECRET")), nil
+			return []byte(s.config.JWTSecret), nil
 		})
 
 		if err != nil || !token.Valid {
-			http.Error(w, "unauthorized: invalid token", http.StatusUnauthorized)
+			s.writeProblem(w, r, "unauthorized: invalid token", http.StatusUnauthorized)
 			return
 		}
 
 		// Extract the userId from the token's payload
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok || !token.Valid {
-			http.Error(w, "unauthorized: invalid token", http.StatusUnauthorized)
+			s.writeProblem(w, r, "unauthorized: invalid token", http.StatusUnauthorized)
 			return
 		}
 
 		userIdFloat, ok := claims["userId"].(float64)
 		if !ok {
-			http.Error(w, "unauthorized: invalid token payload", http.StatusUnauthorized)
+			s.writeProblem(w, r, "unauthorized: invalid token payload", http.StatusUnauthorized)
 			return
 		}
 
 		userId := int(userIdFloat)
 
-		user, err := s.storage.Users.GetUserById(userId)
+		user, err := s.storage.Users.GetUserById(r.Context(), userId)
 		if err != nil {
-			http.Error(w, "unauthorized: invalid token payload", http.StatusUnauthorized)
+			// only a deleted account invalidates the token, a database hiccup shouldn't log everyone out
+			if errors.Is(err, storage.ErrNotFound) {
+				s.writeProblem(w, r, "unauthorized: invalid token payload", http.StatusUnauthorized)
+				return
+			}
+			s.writeError(w, r, err)
 			return
 		}
 
+		// a password change bumps the version, signing out every session started before it.
+		// tokens from before token versions existed carry none and count as version 0
+		tokenVersion, _ := claims["tokenVersion"].(float64)
+		if int(tokenVersion) != user.TokenVersion {
+			s.writeProblem(w, r, "unauthorized: session ended by a password change", http.StatusUnauthorized)
+			return
+		}
+
+		if user.DisabledAt != nil {
+			s.writeProblem(w, r, "forbidden: account disabled", http.StatusForbidden)
+			return
+		}
+
+		setRequestUserId(r.Context(), user.Id)
+
 		// Attach the userId to the context
 		ctx := context.WithValue(r.Context(), userIDKey, user.Id)
 
@@ -258,10 +347,43 @@ func (s *APIServer) AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// bearerToken returns the token of an "Authorization: Bearer <token>" header, empty when there is none
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// AdminMiddleware only lets admins through, it has to run after AuthMiddleware
+func (s *APIServer) AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := r.Context().Value(userIDKey).(int)
+		if !ok {
+			s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := s.storage.Users.GetUserById(r.Context(), userId)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		if user.Role != storage.RoleAdmin {
+			s.writeProblem(w, r, "admin access required", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *APIServer) logoutHandler(w http.ResponseWriter, r *http.Request) {
 	_, ok := r.Context().Value(userIDKey).(int)
 	if !ok {
-		s.writeJSONError(w, "user not authorized", http.StatusUnauthorized)
+		s.writeProblem(w, r, "user not authorized", http.StatusUnauthorized)
 		return
 	}
 	// authenticated cookie name -> auth_token
@@ -280,7 +402,7 @@ func (s *APIServer) logoutHandler(w http.ResponseWriter, r *http.Request) {
 		Message string `json:"message"`
 	}
 	if err := s.writeJSON(w, Envelope{Message: "logged out successfully"}, http.StatusOK); err != nil {
-		s.writeJSONError(w, "something went wrong", http.StatusInternalServerError)
+		s.serverError(w, r, err)
 	}
 }
 
@@ -298,43 +420,3 @@ func (s *APIServer) validateEmail(email string) bool {
 	}
 	return true
 }
-
-/*
-min length 6
-atleast 1 upper case char
-atleast 1 special char
-*/
-func (s *APIServer) validatePassword(password string) bool {
-	const specialChars string = "@#$%&!"
-	const upperCaseChars string = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-
-	if len(password) < 6 {
-		return false
-	}
-
-	isSpecialChar := false
-	for _, specialChar := range specialChars {
-		if strings.Contains(password, string(specialChar)) {
-			isSpecialChar = true
-			break
-		}
-	}
-	if !isSpecialChar {
-		return false
-	}
-
-	// check uppercase
-	isUpperCase := false
-	for _, upperCaseChar := range upperCaseChars {
-		if strings.Contains(password, string(upperCaseChar)) {
-			isUpperCase = true
-			break
-		}
-	}
-
-	if !isUpperCase {
-		return false
-	}
-
-	return true
-}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+type CreateWorkspaceRequest struct {
+	WorkspaceName string `json:"workspace_name"`
+}
+
+type AddWorkspaceMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// workspaceForRequest loads the workspace from the {workspaceId} path parameter and checks the user has atleast the
+// required role in it, it writes the error response itself and returns the user's role as "" when the handler should stop
+func (s *APIServer) workspaceForRequest(w http.ResponseWriter, r *http.Request, userId int, required string) (*storage.Workspace, string) {
+	workspaceId, err := strconv.ParseInt(r.PathValue("workspaceId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return nil, ""
+	}
+
+	workspace, err := s.storage.Workspaces.GetWorkspaceById(r.Context(), int(workspaceId))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("workspace with id %d not found", workspaceId), http.StatusNotFound)
+			return nil, ""
+		}
+		s.writeError(w, r, err)
+		return nil, ""
+	}
+
+	role, err := s.workspaceRole(r.Context(), workspace.Id, userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return nil, ""
+	}
+	if role == "" || workspaceRoleRank[role] < workspaceRoleRank[required] {
+		s.writeProblem(w, r, fmt.Sprintf("user not authorized, %s access to the workspace required", required), http.StatusForbidden)
+		return nil, ""
+	}
+
+	return workspace, role
+}
+
+func (s *APIServer) createWorkspace(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	var payload CreateWorkspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	workspaceName := strings.TrimSpace(payload.WorkspaceName)
+	if workspaceName == "" {
+		s.writeFieldProblem(w, r, "workspace_name", "workspace name is required")
+		return
+	}
+
+	workspace, err := s.storage.Workspaces.CreateWorkspace(r.Context(), workspaceName, userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err = s.writeJSON(w, workspace, http.StatusCreated); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) myWorkspaces(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	workspaces, err := s.storage.Workspaces.GetWorkspacesByUserId(r.Context(), userId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err = s.writeJSON(w, workspaces, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) getWorkspace(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	workspace, role := s.workspaceForRequest(w, r, userId, storage.WorkspaceRoleMember)
+	if role == "" {
+		return
+	}
+
+	members, err := s.storage.Workspaces.GetWorkspaceMembers(r.Context(), workspace.Id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	workspace.Members = members
+
+	if err = s.writeJSON(w, workspace, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) addWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	var payload AddWorkspaceMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		s.writeProblem(w, r, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(payload.Email))
+	role := strings.ToLower(strings.TrimSpace(payload.Role))
+	if role == "" {
+		role = storage.WorkspaceRoleMember
+	}
+
+	if email == "" {
+		s.writeFieldProblem(w, r, "email", "email is required")
+		return
+	}
+	if _, ok := workspaceRoleRank[role]; !ok {
+		s.writeFieldProblem(w, r, "role", "role should be one of member, admin or owner")
+		return
+	}
+
+	workspace, myRole := s.workspaceForRequest(w, r, userId, storage.WorkspaceRoleAdmin)
+	if myRole == "" {
+		return
+	}
+	// admins manage members, only owners can make other owners
+	if role == storage.WorkspaceRoleOwner && myRole != storage.WorkspaceRoleOwner {
+		s.writeProblem(w, r, "only workspace owners can add owners", http.StatusForbidden)
+		return
+	}
+
+	invitee, err := s.storage.Users.GetUserByEmail(r.Context(), email)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.writeProblem(w, r, fmt.Sprintf("no user registered with email %s", email), http.StatusNotFound)
+			return
+		}
+		s.writeError(w, r, err)
+		return
+	}
+
+	// demoting an owner follows the same rules as removing one
+	currentRole, err := s.workspaceRole(r.Context(), workspace.Id, invitee.Id)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if currentRole == storage.WorkspaceRoleOwner && role != storage.WorkspaceRoleOwner {
+		if ok := s.canRemoveWorkspaceOwner(w, r, workspace.Id, myRole); !ok {
+			return
+		}
+	}
+
+	member, err := s.storage.Workspaces.AddWorkspaceMember(r.Context(), workspace.Id, invitee.Id, role)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	member.User = invitee
+
+	if err = s.writeJSON(w, member, http.StatusCreated); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *APIServer) removeWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	userId, ok := r.Context().Value(userIDKey).(int)
+	if !ok {
+		s.writeProblem(w, r, "invalid user id", http.StatusUnauthorized)
+		return
+	}
+
+	memberId, err := strconv.ParseInt(r.PathValue("userId"), 10, 64)
+	if err != nil {
+		s.writeProblem(w, r, "invalid request parameter", http.StatusBadRequest)
+		return
+	}
+
+	// members can always leave, removing anybody else takes an admin
+	required := storage.WorkspaceRoleAdmin
+	if int(memberId) == userId {
+		required = storage.WorkspaceRoleMember
+	}
+	workspace, myRole := s.workspaceForRequest(w, r, userId, required)
+	if myRole == "" {
+		return
+	}
+
+	memberRole, err := s.workspaceRole(r.Context(), workspace.Id, int(memberId))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if memberRole == "" {
+		s.writeProblem(w, r, fmt.Sprintf("user with id %d is not a member of this workspace", memberId), http.StatusNotFound)
+		return
+	}
+	if memberRole == storage.WorkspaceRoleOwner {
+		if ok := s.canRemoveWorkspaceOwner(w, r, workspace.Id, myRole); !ok {
+			return
+		}
+	}
+
+	if err = s.storage.Workspaces.DeleteWorkspaceMember(r.Context(), workspace.Id, int(memberId)); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	type Envelope struct {
+		Message string `json:"message"`
+	}
+	if err = s.writeJSON(w, Envelope{Message: fmt.Sprintf("user with id %d removed from workspace %d", memberId, workspace.Id)}, http.StatusOK); err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// canRemoveWorkspaceOwner checks an owner can lose their role: only owners can do it
+// and the workspace must keep atleast one owner so its forms are never left unmanaged
+func (s *APIServer) canRemoveWorkspaceOwner(w http.ResponseWriter, r *http.Request, workspaceId int, myRole string) bool {
+	if myRole != storage.WorkspaceRoleOwner {
+		s.writeProblem(w, r, "only workspace owners can remove owners", http.StatusForbidden)
+		return false
+	}
+	owners, err := s.storage.Workspaces.CountWorkspaceOwners(r.Context(), workspaceId)
+	if err != nil {
+		s.writeError(w, r, err)
+		return false
+	}
+	if owners <= 1 {
+		s.writeProblem(w, r, "a workspace needs atleast one owner", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
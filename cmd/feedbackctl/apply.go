@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dhruv15803/client"
+	"gopkg.in/yaml.v3"
+)
+
+// apply shows what applying the definition in path changes and applies it once confirmed,
+// yaml is a superset of json so both are read the same way
+func (c *cli) apply(ctx context.Context, path string, yes bool, planOnly bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var definition client.FormDefinition
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&definition); err != nil {
+		return fmt.Errorf("reading %s :- %w", path, err)
+	}
+
+	plan, err := c.client.PlanForm(ctx, definition)
+	if err != nil {
+		return err
+	}
+	if planOnly || len(plan.Changes) == 0 {
+		return c.printPlan(plan)
+	}
+	if !yes {
+		if c.out.format == formatJSON {
+			// the prompt can't go in between json, -yes is needed to apply
+			return c.printPlan(plan)
+		}
+		if err := c.printPlan(plan); err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stderr, "apply these changes? [y/N] ")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+			fmt.Fprintln(os.Stderr, "nothing applied")
+			return nil
+		}
+	}
+
+	applied, err := c.client.ApplyForm(ctx, definition)
+	if err != nil {
+		return err
+	}
+	if c.out.format == formatJSON {
+		return c.out.print(applied, nil, nil)
+	}
+	fmt.Fprintf(c.out.w, "applied %d changes to form %d\n", len(applied.Changes), applied.Form.Id)
+	return nil
+}
+
+// printPlan lists the changes like a diff, + creates, ~ updates and - deletes
+func (c *cli) printPlan(plan *client.FormPlan) error {
+	if c.out.format == formatJSON {
+		return c.out.print(plan, nil, nil)
+	}
+
+	form := "new form"
+	if plan.FormId != nil {
+		form = fmt.Sprintf("form %d", *plan.FormId)
+	}
+	if len(plan.Changes) == 0 {
+		_, err := fmt.Fprintf(c.out.w, "%s (%s) is up to date\n", plan.FormKey, form)
+		return err
+	}
+
+	fmt.Fprintf(c.out.w, "%s (%s):\n", plan.FormKey, form)
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case client.PlanCreateForm:
+			fmt.Fprintln(c.out.w, "  + form")
+		case client.PlanUpdateForm:
+			fmt.Fprintln(c.out.w, "  ~ form")
+		case client.PlanCreateField:
+			fmt.Fprintf(c.out.w, "  + field %s\n", change.FieldKey)
+		case client.PlanUpdateField:
+			fmt.Fprintf(c.out.w, "  ~ field %s\n", planField(change))
+		case client.PlanDeleteField:
+			fmt.Fprintf(c.out.w, "  - field %s\n", planField(change))
+		}
+		for _, diff := range change.Diffs {
+			switch {
+			case change.Action == client.PlanDeleteField:
+				fmt.Fprintf(c.out.w, "      %s: %s\n", diff.Attribute, planValue(diff.From))
+			case diff.From == nil:
+				fmt.Fprintf(c.out.w, "      %s: %s\n", diff.Attribute, planValue(diff.To))
+			default:
+				fmt.Fprintf(c.out.w, "      %s: %s -> %s\n", diff.Attribute, planValue(diff.From), planValue(diff.To))
+			}
+		}
+	}
+	return nil
+}
+
+// planField names a field by its key, fields created before keys existed only have an id
+func planField(change client.PlanChange) string {
+	if change.FieldKey != "" {
+		return change.FieldKey
+	}
+	if change.FieldId != nil {
+		return fmt.Sprintf("#%d", *change.FieldId)
+	}
+	return "-"
+}
+
+func planValue(value any) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// credentials is what login saves for later commands
+type credentials struct {
+	Server string `json:"server"`
+	Token  string `json:"token"`
+}
+
+type credentialsStore struct {
+	path string
+}
+
+func newCredentialsStore() (*credentialsStore, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("locating the config dir :- %w", err)
+	}
+	return &credentialsStore{path: filepath.Join(dir, "feedbackctl", "credentials.json")}, nil
+}
+
+// load returns empty credentials when nobody logged in yet
+func (s *credentialsStore) load() (credentials, error) {
+	var creds credentials
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return creds, nil
+		}
+		return creds, fmt.Errorf("reading %s :- %w", s.path, err)
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return creds, fmt.Errorf("reading %s :- %w", s.path, err)
+	}
+	return creds, nil
+}
+
+// save writes the credentials readable by the current user only, the token is as good as the password
+func (s *credentialsStore) save(creds credentials) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o600)
+}
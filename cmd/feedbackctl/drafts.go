@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/dhruv15803/client"
+)
+
+// drafts runs the "responses draft" commands
+func (c *cli) drafts(ctx context.Context, args []string) error {
+	command, args := subcommand(args)
+	flags := flag.NewFlagSet("responses draft "+command, flag.ContinueOnError)
+
+	switch command {
+	case "list":
+		if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+			return errUsage
+		}
+		drafts, err := c.client.Drafts(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(drafts))
+		for _, draft := range drafts {
+			rows = append(rows, []string{strconv.Itoa(draft.FormId), strconv.Itoa(len(draft.ResponseFields)), draft.UpdatedAt, expiry(draft)})
+		}
+		return c.out.print(drafts, []string{"FORM", "ANSWERS", "SAVED", "EXPIRES"}, rows)
+
+	case "show":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		draft, err := c.client.Draft(ctx, formId)
+		if err != nil {
+			return err
+		}
+		return c.printDraft(draft)
+
+	case "save":
+		changes := answerFlag(flags)
+		if err := flags.Parse(args); err != nil || len(changes) == 0 {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		// answers already in the draft are kept, so a long form can be filled in a few fields at a time
+		var current []client.Answer
+		draft, err := c.client.Draft(ctx, formId)
+		if err == nil {
+			current = draft.ResponseFields
+		} else if !errors.Is(err, client.ErrNotFound) {
+			return err
+		}
+		draft, err = c.client.SaveDraft(ctx, formId, mergeAnswers(current, changes))
+		if err != nil {
+			return err
+		}
+		return c.printDraft(draft)
+
+	case "discard":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		if err := c.client.DiscardDraft(ctx, formId); err != nil {
+			return err
+		}
+		if c.out.format == formatTable {
+			fmt.Fprintf(c.out.w, "discarded the draft of form %d\n", formId)
+		}
+		return nil
+
+	case "submit":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		submitted, err := c.client.SubmitDraft(ctx, formId)
+		if err != nil {
+			return err
+		}
+		for _, answer := range submitted.DroppedAnswers {
+			fmt.Fprintf(os.Stderr, "field %d is no longer on the form, its answer %q wasn't submitted\n", answer.FormFieldId, answer.FieldValue)
+		}
+		rows := make([][]string, 0, len(submitted.ResponseFields))
+		for _, answer := range submitted.ResponseFields {
+			rows = append(rows, []string{strconv.Itoa(answer.FormFieldId), cell(answer.FieldValue)})
+		}
+		return c.out.print(submitted, []string{"FIELD", "ANSWER"}, rows)
+	}
+	return errUsage
+}
+
+func (c *cli) printDraft(draft *client.ResponseDraft) error {
+	rows := make([][]string, 0, len(draft.ResponseFields))
+	for _, answer := range draft.ResponseFields {
+		rows = append(rows, []string{strconv.Itoa(answer.FormFieldId), cell(answer.FieldValue)})
+	}
+	if err := c.out.print(draft, []string{"FIELD", "ANSWER"}, rows); err != nil {
+		return err
+	}
+	if c.out.format == formatTable {
+		fmt.Fprintf(c.out.w, "\nsaved %s, expires %s\n", draft.UpdatedAt, expiry(*draft))
+	}
+	return nil
+}
+
+func expiry(draft client.ResponseDraft) string {
+	if draft.ExpiresAt == nil {
+		return "never"
+	}
+	return *draft.ExpiresAt
+}
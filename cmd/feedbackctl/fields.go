@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dhruv15803/client"
+)
+
+func (c *cli) fields(ctx context.Context, args []string) error {
+	command, args := subcommand(args)
+	flags := flag.NewFlagSet("fields "+command, flag.ContinueOnError)
+
+	switch command {
+	case "add":
+		formId := flags.Int("form", 0, "form to add the field to")
+		title := flags.String("title", "", "field title")
+		required := flags.Bool("required", false, "whether respondents have to answer the field")
+		fieldType := flags.String("type", client.FieldTypeText, "field type, one of text, long_text, number, email, date or choice")
+		options := flags.String("options", "", "comma separated answers a choice field accepts")
+		if err := flags.Parse(args); err != nil || flags.NArg() != 0 || *formId <= 0 {
+			return errUsage
+		}
+		field, err := c.client.CreateFormField(ctx, *formId, client.FormFieldRequest{
+			FieldTitle: *title,
+			Required:   *required,
+			FieldType:  *fieldType,
+			Options:    splitOptions(*options),
+		})
+		if err != nil {
+			return err
+		}
+		return c.printField(field)
+
+	case "edit":
+		title := flags.String("title", "", "new field title")
+		required := flags.Bool("required", false, "whether respondents have to answer the field")
+		fieldType := flags.String("type", "", "new field type, the type is kept when not given")
+		options := flags.String("options", "", "comma separated answers a choice field accepts, kept when not given")
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		fieldId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		field, err := c.client.UpdateFormField(ctx, fieldId, client.FormFieldRequest{
+			FieldTitle: *title,
+			Required:   *required,
+			FieldType:  *fieldType,
+			Options:    splitOptions(*options),
+		})
+		if err != nil {
+			return err
+		}
+		return c.printField(field)
+
+	case "delete":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		fieldId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		if err := c.client.DeleteFormField(ctx, fieldId); err != nil {
+			return err
+		}
+		if c.out.format == formatTable {
+			fmt.Fprintf(c.out.w, "deleted field %d\n", fieldId)
+		}
+		return nil
+	}
+	return errUsage
+}
+
+func (c *cli) printField(field *client.FormField) error {
+	return c.out.print(field,
+		[]string{"FIELD", "FORM", "TITLE", "TYPE", "REQUIRED"},
+		[][]string{{strconv.Itoa(field.Id), strconv.Itoa(field.FormId), cell(field.FieldTitle), field.FieldType, yesNo(field.Required)}},
+	)
+}
+
+// splitOptions turns "yes, no" into [yes no], nothing gives nil so the api keeps the options on edit
+func splitOptions(options string) []string {
+	var split []string
+	for _, option := range strings.Split(options, ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			split = append(split, option)
+		}
+	}
+	return split
+}
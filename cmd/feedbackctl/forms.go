@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dhruv15803/client"
+)
+
+func (c *cli) forms(ctx context.Context, args []string) error {
+	command, args := subcommand(args)
+	flags := flag.NewFlagSet("forms "+command, flag.ContinueOnError)
+
+	switch command {
+	case "list":
+		mine := flags.Bool("mine", false, "only list the forms you authored, including the ones not ready yet")
+		workspaceId := flags.Int("workspace", 0, "only list the forms of this workspace")
+		if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+			return errUsage
+		}
+		var forms []client.Form
+		var err error
+		if *mine {
+			forms, err = c.client.MyForms(ctx, optionalId(*workspaceId))
+		} else {
+			forms, err = c.client.Forms(ctx, optionalId(*workspaceId))
+		}
+		if err != nil {
+			return err
+		}
+		return c.printForms(forms)
+
+	case "get":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		form, err := c.client.Form(ctx, formId)
+		if err != nil {
+			return err
+		}
+		return c.printForm(form)
+
+	case "create":
+		title := flags.String("title", "", "form title")
+		description := flags.String("description", "", "form description")
+		workspaceId := flags.Int("workspace", 0, "workspace to create the form in")
+		if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+			return errUsage
+		}
+		form, err := c.client.CreateForm(ctx, client.CreateFormRequest{
+			FormTitle:       *title,
+			FormDescription: *description,
+			WorkspaceId:     optionalId(*workspaceId),
+		})
+		if err != nil {
+			return err
+		}
+		return c.printForms([]client.Form{*form})
+
+	case "delete":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		if err := c.client.DeleteForm(ctx, formId); err != nil {
+			return err
+		}
+		if c.out.format == formatTable {
+			fmt.Fprintf(c.out.w, "moved form %d to the trash, forms restore %d brings it back\n", formId, formId)
+		}
+		return nil
+
+	case "trash":
+		if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+			return errUsage
+		}
+		forms, err := c.client.Trash(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(forms))
+		for _, form := range forms {
+			deletedAt := "-"
+			if form.DeletedAt != nil {
+				deletedAt = *form.DeletedAt
+			}
+			rows = append(rows, []string{strconv.Itoa(form.Id), cell(form.FormTitle), deletedAt})
+		}
+		return c.out.print(forms, []string{"ID", "TITLE", "DELETED"}, rows)
+
+	case "restore":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		form, err := c.client.RestoreForm(ctx, formId)
+		if err != nil {
+			return err
+		}
+		return c.printForms([]client.Form{*form})
+
+	case "duplicate":
+		title := flags.String("title", "", "title of the copy, defaults to \"Copy of\" the form's title")
+		workspaceId := flags.Int("workspace", 0, "workspace to put the copy in, defaults to the form's")
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		form, err := c.client.DuplicateForm(ctx, formId, copyRequest(*title, *workspaceId))
+		if err != nil {
+			return err
+		}
+		return c.printForm(form)
+
+	case "publish":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		version, err := c.client.PublishForm(ctx, formId)
+		if err != nil {
+			return err
+		}
+		return c.printVersions([]client.FormVersion{*version})
+
+	case "versions":
+		version := flags.Int("version", 0, "show the fields of this version")
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		if *version > 0 {
+			formVersion, err := c.client.FormVersion(ctx, formId, *version)
+			if err != nil {
+				return err
+			}
+			return c.printVersion(formVersion)
+		}
+		versions, err := c.client.FormVersions(ctx, formId)
+		if err != nil {
+			return err
+		}
+		return c.printVersions(versions)
+
+	case "settings":
+		edits := flags.Bool("edits", false, "let respondents edit their responses")
+		window := flags.Duration("window", 0, "how long after submitting a response can be edited, 0 for no limit")
+		withdrawal := flags.Bool("withdrawal", false, "let respondents withdraw their responses")
+		if err := flags.Parse(args); err != nil || *window < 0 {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		// without flags the settings are only shown
+		var settings *client.ResponseSettings
+		if flags.NFlag() == 0 {
+			settings, err = c.client.ResponseSettings(ctx, formId)
+		} else {
+			var editWindow *int
+			if *window > 0 {
+				seconds := int(window.Seconds())
+				editWindow = &seconds
+			}
+			settings, err = c.client.SetResponseSettings(ctx, formId, *edits, editWindow, *withdrawal)
+		}
+		if err != nil {
+			return err
+		}
+		return c.printResponseSettings(settings)
+
+	case "apply":
+		yes := flags.Bool("yes", false, "apply without asking")
+		planOnly := flags.Bool("plan", false, "only show the plan")
+		if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+			return errUsage
+		}
+		return c.apply(ctx, flags.Arg(0), *yes, *planOnly)
+	}
+	return errUsage
+}
+
+func (c *cli) printForms(forms []client.Form) error {
+	rows := make([][]string, 0, len(forms))
+	for _, form := range forms {
+		workspace := "-"
+		if form.WorkspaceId != nil {
+			workspace = strconv.Itoa(*form.WorkspaceId)
+		}
+		rows = append(rows, []string{strconv.Itoa(form.Id), cell(form.FormTitle), yesNo(form.IsReady), workspace, form.CreatedAt})
+	}
+	return c.out.print(forms, []string{"ID", "TITLE", "READY", "WORKSPACE", "CREATED"}, rows)
+}
+
+// printForm shows one form with its fields
+func (c *cli) printForm(form *client.Form) error {
+	if c.out.format == formatJSON {
+		return c.out.print(form, nil, nil)
+	}
+
+	fmt.Fprintf(c.out.w, "%d  %s\n%s\n\n", form.Id, cell(form.FormTitle), form.FormDescription)
+	return c.printFields(form.FormFields)
+}
+
+func (c *cli) printFields(fields []client.FormField) error {
+	rows := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		rows = append(rows, []string{strconv.Itoa(field.Id), cell(field.FieldTitle), field.FieldType, yesNo(field.Required)})
+	}
+	return c.out.table([]string{"FIELD", "TITLE", "TYPE", "REQUIRED"}, rows)
+}
+
+func (c *cli) printVersions(versions []client.FormVersion) error {
+	rows := make([][]string, 0, len(versions))
+	for _, version := range versions {
+		publishedBy := "-"
+		if version.PublishedBy != nil {
+			publishedBy = strconv.Itoa(*version.PublishedBy)
+		}
+		rows = append(rows, []string{strconv.Itoa(version.Version), cell(version.FormTitle), strconv.Itoa(len(version.FormFields)), publishedBy, version.PublishedAt})
+	}
+	return c.out.print(versions, []string{"VERSION", "TITLE", "FIELDS", "PUBLISHED BY", "PUBLISHED"}, rows)
+}
+
+// printVersion shows a published version with its fields, as its respondents saw it
+func (c *cli) printVersion(version *client.FormVersion) error {
+	if c.out.format == formatJSON {
+		return c.out.print(version, nil, nil)
+	}
+
+	fmt.Fprintf(c.out.w, "%d v%d  %s\n%s\n\n", version.FormId, version.Version, cell(version.FormTitle), version.FormDescription)
+	return c.printFields(version.FormFields)
+}
+
+func (c *cli) printResponseSettings(settings *client.ResponseSettings) error {
+	window := "-"
+	if settings.EditWindow != nil {
+		window = (time.Duration(*settings.EditWindow) * time.Second).String()
+	}
+	rows := [][]string{{strconv.Itoa(settings.FormId), yesNo(settings.AllowEdits), window, yesNo(settings.AllowWithdrawal)}}
+	return c.out.print(settings, []string{"FORM", "EDITS", "EDIT WINDOW", "WITHDRAWAL"}, rows)
+}
@@ -0,0 +1,208 @@
+// feedbackctl manages forms from the terminal through the api.
+//
+//	feedbackctl -server https://feedback.example.com login -email someone@example.com
+//	feedbackctl forms list -mine
+//	feedbackctl forms create -title "Sprint 42 retro" -description "what went well, what didn't"
+//	feedbackctl fields add -form 7 -title "What went well?" -required
+//	feedbackctl forms apply retro.yaml
+//	feedbackctl templates use -title "1:1 week 43" 12
+//	feedbackctl -o json responses export -format json 7 > retro.json
+//	feedbackctl responses tail 7
+//
+// login keeps the session token in the user config dir (~/.config/feedbackctl/credentials.json on linux)
+// so later commands don't have to log in again, -token or FEEDBACK_TOKEN take precedence over it.
+// flags go before the positional arguments of a command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/dhruv15803/client"
+)
+
+const defaultServer = "http://localhost:8080"
+
+const usage = `usage: feedbackctl [flags] COMMAND [ARGS]
+
+commands:
+  login -email EMAIL [-password PASSWORD]   start a session, the password is read from stdin when not given
+  logout                                    end the session
+  whoami                                    show the logged in user
+
+  forms list [-mine] [-workspace ID]
+  forms get ID
+  forms create -title TITLE -description DESCRIPTION [-workspace ID]
+  forms delete ID                           move the form to the trash
+  forms trash                               list deleted forms you can restore
+  forms restore ID
+  forms apply [-plan] [-yes] FILE           create or update a form from a yaml or json definition
+  forms duplicate [-title TITLE] [-workspace ID] ID
+  forms publish ID                          publish the current fields, new responses answer them
+  forms versions [-version N] ID
+  forms settings [-edits] [-window 24h] [-withdrawal] ID   set what respondents can do with their responses,
+                                            flags left out are turned off, without flags the settings are shown
+
+  fields add -form ID -title TITLE [-required] [-type TYPE] [-options A,B]
+  fields edit -title TITLE [-required] [-type TYPE] [-options A,B] ID
+  fields delete ID
+
+  templates list
+  templates use [-title TITLE] [-workspace ID] TEMPLATE_ID   create a form from a template
+  templates set -visibility private|workspace|public FORM_ID
+  templates unset FORM_ID
+
+  responses list FORM_ID
+  responses show RESPONSE_ID
+  responses edit -answer FIELD_ID=VALUE [-answer ...] RESPONSE_ID   change your answers, the others are kept
+  responses withdraw RESPONSE_ID
+  responses edits RESPONSE_ID               answers as they were before each edit
+  responses draft save -answer FIELD_ID=VALUE [-answer ...] FORM_ID   save answers without submitting them,
+                                            the ones already in the draft are kept
+  responses draft show FORM_ID
+  responses draft list
+  responses draft discard FORM_ID
+  responses draft submit FORM_ID            submit the draft as your response
+  responses export [-format csv|json] [-out FILE] FORM_ID
+  responses tail [-interval 5s] [-n 10] FORM_ID
+
+flags:
+`
+
+// cli is what every command runs with
+type cli struct {
+	client      *client.Client
+	server      string
+	out         *output
+	credentials *credentialsStore
+}
+
+func main() {
+	server := flag.String("server", "", "api base url, defaults to FEEDBACK_SERVER, then the server logged in to, then "+defaultServer)
+	token := flag.String("token", os.Getenv("FEEDBACK_TOKEN"), "session token, defaults to FEEDBACK_TOKEN, then the one saved by login")
+	format := flag.String("o", formatTable, "output format, table or json")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format != formatTable && *format != formatJSON {
+		fatal(fmt.Errorf("invalid output format %q, should be either %s or %s", *format, formatTable, formatJSON))
+	}
+
+	credentials, err := newCredentialsStore()
+	if err != nil {
+		fatal(err)
+	}
+	saved, err := credentials.load()
+	if err != nil {
+		fatal(err)
+	}
+
+	c := &cli{out: &output{w: os.Stdout, format: *format}, credentials: credentials}
+	c.server = firstNonEmpty(*server, os.Getenv("FEEDBACK_SERVER"), saved.Server, defaultServer)
+	opts := []client.Option{client.WithUserAgent("feedbackctl")}
+	if *token != "" {
+		opts = append(opts, client.WithToken(*token))
+	} else if saved.Token != "" && saved.Server == c.server {
+		opts = append(opts, client.WithToken(saved.Token))
+	}
+	c.client = client.New(c.server, opts...)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := c.run(ctx, args[0], args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			flag.Usage()
+			os.Exit(2)
+		}
+		fatal(err)
+	}
+}
+
+// errUsage is returned by commands called with the wrong arguments
+var errUsage = errors.New("usage")
+
+func (c *cli) run(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "login":
+		return c.login(ctx, args)
+	case "logout":
+		return c.logout(ctx)
+	case "whoami":
+		return c.whoami(ctx)
+	case "forms":
+		return c.forms(ctx, args)
+	case "fields":
+		return c.fields(ctx, args)
+	case "templates":
+		return c.templates(ctx, args)
+	case "responses":
+		return c.responses(ctx, args)
+	}
+	return errUsage
+}
+
+// subcommand splits "forms list ..." into the subcommand and its arguments
+func subcommand(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	return args[0], args[1:]
+}
+
+// idArg parses the one positional id a command takes
+func idArg(flags *flag.FlagSet) (int, error) {
+	if flags.NArg() != 1 {
+		return 0, errUsage
+	}
+	id, err := strconv.Atoi(flags.Arg(0))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid id %q", flags.Arg(0))
+	}
+	return id, nil
+}
+
+// optionalId turns the 0 of an unset id flag into nil
+func optionalId(id int) *int {
+	if id <= 0 {
+		return nil
+	}
+	return &id
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "feedbackctl: %v\n", err)
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		for _, fieldErr := range apiErr.Errors {
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", fieldErr.Field, fieldErr.Message)
+		}
+		if errors.Is(err, client.ErrUnauthorized) {
+			fmt.Fprintln(os.Stderr, "  run feedbackctl login first")
+		}
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+type output struct {
+	w      io.Writer
+	format string
+}
+
+// print writes v as indented json, or as a table of header and rows
+func (o *output) print(v any, header []string, rows [][]string) error {
+	if o.format == formatJSON {
+		encoder := json.NewEncoder(o.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+	return o.table(header, rows)
+}
+
+func (o *output) table(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// line writes v as a single line of json, for output that keeps coming like responses tail
+func (o *output) line(v any) error {
+	return json.NewEncoder(o.w).Encode(v)
+}
+
+// cell keeps tabs and newlines in user input from breaking the table up
+func cell(value string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(value)
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
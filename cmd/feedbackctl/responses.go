@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhruv15803/client"
+)
+
+func (c *cli) responses(ctx context.Context, args []string) error {
+	command, args := subcommand(args)
+	flags := flag.NewFlagSet("responses "+command, flag.ContinueOnError)
+
+	switch command {
+	case "list":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		responses, err := c.client.FormResponses(ctx, formId)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(responses))
+		for _, response := range responses {
+			version := "-"
+			if response.FormVersion != nil {
+				version = strconv.Itoa(*response.FormVersion)
+			}
+			rows = append(rows, []string{strconv.Itoa(response.Id), respondent(response), version, response.SubmittedAt})
+		}
+		return c.out.print(responses, []string{"ID", "RESPONDENT", "VERSION", "SUBMITTED"}, rows)
+
+	case "show":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		responseId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		answers, err := c.client.ResponseFields(ctx, responseId)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(answers))
+		for _, answer := range answers {
+			rows = append(rows, []string{cell(answer.FormField.FieldTitle), cell(answer.FieldValue)})
+		}
+		return c.out.print(answers, []string{"FIELD", "ANSWER"}, rows)
+
+	case "edit":
+		changes := answerFlag(flags)
+		if err := flags.Parse(args); err != nil || len(changes) == 0 {
+			return errUsage
+		}
+		responseId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		return c.editResponse(ctx, responseId, changes)
+
+	case "withdraw":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		responseId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		if err := c.client.WithdrawResponse(ctx, responseId); err != nil {
+			return err
+		}
+		if c.out.format == formatTable {
+			fmt.Fprintf(c.out.w, "withdrew response %d\n", responseId)
+		}
+		return nil
+
+	case "edits":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		responseId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		edits, err := c.client.ResponseEdits(ctx, responseId)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(edits))
+		for _, edit := range edits {
+			rows = append(rows, []string{edit.EditedAt, strconv.Itoa(edit.FormFieldId), cell(edit.FieldValue)})
+		}
+		return c.out.print(edits, []string{"EDITED", "FIELD", "PREVIOUS ANSWER"}, rows)
+
+	case "draft":
+		return c.drafts(ctx, args)
+
+	case "export":
+		format := flags.String("format", "csv", "export format, csv or json")
+		path := flags.String("out", "", "file to write the export to, defaults to stdout")
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		if *format != "csv" && *format != "json" {
+			return fmt.Errorf("invalid export format %q, should be either csv or json", *format)
+		}
+		return c.export(ctx, formId, *format, *path)
+
+	case "tail":
+		interval := flags.Duration("interval", 5*time.Second, "how often to check for new responses")
+		last := flags.Int("n", 10, "how many of the existing responses to show first")
+		if err := flags.Parse(args); err != nil || *interval < time.Second {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		return c.tail(ctx, formId, *interval, *last)
+	}
+	return errUsage
+}
+
+// answerFlag adds the repeatable -answer FIELD_ID=VALUE flag to flags, the answers end up in the returned map
+func answerFlag(flags *flag.FlagSet) map[int]string {
+	changes := make(map[int]string)
+	flags.Func("answer", "FIELD_ID=VALUE, the new answer to a field, can be repeated", func(value string) error {
+		fieldId, answer, ok := strings.Cut(value, "=")
+		id, err := strconv.Atoi(fieldId)
+		if !ok || err != nil || id <= 0 {
+			return fmt.Errorf("should look like FIELD_ID=VALUE")
+		}
+		changes[id] = answer
+		return nil
+	})
+	return changes
+}
+
+// mergeAnswers applies changes to the current answers, keeping their order. answers to fields
+// that weren't answered before come last, ordered by field id
+func mergeAnswers(current []client.Answer, changes map[int]string) []client.Answer {
+	answers := make([]client.Answer, 0, len(current)+len(changes))
+	added := make(map[int]bool)
+	for _, answer := range current {
+		if value, changed := changes[answer.FormFieldId]; changed && !added[answer.FormFieldId] {
+			answer.FieldValue = value
+		}
+		added[answer.FormFieldId] = true
+		answers = append(answers, answer)
+	}
+	fieldIds := make([]int, 0, len(changes))
+	for fieldId := range changes {
+		if !added[fieldId] {
+			fieldIds = append(fieldIds, fieldId)
+		}
+	}
+	sort.Ints(fieldIds)
+	for _, fieldId := range fieldIds {
+		answers = append(answers, client.Answer{FormFieldId: fieldId, FieldValue: changes[fieldId]})
+	}
+	return answers
+}
+
+// editResponse changes the given answers of a response, the api replaces every answer so the others are sent as they are
+func (c *cli) editResponse(ctx context.Context, responseId int, changes map[int]string) error {
+	current, err := c.client.ResponseFields(ctx, responseId)
+	if err != nil {
+		return err
+	}
+
+	answers := make([]client.Answer, 0, len(current))
+	for _, answer := range current {
+		answers = append(answers, client.Answer{FormFieldId: answer.FormFieldId, FieldValue: answer.FieldValue})
+	}
+
+	edited, err := c.client.EditResponse(ctx, responseId, mergeAnswers(answers, changes))
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(edited.ResponseFields))
+	for _, answer := range edited.ResponseFields {
+		rows = append(rows, []string{cell(answer.FormField.FieldTitle), cell(answer.FieldValue)})
+	}
+	return c.out.print(edited, []string{"FIELD", "ANSWER"}, rows)
+}
+
+func (c *cli) export(ctx context.Context, formId int, format string, path string) error {
+	var w io.Writer = c.out.w
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if format == "csv" {
+		return c.client.ExportResponsesCSV(ctx, formId, w)
+	}
+	_, responses, err := c.client.ExportResponses(ctx, formId)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(responses)
+}
+
+// tail polls the form for responses it hasn't shown yet until interrupted, with -o json
+// every response is written as a line of json so it can be piped into jq
+func (c *cli) tail(ctx context.Context, formId int, interval time.Duration, last int) error {
+	form, err := c.client.Form(ctx, formId)
+	if err != nil {
+		return err
+	}
+	responses, err := c.client.FormResponses(ctx, formId)
+	if err != nil {
+		return err
+	}
+
+	sortResponses(responses)
+	seen := make(map[int]bool, len(responses))
+	for i := 0; i < len(responses)-last; i++ {
+		seen[responses[i].Id] = true
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, response := range responses {
+			if seen[response.Id] {
+				continue
+			}
+			exported, err := c.client.ExportResponse(ctx, form, response)
+			if err != nil {
+				return err
+			}
+			if err := c.printTailed(exported); err != nil {
+				return err
+			}
+			seen[response.Id] = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if responses, err = c.client.FormResponses(ctx, formId); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		sortResponses(responses)
+	}
+}
+
+// sortResponses puts the oldest response first so the newest ends up at the bottom like tail does
+func sortResponses(responses []client.FormResponse) {
+	sort.Slice(responses, func(i, j int) bool {
+		return responses[i].Id < responses[j].Id
+	})
+}
+
+func (c *cli) printTailed(response *client.ExportedResponse) error {
+	if c.out.format == formatJSON {
+		return c.out.line(response)
+	}
+	answers := make([]string, 0, len(response.Answers))
+	for _, answer := range response.Answers {
+		answers = append(answers, fmt.Sprintf("%s=%q", cell(answer.FieldTitle), answer.FieldValue))
+	}
+	_, err := fmt.Fprintf(c.out.w, "%s  #%d  %s  %s\n", response.SubmittedAt, response.Id, response.Respondent, strings.Join(answers, " "))
+	return err
+}
+
+func respondent(response client.FormResponse) string {
+	if response.Respondent != nil {
+		return response.Respondent.Email
+	}
+	return strconv.Itoa(response.RespondentId)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+
+	"github.com/dhruv15803/client"
+)
+
+func (c *cli) templates(ctx context.Context, args []string) error {
+	command, args := subcommand(args)
+	flags := flag.NewFlagSet("templates "+command, flag.ContinueOnError)
+
+	switch command {
+	case "list":
+		if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+			return errUsage
+		}
+		templates, err := c.client.Templates(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(templates))
+		for _, template := range templates {
+			author := "-"
+			if template.User != nil {
+				author = template.User.Email
+			}
+			rows = append(rows, []string{strconv.Itoa(template.Id), cell(template.FormTitle), visibility(template), author})
+		}
+		return c.out.print(templates, []string{"ID", "TITLE", "VISIBILITY", "AUTHOR"}, rows)
+
+	case "use":
+		title := flags.String("title", "", "title of the new form, defaults to the template's")
+		workspaceId := flags.Int("workspace", 0, "workspace to create the form in, defaults to the template's")
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		templateId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		form, err := c.client.InstantiateTemplate(ctx, templateId, copyRequest(*title, *workspaceId))
+		if err != nil {
+			return err
+		}
+		return c.printForm(form)
+
+	case "set":
+		visibility := flags.String("visibility", client.TemplatePrivate, "who can use the template, private, workspace or public")
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		form, err := c.client.SetFormTemplate(ctx, formId, *visibility)
+		if err != nil {
+			return err
+		}
+		return c.printTemplate(form)
+
+	case "unset":
+		if err := flags.Parse(args); err != nil {
+			return errUsage
+		}
+		formId, err := idArg(flags)
+		if err != nil {
+			return err
+		}
+		form, err := c.client.UnsetFormTemplate(ctx, formId)
+		if err != nil {
+			return err
+		}
+		return c.printTemplate(form)
+	}
+	return errUsage
+}
+
+func (c *cli) printTemplate(form *client.Form) error {
+	return c.out.print(form,
+		[]string{"ID", "TITLE", "VISIBILITY"},
+		[][]string{{strconv.Itoa(form.Id), cell(form.FormTitle), visibility(*form)}},
+	)
+}
+
+// copyRequest leaves out what wasn't given so the api picks the defaults
+func copyRequest(title string, workspaceId int) client.CopyFormRequest {
+	req := client.CopyFormRequest{WorkspaceId: optionalId(workspaceId)}
+	if title != "" {
+		req.FormTitle = &title
+	}
+	return req
+}
+
+func visibility(form client.Form) string {
+	if form.TemplateVisibility == nil {
+		return "-"
+	}
+	return *form.TemplateVisibility
+}
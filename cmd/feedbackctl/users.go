@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dhruv15803/client"
+)
+
+func (c *cli) login(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("login", flag.ContinueOnError)
+	email := flags.String("email", "", "email to log in with")
+	password := flags.String("password", os.Getenv("FEEDBACK_PASSWORD"), "password, defaults to FEEDBACK_PASSWORD, then a line read from stdin")
+	if err := flags.Parse(args); err != nil || *email == "" {
+		return errUsage
+	}
+
+	if *password == "" {
+		fmt.Fprint(os.Stderr, "password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("reading the password :- %w", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	user, err := c.client.Login(ctx, *email, *password)
+	if err != nil {
+		return err
+	}
+	if err := c.credentials.save(credentials{Server: c.server, Token: c.client.Token()}); err != nil {
+		return fmt.Errorf("saving the session :- %w", err)
+	}
+	return c.printUser(user)
+}
+
+// logout forgets the saved token even when the api can't be reached, the server is kept for the next login
+func (c *cli) logout(ctx context.Context) error {
+	err := c.client.Logout(ctx)
+	if saveErr := c.credentials.save(credentials{Server: c.server}); saveErr != nil {
+		return saveErr
+	}
+	return err
+}
+
+func (c *cli) whoami(ctx context.Context) error {
+	user, err := c.client.Me(ctx)
+	if err != nil {
+		return err
+	}
+	return c.printUser(user)
+}
+
+func (c *cli) printUser(user *client.User) error {
+	return c.out.print(user,
+		[]string{"ID", "USERNAME", "EMAIL", "ROLE"},
+		[][]string{{strconv.Itoa(user.Id), cell(user.Username), user.Email, user.Role}},
+	)
+}
@@ -0,0 +1,129 @@
+// migrate applies the schema migrations embedded from cmd/migrate/migrations to the database in DB_CONN.
+//
+//	DB_CONN=... go run ./cmd/migrate up
+//	DB_CONN=... go run ./cmd/migrate down 2
+//	DB_CONN=... go run ./cmd/migrate goto 7
+//	DB_CONN=... go run ./cmd/migrate status
+//	go run ./cmd/migrate create add_form_tags
+//
+// DB_CONN takes the same values as the api, sqlite://path/to/file.db gets the sqlite migrations.
+// create writes the new empty files for both postgres and sqlite under -dir.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/dhruv15803/cmd/migrate/migrations"
+	"github.com/dhruv15803/internal/migrate"
+	"github.com/dhruv15803/internal/storage"
+)
+
+func main() {
+	dbConn := flag.String("db", os.Getenv("DB_CONN"), "database connection string, defaults to DB_CONN")
+	dir := flag.String("dir", filepath.Join("cmd", "migrate", "migrations"), "migrations directory, only used by create")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: migrate [flags] up | down [N] | goto V | status | create NAME\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		paths, err := migrate.Create(args[1], *dir, filepath.Join(*dir, "sqlite"))
+		if err != nil {
+			log.Fatalf("failed to create migration :- %v", err.Error())
+		}
+		for _, path := range paths {
+			log.Printf("created %s", path)
+		}
+		return
+	}
+
+	db, driver, err := storage.Open(*dbConn)
+	if err != nil {
+		log.Fatalf("DB CONNECTION FAILED:- %v", err.Error())
+	}
+	defer db.Close()
+
+	source, err := migrations.FS(driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrator, err := migrate.New(db, driver, source)
+	if err != nil {
+		log.Fatalf("failed to load migrations :- %v", err.Error())
+	}
+	migrator.Logf = log.Printf
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			if n, err = strconv.Atoi(args[1]); err != nil {
+				log.Fatalf("invalid number of steps %q", args[1])
+			}
+		}
+		err = migrator.Down(ctx, n)
+	case "goto":
+		if len(args) != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			log.Fatalf("invalid version %q", args[1])
+		}
+		err = migrator.Goto(ctx, version)
+	case "status":
+		err = printStatus(ctx, migrator)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("migrate %s failed :- %v", args[0], err.Error())
+	}
+}
+
+func printStatus(ctx context.Context, migrator *migrate.Migrator) error {
+	version, dirty, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		state := "pending"
+		if status.Applied {
+			state = "applied"
+		}
+		fmt.Printf("%06d  %-8s %s\n", status.Version, state, status.Name)
+	}
+	if dirty {
+		fmt.Printf("database is at version %d and DIRTY\n", version)
+	} else {
+		fmt.Printf("database is at version %d\n", version)
+	}
+	return nil
+}
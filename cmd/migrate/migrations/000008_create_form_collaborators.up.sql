@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS form_collaborators (
+    form_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY(form_id, user_id),
+    FOREIGN KEY(form_id) REFERENCES forms(id) ON DELETE CASCADE,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
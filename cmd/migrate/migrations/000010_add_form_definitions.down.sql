@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS form_fields_form_id_field_key_idx;
+ALTER TABLE form_fields
+    DROP COLUMN IF EXISTS options,
+    DROP COLUMN IF EXISTS position,
+    DROP COLUMN IF EXISTS field_type,
+    DROP COLUMN IF EXISTS field_key;
+
+DROP INDEX IF EXISTS forms_form_key_idx;
+ALTER TABLE forms DROP COLUMN IF EXISTS form_key;
@@ -0,0 +1,13 @@
+-- form keys are unique across the instance so a form definition kept in git always points at the same form
+ALTER TABLE forms ADD COLUMN IF NOT EXISTS form_key VARCHAR(100);
+CREATE UNIQUE INDEX IF NOT EXISTS forms_form_key_idx ON forms(form_key);
+
+ALTER TABLE form_fields
+    ADD COLUMN IF NOT EXISTS field_key VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS field_type VARCHAR(20) NOT NULL DEFAULT 'text' CHECK (field_type IN ('text', 'long_text', 'number', 'email', 'date', 'choice')),
+    ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS options TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS form_fields_form_id_field_key_idx ON form_fields(form_id, field_key);
+
+-- existing fields keep the order they were added in
+UPDATE form_fields SET position = id;
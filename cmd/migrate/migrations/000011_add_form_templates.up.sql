@@ -0,0 +1,3 @@
+-- a form with a template_visibility is a template others can create forms from, NULL for regular forms
+ALTER TABLE forms ADD COLUMN IF NOT EXISTS template_visibility VARCHAR(20) CHECK (template_visibility IN ('private', 'workspace', 'public'));
+CREATE INDEX IF NOT EXISTS forms_template_visibility_idx ON forms(template_visibility) WHERE template_visibility IS NOT NULL;
@@ -0,0 +1,8 @@
+DELETE FROM response_fields WHERE form_field_id NOT IN (SELECT id FROM form_fields);
+ALTER TABLE response_fields ADD CONSTRAINT response_fields_form_field_id_fkey
+    FOREIGN KEY(form_field_id) REFERENCES form_fields(id) ON DELETE CASCADE;
+
+ALTER TABLE form_responses DROP CONSTRAINT IF EXISTS form_responses_form_version_fkey;
+ALTER TABLE form_responses DROP COLUMN IF EXISTS form_version;
+ALTER TABLE forms DROP COLUMN IF EXISTS published_version;
+DROP TABLE IF EXISTS form_versions;
@@ -0,0 +1,24 @@
+-- publishing a form freezes its title, description and fields (a json array) as the next version
+CREATE TABLE IF NOT EXISTS form_versions (
+    id BIGSERIAL PRIMARY KEY,
+    form_id BIGINT NOT NULL,
+    version INTEGER NOT NULL,
+    form_title VARCHAR(455) NOT NULL,
+    form_description TEXT NOT NULL,
+    form_fields TEXT NOT NULL,
+    published_by BIGINT,
+    published_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE(form_id, version),
+    FOREIGN KEY(form_id) REFERENCES forms(id) ON DELETE CASCADE,
+    FOREIGN KEY(published_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+ALTER TABLE forms ADD COLUMN IF NOT EXISTS published_version INTEGER;
+
+-- responses submitted before versions existed keep a NULL version
+ALTER TABLE form_responses ADD COLUMN IF NOT EXISTS form_version INTEGER;
+ALTER TABLE form_responses ADD CONSTRAINT form_responses_form_version_fkey
+    FOREIGN KEY(form_id, form_version) REFERENCES form_versions(form_id, version) ON DELETE CASCADE;
+
+-- answers outlive the field they answered, the version they were submitted against describes the field
+ALTER TABLE response_fields DROP CONSTRAINT IF EXISTS response_fields_form_field_id_fkey;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS response_field_edits;
+ALTER TABLE form_responses DROP COLUMN IF EXISTS updated_at;
+DROP TABLE IF EXISTS form_response_settings;
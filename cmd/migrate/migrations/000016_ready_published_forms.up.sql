@@ -0,0 +1,2 @@
+-- a published form keeps taking responses against its published version whatever happens to its draft fields
+UPDATE forms SET is_ready = TRUE WHERE published_version IS NOT NULL;
@@ -0,0 +1,2 @@
+-- bumped by every password change, tokens signed for an older version are no longer accepted
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE forms DROP CONSTRAINT IF EXISTS forms_workspace_id_fkey;
+ALTER TABLE forms ADD CONSTRAINT forms_workspace_id_fkey
+    FOREIGN KEY(workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
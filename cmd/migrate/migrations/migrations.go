@@ -0,0 +1,29 @@
+// Package migrations embeds the schema migrations so the binaries can apply them without the source tree.
+//
+// every change to the schema ships as a numbered pair of up/down files in this directory for postgres
+// and a pair with the same number under sqlite/ for the sqlite backend.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+//go:embed *.sql
+var postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqlite embed.FS
+
+// FS returns the migrations written for the given database/sql driver
+func FS(driver string) (fs.FS, error) {
+	switch driver {
+	case "postgres":
+		return postgres, nil
+	case "sqlite":
+		return fs.Sub(sqlite, "sqlite")
+	default:
+		return nil, fmt.Errorf("no migrations for driver %q", driver)
+	}
+}
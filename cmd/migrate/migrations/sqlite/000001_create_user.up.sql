@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS users(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email VARCHAR(455) UNIQUE NOT NULL,
+    username VARCHAR(455) UNIQUE NOT NULL,
+    password VARCHAR(455) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS forms(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    form_title VARCHAR(455) NOT NULL,
+    form_description TEXT NOT NULL,
+    is_ready BOOLEAN NOT NULL DEFAULT FALSE,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
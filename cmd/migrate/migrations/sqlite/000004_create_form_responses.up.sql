@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS form_responses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    form_id BIGINT NOT NULL,
+    respondent_id BIGINT NOT NULL,
+    submitted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(form_id) REFERENCES forms(id) ON DELETE CASCADE,
+    FOREIGN KEY(respondent_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+ALTER TABLE forms DROP COLUMN workspace_id;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
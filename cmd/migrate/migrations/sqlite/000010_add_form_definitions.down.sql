@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS form_fields_form_id_field_key_idx;
+ALTER TABLE form_fields DROP COLUMN options;
+ALTER TABLE form_fields DROP COLUMN position;
+ALTER TABLE form_fields DROP COLUMN field_type;
+ALTER TABLE form_fields DROP COLUMN field_key;
+
+DROP INDEX IF EXISTS forms_form_key_idx;
+ALTER TABLE forms DROP COLUMN form_key;
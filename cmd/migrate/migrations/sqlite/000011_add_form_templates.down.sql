@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS forms_template_visibility_idx;
+ALTER TABLE forms DROP COLUMN template_visibility;
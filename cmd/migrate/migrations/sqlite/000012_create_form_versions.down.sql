@@ -0,0 +1,17 @@
+CREATE TABLE response_fields_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    field_value TEXT NOT NULL,
+    form_response_id BIGINT NOT NULL,
+    form_field_id BIGINT NOT NULL,
+    FOREIGN KEY(form_response_id) REFERENCES form_responses(id) ON DELETE CASCADE,
+    FOREIGN KEY(form_field_id) REFERENCES form_fields(id) ON DELETE CASCADE
+);
+INSERT INTO response_fields_old (id, field_value, form_response_id, form_field_id)
+    SELECT id, field_value, form_response_id, form_field_id FROM response_fields
+    WHERE form_field_id IN (SELECT id FROM form_fields);
+DROP TABLE response_fields;
+ALTER TABLE response_fields_old RENAME TO response_fields;
+
+ALTER TABLE form_responses DROP COLUMN form_version;
+ALTER TABLE forms DROP COLUMN published_version;
+DROP TABLE IF EXISTS form_versions;
@@ -0,0 +1,34 @@
+-- publishing a form freezes its title, description and fields (a json array) as the next version
+CREATE TABLE IF NOT EXISTS form_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    form_id BIGINT NOT NULL,
+    version INTEGER NOT NULL,
+    form_title VARCHAR(455) NOT NULL,
+    form_description TEXT NOT NULL,
+    form_fields TEXT NOT NULL,
+    published_by BIGINT,
+    published_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(form_id, version),
+    FOREIGN KEY(form_id) REFERENCES forms(id) ON DELETE CASCADE,
+    FOREIGN KEY(published_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+ALTER TABLE forms ADD COLUMN published_version INTEGER;
+
+-- responses submitted before versions existed keep a NULL version. sqlite can't add the
+-- (form_id, form_version) foreign key to an existing table without rebuilding form_responses,
+-- which would cascade into response_fields, so the storage layer only ever writes published versions
+ALTER TABLE form_responses ADD COLUMN form_version INTEGER;
+
+-- answers outlive the field they answered, the version they were submitted against describes the field
+CREATE TABLE response_fields_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    field_value TEXT NOT NULL,
+    form_response_id BIGINT NOT NULL,
+    form_field_id BIGINT NOT NULL,
+    FOREIGN KEY(form_response_id) REFERENCES form_responses(id) ON DELETE CASCADE
+);
+INSERT INTO response_fields_new (id, field_value, form_response_id, form_field_id)
+    SELECT id, field_value, form_response_id, form_field_id FROM response_fields;
+DROP TABLE response_fields;
+ALTER TABLE response_fields_new RENAME TO response_fields;
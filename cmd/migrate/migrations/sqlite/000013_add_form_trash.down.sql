@@ -0,0 +1,4 @@
+-- forms still in the trash are gone for good without the column that hides them
+DELETE FROM forms WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS forms_deleted_at_idx;
+ALTER TABLE forms DROP COLUMN deleted_at;
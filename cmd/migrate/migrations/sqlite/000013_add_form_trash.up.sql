@@ -0,0 +1,3 @@
+-- deleting a form moves it to the trash, it is purged for good once it has been there for the retention period
+ALTER TABLE forms ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS forms_deleted_at_idx ON forms(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,23 @@
+-- what respondents may do with a response once it is submitted, forms without a row allow neither.
+-- edit_window is in seconds after submitting, NULL leaves edits open for good
+CREATE TABLE IF NOT EXISTS form_response_settings (
+    form_id BIGINT PRIMARY KEY,
+    allow_edits BOOLEAN NOT NULL DEFAULT FALSE,
+    edit_window INTEGER CHECK (edit_window > 0),
+    allow_withdrawal BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(form_id) REFERENCES forms(id) ON DELETE CASCADE
+);
+
+ALTER TABLE form_responses ADD COLUMN updated_at TIMESTAMP;
+
+-- the answers an edit replaced, answers removed by an edit are kept here too
+CREATE TABLE IF NOT EXISTS response_field_edits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    form_response_id BIGINT NOT NULL,
+    form_field_id BIGINT NOT NULL,
+    field_value TEXT NOT NULL,
+    edited_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(form_response_id) REFERENCES form_responses(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS response_field_edits_form_response_id_idx ON response_field_edits(form_response_id);
@@ -0,0 +1,16 @@
+-- answers a respondent saved without submitting (a json array), one draft per form and respondent.
+-- drafts without expires_at are kept until they are submitted or discarded
+CREATE TABLE IF NOT EXISTS response_drafts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    form_id BIGINT NOT NULL,
+    respondent_id BIGINT NOT NULL,
+    response_fields TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    UNIQUE(form_id, respondent_id),
+    FOREIGN KEY(form_id) REFERENCES forms(id) ON DELETE CASCADE,
+    FOREIGN KEY(respondent_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS response_drafts_respondent_id_idx ON response_drafts(respondent_id);
+CREATE INDEX IF NOT EXISTS response_drafts_expires_at_idx ON response_drafts(expires_at) WHERE expires_at IS NOT NULL;
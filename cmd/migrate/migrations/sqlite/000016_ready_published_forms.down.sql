@@ -0,0 +1 @@
+-- is_ready is recomputed whenever the fields change, nothing to undo
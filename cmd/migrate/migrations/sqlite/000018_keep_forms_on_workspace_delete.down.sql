@@ -0,0 +1 @@
+DROP TRIGGER IF EXISTS forms_keep_on_workspace_delete;
@@ -0,0 +1,7 @@
+-- deleting a workspace leaves its forms with their owners instead of deleting them,
+-- sqlite can't change a foreign key without rebuilding forms so the trigger detaches
+-- the forms before the ON DELETE CASCADE runs
+CREATE TRIGGER IF NOT EXISTS forms_keep_on_workspace_delete BEFORE DELETE ON workspaces
+BEGIN
+    UPDATE forms SET workspace_id = NULL WHERE workspace_id = OLD.id;
+END;
@@ -0,0 +1,319 @@
+// Package config loads the api server settings once at startup. Values come from, lowest priority first,
+// the defaults below, an optional YAML file, a .env file in the working directory and the environment.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhruv15803/internal/password"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// MinJWTSecretLength is the shortest JWT_SECRET accepted, HS256 keys shorter than the hash output are easy to brute force
+const MinJWTSecretLength = 32
+
+type Config struct {
+	// Addr is where the server listens, a bare PORT like 8080 is turned into :8080
+	Addr string `yaml:"addr"`
+	// ClientURL is the frontend origin allowed by CORS
+	ClientURL string `yaml:"client_url"`
+	JWTSecret string `yaml:"jwt_secret"`
+	// LogLevel is one of debug, info, warn or error
+	LogLevel string          `yaml:"log_level"`
+	DB       DBConfig        `yaml:"db"`
+	Password password.Policy `yaml:"password"`
+	// TrashRetention is how long deleted forms stay in the trash before they are purged, 0 never purges them
+	TrashRetention time.Duration `yaml:"trash_retention"`
+	// DraftExpiry is how long a draft response is kept after it was last saved, 0 keeps drafts until they are submitted
+	DraftExpiry time.Duration `yaml:"draft_expiry"`
+	// ShutdownDelay is how long readyz fails before the server stops accepting connections on shutdown,
+	// long enough for the load balancer to take it out of rotation
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+	// LoginThrottle slows down and locks out repeated failed logins, per account and per client ip
+	LoginThrottle LoginThrottleConfig `yaml:"login_throttle"`
+	// TrustedProxies are the addresses or CIDR ranges of the load balancers and proxies in front of the
+	// server. X-Forwarded-For and X-Real-IP are only believed when they come from one of them, without any
+	// the client ip is the peer address, so behind a proxy every client would share one login throttle
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type LoginThrottleConfig struct {
+	Account ThrottleConfig `yaml:"account"`
+	IP      ThrottleConfig `yaml:"ip"`
+}
+
+// ThrottleConfig: the first FreeAttempts failures aren't delayed, after that every failure is,
+// and LockoutThreshold failures lock the key out for LockoutDuration
+type ThrottleConfig struct {
+	FreeAttempts     int           `yaml:"free_attempts"`
+	LockoutThreshold int           `yaml:"lockout_threshold"`
+	LockoutDuration  time.Duration `yaml:"lockout_duration"`
+}
+
+type DBConfig struct {
+	// Conn is postgres connection string, sqlite://path or memory://
+	Conn string `yaml:"conn"`
+	// QueryTimeout bounds every query, 0 leaves them bounded by the request alone
+	QueryTimeout    time.Duration `yaml:"query_timeout"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	// AutoMigrate applies pending migrations before the server starts
+	AutoMigrate bool `yaml:"auto_migrate"`
+}
+
+func Default() Config {
+	return Config{
+		Addr:     ":8080",
+		LogLevel: "info",
+		DB: DBConfig{
+			QueryTimeout:    5 * time.Second,
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Password:       password.DefaultPolicy(),
+		TrashRetention: 30 * 24 * time.Hour,
+		DraftExpiry:    14 * 24 * time.Hour,
+		ShutdownDelay:  5 * time.Second,
+		LoginThrottle: LoginThrottleConfig{
+			Account: ThrottleConfig{FreeAttempts: 3, LockoutThreshold: 10, LockoutDuration: 15 * time.Minute},
+			IP:      ThrottleConfig{FreeAttempts: 10, LockoutThreshold: 100, LockoutDuration: 15 * time.Minute},
+		},
+	}
+}
+
+// Load builds the config and validates it. yamlPath is optional, when set the file has to exist.
+func Load(yamlPath string) (*Config, error) {
+	cfg := Default()
+
+	if yamlPath != "" {
+		contents, err := os.ReadFile(yamlPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(contents, &cfg); err != nil {
+			return nil, fmt.Errorf("parsing %s :- %v", yamlPath, err)
+		}
+	}
+
+	// .env never overrides variables that are already set, a missing .env is fine
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("loading .env :- %v", err)
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (cfg *Config) loadEnv() error {
+	var errs []error
+
+	envString := func(key string, value *string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*value = v
+		}
+	}
+	envInt := func(key string, value *int) {
+		if v, ok := os.LookupEnv(key); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s should be a whole number, got %q", key, v))
+				return
+			}
+			*value = n
+		}
+	}
+	envBool := func(key string, value *bool) {
+		if v, ok := os.LookupEnv(key); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s should be true or false, got %q", key, v))
+				return
+			}
+			*value = b
+		}
+	}
+	envList := func(key string, value *[]string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*value = nil
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*value = append(*value, item)
+				}
+			}
+		}
+	}
+	envDuration := func(key string, value *time.Duration) {
+		if v, ok := os.LookupEnv(key); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s should be a duration like 5s, got %q", key, v))
+				return
+			}
+			*value = d
+		}
+	}
+
+	envString("PORT", &cfg.Addr)
+	envString("CLIENT_URL", &cfg.ClientURL)
+	envString("JWT_SECRET", &cfg.JWTSecret)
+	envString("LOG_LEVEL", &cfg.LogLevel)
+	envDuration("TRASH_RETENTION", &cfg.TrashRetention)
+	envDuration("DRAFT_EXPIRY", &cfg.DraftExpiry)
+	envDuration("SHUTDOWN_DELAY", &cfg.ShutdownDelay)
+
+	envInt("LOGIN_ACCOUNT_FREE_ATTEMPTS", &cfg.LoginThrottle.Account.FreeAttempts)
+	envInt("LOGIN_ACCOUNT_LOCKOUT_THRESHOLD", &cfg.LoginThrottle.Account.LockoutThreshold)
+	envDuration("LOGIN_ACCOUNT_LOCKOUT_DURATION", &cfg.LoginThrottle.Account.LockoutDuration)
+	envInt("LOGIN_IP_FREE_ATTEMPTS", &cfg.LoginThrottle.IP.FreeAttempts)
+	envInt("LOGIN_IP_LOCKOUT_THRESHOLD", &cfg.LoginThrottle.IP.LockoutThreshold)
+	envDuration("LOGIN_IP_LOCKOUT_DURATION", &cfg.LoginThrottle.IP.LockoutDuration)
+	envList("TRUSTED_PROXIES", &cfg.TrustedProxies)
+
+	envString("DB_CONN", &cfg.DB.Conn)
+	envDuration("QUERY_TIMEOUT", &cfg.DB.QueryTimeout)
+	envInt("DB_MAX_OPEN_CONNS", &cfg.DB.MaxOpenConns)
+	envInt("DB_MAX_IDLE_CONNS", &cfg.DB.MaxIdleConns)
+	envDuration("DB_CONN_MAX_LIFETIME", &cfg.DB.ConnMaxLifetime)
+	envDuration("DB_CONN_MAX_IDLE_TIME", &cfg.DB.ConnMaxIdleTime)
+	envBool("AUTO_MIGRATE", &cfg.DB.AutoMigrate)
+
+	envInt("PASSWORD_MIN_LENGTH", &cfg.Password.MinLength)
+	envInt("PASSWORD_MAX_LENGTH", &cfg.Password.MaxLength)
+	envBool("PASSWORD_REQUIRE_UPPERCASE", &cfg.Password.RequireUppercase)
+	envBool("PASSWORD_REQUIRE_LOWERCASE", &cfg.Password.RequireLowercase)
+	envBool("PASSWORD_REQUIRE_DIGIT", &cfg.Password.RequireDigit)
+	envBool("PASSWORD_REQUIRE_SPECIAL", &cfg.Password.RequireSpecial)
+	envBool("PASSWORD_REJECT_COMMON", &cfg.Password.RejectCommonWords)
+	envBool("PASSWORD_REJECT_PERSONAL_INFO", &cfg.Password.RejectPersonalInfo)
+	envString("PASSWORD_SPECIAL_CHARS", &cfg.Password.SpecialChars)
+
+	if cfg.Addr != "" && !strings.Contains(cfg.Addr, ":") {
+		cfg.Addr = ":" + cfg.Addr
+	}
+	return errors.Join(errs...)
+}
+
+// Validate reports every bad setting at once so a broken deploy can be fixed in one go
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	if cfg.Addr == "" {
+		errs = append(errs, errors.New("PORT is required"))
+	}
+	if len(cfg.JWTSecret) < MinJWTSecretLength {
+		errs = append(errs, fmt.Errorf("JWT_SECRET should be at least %d characters long", MinJWTSecretLength))
+	}
+	if cfg.ClientURL != "" {
+		if u, err := url.Parse(cfg.ClientURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("CLIENT_URL should be an origin like https://example.com, got %q", cfg.ClientURL))
+		}
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL should be debug, info, warn or error, got %q", cfg.LogLevel))
+	}
+	if cfg.TrashRetention < 0 {
+		errs = append(errs, errors.New("TRASH_RETENTION can't be negative"))
+	}
+	if cfg.DraftExpiry < 0 {
+		errs = append(errs, errors.New("DRAFT_EXPIRY can't be negative"))
+	}
+	if cfg.ShutdownDelay < 0 {
+		errs = append(errs, errors.New("SHUTDOWN_DELAY can't be negative"))
+	}
+	errs = append(errs, cfg.LoginThrottle.Account.validate("LOGIN_ACCOUNT")...)
+	errs = append(errs, cfg.LoginThrottle.IP.validate("LOGIN_IP")...)
+	for _, proxy := range cfg.TrustedProxies {
+		if _, err := parseProxy(proxy); err != nil {
+			errs = append(errs, fmt.Errorf("TRUSTED_PROXIES should hold ip addresses or CIDR ranges, got %q", proxy))
+		}
+	}
+
+	if strings.TrimSpace(cfg.DB.Conn) == "" {
+		errs = append(errs, errors.New("DB_CONN is required"))
+	}
+	if cfg.DB.QueryTimeout < 0 {
+		errs = append(errs, errors.New("QUERY_TIMEOUT can't be negative"))
+	}
+	if cfg.DB.MaxOpenConns < 0 || cfg.DB.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS can't be negative"))
+	}
+	if cfg.DB.ConnMaxLifetime < 0 || cfg.DB.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME can't be negative"))
+	}
+
+	if cfg.Password.MinLength < 0 {
+		errs = append(errs, errors.New("PASSWORD_MIN_LENGTH can't be negative"))
+	}
+	if cfg.Password.MaxLength > 0 && cfg.Password.MaxLength < cfg.Password.MinLength {
+		errs = append(errs, errors.New("PASSWORD_MAX_LENGTH can't be less than PASSWORD_MIN_LENGTH"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validate names the settings after their environment variables, prefix is LOGIN_ACCOUNT or LOGIN_IP
+func (t ThrottleConfig) validate(prefix string) []error {
+	var errs []error
+	if t.FreeAttempts < 0 {
+		errs = append(errs, fmt.Errorf("%s_FREE_ATTEMPTS can't be negative", prefix))
+	}
+	if t.LockoutThreshold <= t.FreeAttempts {
+		errs = append(errs, fmt.Errorf("%s_LOCKOUT_THRESHOLD should be more than %s_FREE_ATTEMPTS", prefix, prefix))
+	}
+	if t.LockoutDuration <= 0 {
+		errs = append(errs, fmt.Errorf("%s_LOCKOUT_DURATION should be above 0", prefix))
+	}
+	return errs
+}
+
+// TrustedProxyPrefixes is TrustedProxies parsed, a bare address is a range of one. Validate has already
+// made sure they parse
+func (cfg *Config) TrustedProxyPrefixes() []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cfg.TrustedProxies))
+	for _, proxy := range cfg.TrustedProxies {
+		if prefix, err := parseProxy(proxy); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+func parseProxy(proxy string) (netip.Prefix, error) {
+	if strings.Contains(proxy, "/") {
+		prefix, err := netip.ParsePrefix(proxy)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// SlogLevel is LogLevel parsed, Validate has already made sure it parses
+func (cfg *Config) SlogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
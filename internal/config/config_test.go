@@ -0,0 +1,232 @@
+package config
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envKeys is every variable loadEnv reads
+var envKeys = []string{
+	"PORT", "CLIENT_URL", "JWT_SECRET", "LOG_LEVEL", "TRASH_RETENTION", "DRAFT_EXPIRY", "SHUTDOWN_DELAY",
+	"LOGIN_ACCOUNT_FREE_ATTEMPTS", "LOGIN_ACCOUNT_LOCKOUT_THRESHOLD", "LOGIN_ACCOUNT_LOCKOUT_DURATION",
+	"LOGIN_IP_FREE_ATTEMPTS", "LOGIN_IP_LOCKOUT_THRESHOLD", "LOGIN_IP_LOCKOUT_DURATION", "TRUSTED_PROXIES",
+	"DB_CONN", "QUERY_TIMEOUT", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "DB_CONN_MAX_LIFETIME", "DB_CONN_MAX_IDLE_TIME", "AUTO_MIGRATE",
+	"PASSWORD_MIN_LENGTH", "PASSWORD_MAX_LENGTH", "PASSWORD_REQUIRE_UPPERCASE", "PASSWORD_REQUIRE_LOWERCASE", "PASSWORD_REQUIRE_DIGIT",
+	"PASSWORD_REQUIRE_SPECIAL", "PASSWORD_REJECT_COMMON", "PASSWORD_REJECT_PERSONAL_INFO", "PASSWORD_SPECIAL_CHARS",
+}
+
+const testSecret = "test-secret-test-secret-test-secret-00"
+
+// isolate runs the test in an empty directory with none of the config variables set, Load reads .env from the
+// working directory and godotenv sets what it reads in the process environment, so both are put back afterwards
+func isolate(t *testing.T) string {
+	t.Helper()
+	for _, key := range envKeys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func writeFile(t *testing.T, path string, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	isolate(t)
+	t.Setenv("JWT_SECRET", testSecret)
+	t.Setenv("DB_CONN", "memory://")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Default()
+	want.JWTSecret = testSecret
+	want.DB.Conn = "memory://"
+	if cfg.Addr != want.Addr || cfg.LogLevel != want.LogLevel || cfg.DB != want.DB || cfg.Password != want.Password ||
+		cfg.TrashRetention != want.TrashRetention || cfg.LoginThrottle != want.LoginThrottle || cfg.TrustedProxies != nil {
+		t.Fatalf("Load returned %+v, want the defaults %+v", cfg, want)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := isolate(t)
+
+	yamlPath := filepath.Join(dir, "config.yaml")
+	writeFile(t, yamlPath, `
+addr: ":9000"
+client_url: https://yaml.example.com
+jwt_secret: `+testSecret+`
+log_level: debug
+trash_retention: 1h
+trusted_proxies: [10.0.0.0/8]
+db:
+  conn: sqlite://yaml.db
+  max_open_conns: 7
+login_throttle:
+  account:
+    free_attempts: 5
+password:
+  min_length: 20
+`)
+	// .env only fills in what the environment doesn't set
+	writeFile(t, filepath.Join(dir, ".env"), `
+LOG_LEVEL=warn
+DB_CONN=sqlite://dotenv.db
+PASSWORD_MIN_LENGTH=16
+`)
+	t.Setenv("DB_CONN", "memory://")
+	t.Setenv("PORT", "9090")
+	t.Setenv("TRUSTED_PROXIES", " 192.0.2.1 , ,10.0.0.0/8")
+
+	cfg, err := Load(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		got, want any
+	}{
+		{"default kept", cfg.DraftExpiry, Default().DraftExpiry},
+		{"default kept next to a yaml value", cfg.LoginThrottle.Account.LockoutThreshold, Default().LoginThrottle.Account.LockoutThreshold},
+		{"yaml over default", cfg.ClientURL, "https://yaml.example.com"},
+		{"yaml over default", cfg.TrashRetention, time.Hour},
+		{"yaml over default", cfg.DB.MaxOpenConns, 7},
+		{"yaml over default", cfg.LoginThrottle.Account.FreeAttempts, 5},
+		{".env over yaml", cfg.LogLevel, "warn"},
+		{".env over yaml", cfg.Password.MinLength, 16},
+		{"env over .env", cfg.DB.Conn, "memory://"},
+		{"env over yaml, a bare port gets a colon", cfg.Addr, ":9090"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if !slices.Equal(cfg.TrustedProxies, []string{"192.0.2.1", "10.0.0.0/8"}) {
+		t.Errorf("env over yaml: trusted proxies are %q", cfg.TrustedProxies)
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{name: "not a number", env: map[string]string{"DB_MAX_OPEN_CONNS": "lots"}, want: []string{"DB_MAX_OPEN_CONNS should be a whole number"}},
+		{name: "not a bool", env: map[string]string{"AUTO_MIGRATE": "maybe"}, want: []string{"AUTO_MIGRATE should be true or false"}},
+		{name: "not a duration", env: map[string]string{"QUERY_TIMEOUT": "5"}, want: []string{"QUERY_TIMEOUT should be a duration"}},
+		{name: "empty port", env: map[string]string{"PORT": ""}, want: []string{"PORT is required"}},
+		{name: "short secret", env: map[string]string{"JWT_SECRET": "short"}, want: []string{"JWT_SECRET should be at least"}},
+		{name: "missing db", env: map[string]string{"DB_CONN": " "}, want: []string{"DB_CONN is required"}},
+		{name: "client url without a scheme", env: map[string]string{"CLIENT_URL": "example.com"}, want: []string{"CLIENT_URL should be an origin"}},
+		{name: "unknown log level", env: map[string]string{"LOG_LEVEL": "loud"}, want: []string{"LOG_LEVEL should be"}},
+		{name: "negative retention", env: map[string]string{"TRASH_RETENTION": "-1h"}, want: []string{"TRASH_RETENTION can't be negative"}},
+		{name: "negative pool", env: map[string]string{"DB_MAX_IDLE_CONNS": "-1"}, want: []string{"DB_MAX_IDLE_CONNS can't be negative"}},
+		{name: "max length below min length", env: map[string]string{"PASSWORD_MIN_LENGTH": "12", "PASSWORD_MAX_LENGTH": "8"}, want: []string{"PASSWORD_MAX_LENGTH can't be less"}},
+		{name: "threshold below free attempts", env: map[string]string{"LOGIN_IP_LOCKOUT_THRESHOLD": "5"}, want: []string{"LOGIN_IP_LOCKOUT_THRESHOLD should be more than LOGIN_IP_FREE_ATTEMPTS"}},
+		{name: "no lockout", env: map[string]string{"LOGIN_ACCOUNT_LOCKOUT_DURATION": "0s"}, want: []string{"LOGIN_ACCOUNT_LOCKOUT_DURATION should be above 0"}},
+		{name: "bad proxy", env: map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8,proxy.internal"}, want: []string{`TRUSTED_PROXIES should hold ip addresses or CIDR ranges, got "proxy.internal"`}},
+		{name: "bad proxy range", env: map[string]string{"TRUSTED_PROXIES": "10.0.0.0/33"}, want: []string{`got "10.0.0.0/33"`}},
+		{name: "every problem at once", env: map[string]string{"JWT_SECRET": "", "LOG_LEVEL": "loud", "QUERY_TIMEOUT": "-1s"}, want: []string{"JWT_SECRET", "LOG_LEVEL", "QUERY_TIMEOUT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			t.Setenv("JWT_SECRET", testSecret)
+			t.Setenv("DB_CONN", "memory://")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := Load("")
+			if err == nil {
+				t.Fatal("Load accepted the config")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load failed with %q, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadYAMLErrors(t *testing.T) {
+	dir := isolate(t)
+	t.Setenv("JWT_SECRET", testSecret)
+	t.Setenv("DB_CONN", "memory://")
+
+	if _, err := Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("Load accepted a yaml file that doesn't exist")
+	}
+	yamlPath := filepath.Join(dir, "config.yaml")
+	writeFile(t, yamlPath, "db: [not, a, mapping]\n")
+	if _, err := Load(yamlPath); err == nil || !strings.Contains(err.Error(), "parsing "+yamlPath) {
+		t.Fatalf("Load returned %v for broken yaml", err)
+	}
+	// yaml values are validated like the environment ones
+	writeFile(t, yamlPath, "trusted_proxies: [not-an-ip]\n")
+	if _, err := Load(yamlPath); err == nil || !strings.Contains(err.Error(), "TRUSTED_PROXIES") {
+		t.Fatalf("Load returned %v for a bad proxy in yaml", err)
+	}
+}
+
+func TestThrottleConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		throttle ThrottleConfig
+		want     []string
+	}{
+		{name: "valid", throttle: ThrottleConfig{FreeAttempts: 3, LockoutThreshold: 10, LockoutDuration: time.Minute}},
+		{name: "no free attempts", throttle: ThrottleConfig{LockoutThreshold: 1, LockoutDuration: time.Minute}},
+		{name: "negative free attempts", throttle: ThrottleConfig{FreeAttempts: -1, LockoutThreshold: 10, LockoutDuration: time.Minute}, want: []string{"TEST_FREE_ATTEMPTS can't be negative"}},
+		{name: "threshold equal to free attempts", throttle: ThrottleConfig{FreeAttempts: 3, LockoutThreshold: 3, LockoutDuration: time.Minute}, want: []string{"TEST_LOCKOUT_THRESHOLD should be more than TEST_FREE_ATTEMPTS"}},
+		{name: "negative duration", throttle: ThrottleConfig{FreeAttempts: 3, LockoutThreshold: 10, LockoutDuration: -time.Minute}, want: []string{"TEST_LOCKOUT_DURATION should be above 0"}},
+		{name: "zero value", throttle: ThrottleConfig{}, want: []string{"TEST_LOCKOUT_THRESHOLD should be more than TEST_FREE_ATTEMPTS", "TEST_LOCKOUT_DURATION should be above 0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range tt.throttle.validate("TEST") {
+				got = append(got, err.Error())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("validate returned %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrustedProxyPrefixes(t *testing.T) {
+	cfg := Config{TrustedProxies: []string{"192.0.2.1", "10.1.2.3/8", "2001:db8::1"}}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.1/32"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::1/128"),
+	}
+	if got := cfg.TrustedProxyPrefixes(); !slices.Equal(got, want) {
+		t.Fatalf("TrustedProxyPrefixes returned %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,352 @@
+// Package migrate applies the numbered NNNNNN_name.up.sql / NNNNNN_name.down.sql migrations.
+//
+// the applied version is kept in a single row schema_migrations(version, dirty) table, the same
+// layout golang-migrate uses, so databases that were migrated with that tool carry on from where it left.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// advisoryLockKey is the postgres advisory lock every migrator takes, so two api instances
+// starting together with AUTO_MIGRATE don't apply the same migration twice
+const advisoryLockKey = 4783150611
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var ErrDirty = errors.New("database is dirty, a previous migration failed half way and has to be fixed by hand")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+	// Logf, when set, is told about every migration that gets applied or rolled back
+	Logf func(format string, args ...any)
+}
+
+// New reads the migrations in fsys, driver is the database/sql driver name of db ("postgres" or "sqlite")
+func New(db *sql.DB, driver string, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// Load collects the migrations at the top of fsys ordered by version, every version needs both an up and a down file
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	// files are tracked separately from their contents, a freshly created migration is still empty
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("bad migration version in %s :- %v", entry.Name(), err)
+		}
+		contents, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, migration.Name, match[2])
+		}
+		seen[fmt.Sprintf("%d.%s", version, match[3])] = true
+		if match[3] == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if !seen[fmt.Sprintf("%d.up", migration.Version)] || !seen[fmt.Sprintf("%d.down", migration.Version)] {
+			return nil, fmt.Errorf("migration %06d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Version returns the version the database is at, 0 when nothing has been applied
+func (m *Migrator) Version(ctx context.Context) (version int, dirty bool, err error) {
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return 0, false, err
+	}
+	return m.version(ctx, m.db)
+}
+
+// Status lists every known migration and whether the database has it
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	version, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, MigrationStatus{Migration: migration, Applied: migration.Version <= version})
+	}
+	return statuses, nil
+}
+
+// Latest is the version of the newest known migration, the one Up brings the database to
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up(ctx context.Context) error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+	return m.Goto(ctx, m.Latest())
+}
+
+// Down rolls back the last n applied migrations
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	if n < 1 {
+		return fmt.Errorf("down needs at least one step, got %d", n)
+	}
+	return m.withLock(ctx, func() error {
+		version, err := m.checkedVersion(ctx)
+		if err != nil {
+			return err
+		}
+		target := 0
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if m.migrations[i].Version > version {
+				continue
+			}
+			if n == 0 {
+				target = m.migrations[i].Version
+				break
+			}
+			n--
+		}
+		return m.migrateTo(ctx, version, target)
+	})
+}
+
+// Goto migrates up or down until the database is at version, 0 rolls everything back
+func (m *Migrator) Goto(ctx context.Context, version int) error {
+	if version != 0 && m.find(version) < 0 {
+		return fmt.Errorf("no migration with version %d", version)
+	}
+	return m.withLock(ctx, func() error {
+		current, err := m.checkedVersion(ctx)
+		if err != nil {
+			return err
+		}
+		return m.migrateTo(ctx, current, version)
+	})
+}
+
+func (m *Migrator) find(version int) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Migrator) checkedVersion(ctx context.Context) (int, error) {
+	version, dirty, err := m.version(ctx, m.db)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w (version %d)", ErrDirty, version)
+	}
+	return version, nil
+}
+
+func (m *Migrator) migrateTo(ctx context.Context, current int, target int) error {
+	for _, migration := range m.migrations {
+		if migration.Version > current && migration.Version <= target {
+			if err := m.step(ctx, migration, current, migration.Version, migration.Up); err != nil {
+				return err
+			}
+			m.logf("applied %06d_%s", migration.Version, migration.Name)
+			current = migration.Version
+		}
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= current && migration.Version > target {
+			previous := 0
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := m.step(ctx, migration, current, previous, migration.Down); err != nil {
+				return err
+			}
+			m.logf("rolled back %06d_%s", migration.Version, migration.Name)
+			current = previous
+		}
+	}
+	return nil
+}
+
+// step runs one migration file and moves the recorded version from "from" to "to" in the same transaction,
+// a failing migration leaves the database exactly where it was
+func (m *Migrator) step(ctx context.Context, migration Migration, from int, to int, script string) (err error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// the lock only guards postgres, re-checking inside the transaction keeps sqlite honest as well
+	version, _, err := m.version(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if version != from {
+		return fmt.Errorf("database moved to version %d while migrating, expected %d", version, from)
+	}
+
+	if strings.TrimSpace(script) != "" {
+		if _, err = tx.ExecContext(ctx, script); err != nil {
+			return fmt.Errorf("migration %06d_%s failed :- %v", migration.Version, migration.Name, err)
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if to != 0 {
+		if _, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations(version,dirty) VALUES($1,$2)`, to, false); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (m *Migrator) version(ctx context.Context, q queryer) (version int, dirty bool, err error) {
+	row := q.QueryRowContext(ctx, `SELECT version,dirty FROM schema_migrations LIMIT 1`)
+	if err := row.Scan(&version, &dirty); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
+func (m *Migrator) ensureVersionTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	return err
+}
+
+// withLock runs fn while holding the postgres advisory lock, sqlite serialises writers on its own
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return err
+	}
+	if m.driver != "postgres" {
+		return fn()
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, advisoryLockKey); err != nil {
+		return fmt.Errorf("failed to take the migration lock :- %v", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, advisoryLockKey)
+
+	return fn()
+}
+
+func (m *Migrator) logf(format string, args ...any) {
+	if m.Logf != nil {
+		m.Logf(format, args...)
+	}
+}
+
+// Create writes an empty up/down pair for the next version into every dir and returns the new file paths.
+// all dirs get the same version so the postgres and sqlite migrations stay in step.
+func Create(name string, dirs ...string) ([]string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), "_")
+	if name == "" {
+		return nil, errors.New("migration name should contain letters or digits")
+	}
+
+	next := 1
+	for _, dir := range dirs {
+		migrations, err := Load(os.DirFS(dir))
+		if err != nil {
+			return nil, err
+		}
+		if len(migrations) > 0 && migrations[len(migrations)-1].Version >= next {
+			next = migrations[len(migrations)-1].Version + 1
+		}
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, fmt.Sprintf("%06d_%s.%s.sql", next, name, direction))
+			if err := os.WriteFile(path, nil, 0o644); err != nil {
+				return paths, err
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,286 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dhruv15803/internal/migrate"
+	"github.com/dhruv15803/internal/storage"
+)
+
+// testMigrations skips version 3 so stepping down has to find the previous migration rather than count
+var testMigrations = fstest.MapFS{
+	"000001_create_a.up.sql":   {Data: []byte(`CREATE TABLE a (id INTEGER PRIMARY KEY);`)},
+	"000001_create_a.down.sql": {Data: []byte(`DROP TABLE a;`)},
+	"000002_create_b.up.sql":   {Data: []byte(`CREATE TABLE b (id INTEGER PRIMARY KEY); INSERT INTO b(id) VALUES(1);`)},
+	"000002_create_b.down.sql": {Data: []byte(`DROP TABLE b;`)},
+	"000004_create_c.up.sql":   {Data: []byte(`CREATE TABLE c (id INTEGER PRIMARY KEY);`)},
+	"000004_create_c.down.sql": {Data: []byte(`DROP TABLE c;`)},
+	"README.md":                {Data: []byte(`not a migration`)},
+}
+
+func newMigrator(t *testing.T, fsys fstest.MapFS) (*migrate.Migrator, *sql.DB) {
+	t.Helper()
+	db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, "sqlite", fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrator, db
+}
+
+// expectAt fails the test unless the database is clean at version and has exactly the tables the applied migrations create
+func expectAt(t *testing.T, migrator *migrate.Migrator, db *sql.DB, version int, tables ...string) {
+	t.Helper()
+	ctx := context.Background()
+	got, dirty, err := migrator.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != version || dirty {
+		t.Fatalf("database is at version %d (dirty %v), want %d", got, dirty, version)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name IN ('a','b','c') ORDER BY name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names, tables) {
+		t.Fatalf("database at version %d has tables %v, want %v", version, names, tables)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := migrate.Load(testMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []int
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	if !slices.Equal(versions, []int{1, 2, 4}) || migrations[0].Name != "create_a" || migrations[0].Down != "DROP TABLE a;" {
+		t.Fatalf("Load returned %+v", migrations)
+	}
+
+	if _, err := migrate.Load(fstest.MapFS{"000001_a.up.sql": {}}); err == nil {
+		t.Fatal("Load accepted a migration without a down file")
+	}
+	if _, err := migrate.Load(fstest.MapFS{"000001_a.up.sql": {}, "000001_b.down.sql": {}}); err == nil {
+		t.Fatal("Load accepted a version with two names")
+	}
+}
+
+func TestMigratorGotoAndDown(t *testing.T) {
+	migrator, db := newMigrator(t, testMigrations)
+	ctx := context.Background()
+
+	expectAt(t, migrator, db, 0)
+	if migrator.Latest() != 4 {
+		t.Fatalf("Latest is %d, want 4", migrator.Latest())
+	}
+
+	if err := migrator.Goto(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	expectAt(t, migrator, db, 2, "a", "b")
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expectAt(t, migrator, db, 4, "a", "b", "c")
+	// up on an up to date database is a no-op
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expectAt(t, migrator, db, 4, "a", "b", "c")
+
+	if err := migrator.Down(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	expectAt(t, migrator, db, 2, "a", "b")
+	if err := migrator.Goto(ctx, 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Down(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	expectAt(t, migrator, db, 1, "a")
+	// more steps than applied migrations rolls everything back
+	if err := migrator.Down(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	expectAt(t, migrator, db, 0)
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Goto(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	expectAt(t, migrator, db, 0)
+
+	if err := migrator.Goto(ctx, 3); err == nil {
+		t.Fatal("Goto accepted a version without a migration")
+	}
+	if err := migrator.Down(ctx, 0); err == nil {
+		t.Fatal("Down accepted zero steps")
+	}
+	expectAt(t, migrator, db, 0)
+}
+
+func TestMigratorStatus(t *testing.T) {
+	migrator, _ := newMigrator(t, testMigrations)
+	ctx := context.Background()
+
+	if err := migrator.Goto(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied []bool
+	for _, status := range statuses {
+		applied = append(applied, status.Applied)
+	}
+	if len(statuses) != 3 || statuses[2].Name != "create_c" || !slices.Equal(applied, []bool{true, true, false}) {
+		t.Fatalf("Status returned %+v", statuses)
+	}
+}
+
+// a failing migration rolls back with its transaction instead of leaving the database dirty
+func TestMigratorFailedMigration(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_create_a.up.sql":   testMigrations["000001_create_a.up.sql"],
+		"000001_create_a.down.sql": testMigrations["000001_create_a.down.sql"],
+		"000002_broken.up.sql":     {Data: []byte(`CREATE TABLE b (id INTEGER PRIMARY KEY); INSERT INTO missing(id) VALUES(1);`)},
+		"000002_broken.down.sql":   {Data: []byte(`DROP TABLE b;`)},
+	}
+	migrator, db := newMigrator(t, fsys)
+
+	if err := migrator.Up(context.Background()); err == nil {
+		t.Fatal("Up applied a broken migration")
+	}
+	expectAt(t, migrator, db, 1, "a")
+}
+
+func TestMigratorDirty(t *testing.T) {
+	migrator, db := newMigrator(t, testMigrations)
+	ctx := context.Background()
+
+	if err := migrator.Goto(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	// golang-migrate leaves this behind when a migration fails half way
+	if _, err := db.ExecContext(ctx, `UPDATE schema_migrations SET dirty = $1`, true); err != nil {
+		t.Fatal(err)
+	}
+
+	version, dirty, err := migrator.Version(ctx)
+	if err != nil || version != 2 || !dirty {
+		t.Fatalf("Version returned %d, %v, %v, want a dirty version 2", version, dirty, err)
+	}
+	if _, err := migrator.Status(ctx); err != nil {
+		t.Fatalf("Status on a dirty database: %v", err)
+	}
+	for name, err := range map[string]error{
+		"up":   migrator.Up(ctx),
+		"down": migrator.Down(ctx, 1),
+		"goto": migrator.Goto(ctx, 1),
+	} {
+		if !errors.Is(err, migrate.ErrDirty) {
+			t.Fatalf("%s on a dirty database returned %v, want ErrDirty", name, err)
+		}
+	}
+
+	// fixing the schema by hand and clearing the flag lets migrations carry on
+	if _, err := db.ExecContext(ctx, `UPDATE schema_migrations SET dirty = $1`, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expectAt(t, migrator, db, 4, "a", "b", "c")
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	sqliteDir := filepath.Join(dir, "sqlite")
+	if err := os.Mkdir(sqliteDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// the sqlite migrations are ahead, both get the version after the newest one
+	for _, name := range []string{"000001_a.up.sql", "000001_a.down.sql"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"000001_a.up.sql", "000001_a.down.sql", "000002_b.up.sql", "000002_b.down.sql"} {
+		if err := os.WriteFile(filepath.Join(sqliteDir, name), []byte("SELECT 1;"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := migrate.Create("  Add Form-Tags! ", dir, sqliteDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "000003_add_form_tags.up.sql"),
+		filepath.Join(dir, "000003_add_form_tags.down.sql"),
+		filepath.Join(sqliteDir, "000003_add_form_tags.up.sql"),
+		filepath.Join(sqliteDir, "000003_add_form_tags.down.sql"),
+	}
+	if !slices.Equal(paths, want) {
+		t.Fatalf("Create wrote %v, want %v", paths, want)
+	}
+
+	// the new empty pair loads, and migrating through it does nothing
+	migrations, err := migrate.Load(os.DirFS(sqliteDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := migrations[len(migrations)-1]; last.Version != 3 || last.Name != "add_form_tags" || last.Up != "" {
+		t.Fatalf("the created migration loads as %+v", last)
+	}
+	migrator, db := newMigrator(t, fstest.MapFS{
+		"000001_create_a.up.sql":        testMigrations["000001_create_a.up.sql"],
+		"000001_create_a.down.sql":      testMigrations["000001_create_a.down.sql"],
+		"000002_add_form_tags.up.sql":   {},
+		"000002_add_form_tags.down.sql": {},
+	})
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectAt(t, migrator, db, 2, "a")
+
+	if _, err := migrate.Create(" -- ", dir); err == nil {
+		t.Fatal("Create accepted a name without letters or digits")
+	}
+	if _, err := migrate.Create("missing", filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("Create wrote into a directory that doesn't exist")
+	}
+}
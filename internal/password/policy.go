@@ -0,0 +1,157 @@
+package password
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcrypt only looks at the first 72 bytes of a password, anything past that is silently ignored
+const BcryptMaxBytes = 72
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var commonPasswords = loadCommonPasswords(commonPasswordsFile)
+
+type Policy struct {
+	MinLength int `yaml:"min_length"`
+	// MaxLength is in bytes, it is capped at BcryptMaxBytes
+	MaxLength        int  `yaml:"max_length"`
+	RequireUppercase bool `yaml:"require_uppercase"`
+	RequireLowercase bool `yaml:"require_lowercase"`
+	RequireDigit     bool `yaml:"require_digit"`
+	RequireSpecial   bool `yaml:"require_special"`
+	// SpecialChars restricts what counts as a special character,
+	// when empty any unicode punctuation or symbol counts
+	SpecialChars      string `yaml:"special_chars"`
+	RejectCommonWords bool   `yaml:"reject_common"`
+	// RejectPersonalInfo rejects passwords containing the username or the email (or the part before the @)
+	RejectPersonalInfo bool `yaml:"reject_personal_info"`
+}
+
+// personalInfoMinLength is the shortest username or email part checked for, shorter ones turn up by chance
+const personalInfoMinLength = 3
+
+// Violation is one rule of the policy the password failed
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:          6,
+		MaxLength:          BcryptMaxBytes,
+		RequireUppercase:   true,
+		RequireSpecial:     true,
+		RejectCommonWords:  true,
+		RejectPersonalInfo: true,
+	}
+}
+
+// Validate returns every rule the password breaks, an empty slice means the password is acceptable.
+// personalInfo is the username and email of the account the password is for
+func (p Policy) Validate(password string, personalInfo ...string) []Violation {
+	violations := []Violation{}
+
+	maxLength := p.MaxLength
+	if maxLength <= 0 || maxLength > BcryptMaxBytes {
+		maxLength = BcryptMaxBytes
+	}
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, Violation{
+			Rule:    "min_length",
+			Message: fmt.Sprintf("password should have atleast %d characters", p.MinLength),
+		})
+	}
+	if len(password) > maxLength {
+		violations = append(violations, Violation{
+			Rule:    "max_length",
+			Message: fmt.Sprintf("password should be at most %d bytes long", maxLength),
+		})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		}
+		if p.isSpecial(c) {
+			hasSpecial = true
+		}
+	}
+
+	if p.RequireUppercase && !hasUpper {
+		violations = append(violations, Violation{Rule: "uppercase", Message: "password should have atleast 1 uppercase character"})
+	}
+	if p.RequireLowercase && !hasLower {
+		violations = append(violations, Violation{Rule: "lowercase", Message: "password should have atleast 1 lowercase character"})
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Rule: "digit", Message: "password should have atleast 1 digit"})
+	}
+	if p.RequireSpecial && !hasSpecial {
+		message := "password should have atleast 1 special character"
+		if p.SpecialChars != "" {
+			message = fmt.Sprintf("password should have atleast 1 special character out of %s", p.SpecialChars)
+		}
+		violations = append(violations, Violation{Rule: "special", Message: message})
+	}
+
+	if p.RejectCommonWords {
+		if _, ok := commonPasswords[strings.ToLower(password)]; ok {
+			violations = append(violations, Violation{Rule: "common", Message: "password is too common"})
+		}
+	}
+
+	if p.RejectPersonalInfo && containsPersonalInfo(password, personalInfo) {
+		violations = append(violations, Violation{Rule: "personal_info", Message: "password should not contain your username or email"})
+	}
+
+	return violations
+}
+
+func (p Policy) isSpecial(c rune) bool {
+	if p.SpecialChars != "" {
+		return strings.ContainsRune(p.SpecialChars, c)
+	}
+	return unicode.IsPunct(c) || unicode.IsSymbol(c)
+}
+
+func containsPersonalInfo(password string, personalInfo []string) bool {
+	password = strings.ToLower(password)
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		candidates := []string{info}
+		if local, _, ok := strings.Cut(info, "@"); ok {
+			candidates = append(candidates, local)
+		}
+		for _, candidate := range candidates {
+			if utf8.RuneCountInString(candidate) >= personalInfoMinLength && strings.Contains(password, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func loadCommonPasswords(file string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(file, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
@@ -0,0 +1,69 @@
+package password
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       Policy
+		password     string
+		personalInfo []string
+		want         []string
+	}{
+		{name: "default policy accepts", policy: DefaultPolicy(), password: "Tr0ub4dor&3"},
+		{name: "too short", policy: DefaultPolicy(), password: "Ab!", want: []string{"min_length"}},
+		{name: "length counts characters not bytes", policy: Policy{MinLength: 4}, password: "ééé", want: []string{"min_length"}},
+		{name: "multibyte characters meet the minimum", policy: Policy{MinLength: 3}, password: "ééé"},
+		{name: "too long", policy: Policy{MaxLength: 10}, password: strings.Repeat("a", 11), want: []string{"max_length"}},
+		{name: "max length is capped at the bcrypt limit", policy: Policy{MaxLength: 100}, password: strings.Repeat("a", BcryptMaxBytes+1), want: []string{"max_length"}},
+		{name: "max length is in bytes", policy: Policy{MaxLength: 5}, password: "ééé", want: []string{"max_length"}},
+		{name: "no max length means the bcrypt limit", policy: Policy{}, password: strings.Repeat("a", BcryptMaxBytes)},
+		{name: "missing uppercase", policy: Policy{RequireUppercase: true}, password: "lower", want: []string{"uppercase"}},
+		{name: "unicode uppercase counts", policy: Policy{RequireUppercase: true}, password: "Élan"},
+		{name: "missing lowercase", policy: Policy{RequireLowercase: true}, password: "UPPER", want: []string{"lowercase"}},
+		{name: "missing digit", policy: Policy{RequireDigit: true}, password: "nodigits", want: []string{"digit"}},
+		{name: "missing special", policy: Policy{RequireSpecial: true}, password: "plain", want: []string{"special"}},
+		{name: "any symbol is special by default", policy: Policy{RequireSpecial: true}, password: "plain€"},
+		{name: "special chars restrict what counts", policy: Policy{RequireSpecial: true, SpecialChars: "@#"}, password: "plain!", want: []string{"special"}},
+		{name: "special chars", policy: Policy{RequireSpecial: true, SpecialChars: "@#"}, password: "plain#"},
+		{name: "every class missing", policy: Policy{RequireUppercase: true, RequireLowercase: true, RequireDigit: true, RequireSpecial: true}, password: "", want: []string{"uppercase", "lowercase", "digit", "special"}},
+		{name: "common password", policy: Policy{RejectCommonWords: true}, password: "password", want: []string{"common"}},
+		{name: "common password in another case", policy: Policy{RejectCommonWords: true}, password: "PassWord", want: []string{"common"}},
+		{name: "common passwords allowed", policy: Policy{}, password: "password"},
+		{name: "contains the username", policy: Policy{RejectPersonalInfo: true}, password: "xxAlice99", personalInfo: []string{"alice", "someone@example.com"}, want: []string{"personal_info"}},
+		{name: "contains the email", policy: Policy{RejectPersonalInfo: true}, password: "bob@example.com!", personalInfo: []string{"robert", "bob@example.com"}, want: []string{"personal_info"}},
+		{name: "contains the email's local part", policy: Policy{RejectPersonalInfo: true}, password: "Bobby-2024", personalInfo: []string{"robert", "bobby@example.com"}, want: []string{"personal_info"}},
+		{name: "short usernames are ignored", policy: Policy{RejectPersonalInfo: true}, password: "al-the-great", personalInfo: []string{"al"}},
+		{name: "personal info allowed", policy: Policy{}, password: "alice", personalInfo: []string{"alice"}},
+		{name: "violations come in rule order", policy: DefaultPolicy(), password: "alice", personalInfo: []string{"alice"}, want: []string{"min_length", "uppercase", "special", "personal_info"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := tt.policy.Validate(tt.password, tt.personalInfo...)
+			if violations == nil {
+				t.Fatal("Validate returned nil, want an empty slice")
+			}
+			var rules []string
+			for _, violation := range violations {
+				if violation.Message == "" {
+					t.Errorf("rule %s has no message", violation.Rule)
+				}
+				rules = append(rules, violation.Rule)
+			}
+			if !slices.Equal(rules, tt.want) {
+				t.Fatalf("Validate(%q) broke %v, want %v", tt.password, rules, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicySpecialCharsMessage(t *testing.T) {
+	violations := Policy{RequireSpecial: true, SpecialChars: "@#"}.Validate("plain")
+	if len(violations) != 1 || !strings.Contains(violations[0].Message, "@#") {
+		t.Fatalf("Validate returned %+v, want a message naming the special characters", violations)
+	}
+}
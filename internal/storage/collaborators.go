@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	CollaboratorViewer = "viewer"
+	CollaboratorEditor = "editor"
+	CollaboratorOwner  = "owner"
+)
+
+type FormCollaborator struct {
+	FormId    int    `json:"form_id"`
+	UserId    int    `json:"user_id"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+	User      *User  `json:"user"`
+}
+
+type FormCollaboratorStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// AddFormCollaborator adds userId to the form, or changes their role if they already collaborate on it
+func (s *FormCollaboratorStore) AddFormCollaborator(ctx context.Context, formId int, userId int, role string) (*FormCollaborator, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var collaborator FormCollaborator
+	query := `INSERT INTO form_collaborators(form_id,user_id,role) VALUES($1,$2,$3)
+	ON CONFLICT(form_id,user_id) DO UPDATE SET role=EXCLUDED.role
+	RETURNING form_id,user_id,role,created_at`
+	row := s.db.QueryRowContext(ctx, query, formId, userId, role)
+	if err := row.Scan(&collaborator.FormId, &collaborator.UserId, &collaborator.Role, &collaborator.CreatedAt); err != nil {
+		return nil, dbError(err)
+	}
+	return &collaborator, nil
+}
+
+func (s *FormCollaboratorStore) GetFormCollaborator(ctx context.Context, formId int, userId int) (*FormCollaborator, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var collaborator FormCollaborator
+	query := `SELECT form_id,user_id,role,created_at FROM form_collaborators WHERE form_id=$1 AND user_id=$2`
+	row := s.db.QueryRowContext(ctx, query, formId, userId)
+	if err := row.Scan(&collaborator.FormId, &collaborator.UserId, &collaborator.Role, &collaborator.CreatedAt); err != nil {
+		return nil, dbError(err)
+	}
+	return &collaborator, nil
+}
+
+func (s *FormCollaboratorStore) GetFormCollaboratorsByFormId(ctx context.Context, formId int) ([]FormCollaborator, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var collaborators []FormCollaborator
+	query := `SELECT fc.form_id,fc.user_id,fc.role,fc.created_at,
+	u.id,u.email,u.username,u.password,u.created_at,u.updated_at,u.role,u.disabled_at,u.token_version
+	FROM form_collaborators AS fc INNER JOIN users AS u ON fc.user_id=u.id
+	WHERE fc.form_id=$1 ORDER BY fc.created_at`
+	rows, err := s.db.QueryContext(ctx, query, formId)
+	if err != nil {
+		return []FormCollaborator{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var collaborator FormCollaborator
+		var user User
+		if err := rows.Scan(&collaborator.FormId, &collaborator.UserId, &collaborator.Role, &collaborator.CreatedAt,
+			&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+			return []FormCollaborator{}, dbError(err)
+		}
+		collaborator.User = &user
+		collaborators = append(collaborators, collaborator)
+	}
+
+	return collaborators, nil
+}
+
+func (s *FormCollaboratorStore) DeleteFormCollaborator(ctx context.Context, formId int, userId int) error {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM form_collaborators WHERE form_id=$1 AND user_id=$2`
+	result, err := s.db.ExecContext(ctx, query, formId, userId)
+	if err != nil {
+		return dbError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if rowsAffected < 1 {
+		return fmt.Errorf("%w: collaborator with id %d not removed from form %d", ErrNotFound, userId, formId)
+	}
+	return nil
+}
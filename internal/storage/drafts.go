@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DraftAnswer is an answer saved in a draft, it is only checked against the form when the draft is submitted
+type DraftAnswer struct {
+	FormFieldId int    `json:"form_field_id"`
+	FieldValue  string `json:"field_value"`
+}
+
+// ResponseDraft holds the answers a respondent saved without submitting, there is one per form and respondent.
+// ExpiresAt is nil for drafts that are kept until they are submitted or discarded
+type ResponseDraft struct {
+	Id             int           `json:"id"`
+	FormId         int           `json:"form_id"`
+	RespondentId   int           `json:"respondent_id"`
+	ResponseFields []DraftAnswer `json:"response_fields"`
+	CreatedAt      string        `json:"created_at"`
+	UpdatedAt      string        `json:"updated_at"`
+	ExpiresAt      *string       `json:"expires_at"`
+}
+
+type ResponseDraftStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+const responseDraftColumns = `id,form_id,respondent_id,response_fields,created_at,updated_at,expires_at`
+
+// selectResponseDrafts is joined with forms to leave out drafts of forms in the trash
+const selectResponseDrafts = `SELECT d.id,d.form_id,d.respondent_id,d.response_fields,d.created_at,d.updated_at,d.expires_at
+	FROM response_drafts AS d INNER JOIN forms AS f ON d.form_id=f.id`
+
+// liveDraftWhere leaves out drafts that expired before the time in parameter $n and drafts of forms in the trash
+func liveDraftWhere(n int) string {
+	return fmt.Sprintf(`f.deleted_at IS NULL AND (d.expires_at IS NULL OR d.expires_at > $%d)`, n)
+}
+
+// scanResponseDraft scans the responseDraftColumns, the answers are kept as a json array
+func scanResponseDraft(row rowScanner, draft *ResponseDraft) error {
+	var answers string
+	if err := row.Scan(&draft.Id, &draft.FormId, &draft.RespondentId, &answers, &draft.CreatedAt, &draft.UpdatedAt, &draft.ExpiresAt); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(answers), &draft.ResponseFields); err != nil {
+		return fmt.Errorf("decoding draft answers :- %w", err)
+	}
+	return nil
+}
+
+// SaveResponseDraft replaces the respondent's draft for the form with answers,
+// expiresAt nil keeps the draft until it is submitted or discarded
+func (s *ResponseDraftStore) SaveResponseDraft(ctx context.Context, formId int, respondentId int, answers []DraftAnswer, expiresAt *time.Time) (*ResponseDraft, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	if answers == nil {
+		answers = []DraftAnswer{}
+	}
+	encoded, err := json.Marshal(answers)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	// an expired draft that wasn't purged yet starts over
+	var draft ResponseDraft
+	query := `INSERT INTO response_drafts(form_id,respondent_id,response_fields,updated_at,expires_at) VALUES($1,$2,$3,$4,$5)
+	ON CONFLICT(form_id,respondent_id) DO UPDATE SET response_fields=EXCLUDED.response_fields,updated_at=EXCLUDED.updated_at,
+	expires_at=EXCLUDED.expires_at,
+	created_at=CASE WHEN response_drafts.expires_at <= EXCLUDED.updated_at THEN EXCLUDED.updated_at ELSE response_drafts.created_at END
+	RETURNING ` + responseDraftColumns
+	row := s.db.QueryRowContext(ctx, query, formId, respondentId, string(encoded), time.Now().UTC(), expiresAt)
+	if err := scanResponseDraft(row, &draft); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &draft, nil
+}
+
+func (s *ResponseDraftStore) GetResponseDraft(ctx context.Context, formId int, respondentId int) (*ResponseDraft, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var draft ResponseDraft
+	query := selectResponseDrafts + ` WHERE d.form_id=$1 AND d.respondent_id=$2 AND ` + liveDraftWhere(3)
+	row := s.db.QueryRowContext(ctx, query, formId, respondentId, time.Now().UTC())
+	if err := scanResponseDraft(row, &draft); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &draft, nil
+}
+
+// GetResponseDraftsByRespondentId lists the drafts the respondent can still resume, the most recently saved first
+func (s *ResponseDraftStore) GetResponseDraftsByRespondentId(ctx context.Context, respondentId int) ([]ResponseDraft, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := selectResponseDrafts + ` WHERE d.respondent_id=$1 AND ` + liveDraftWhere(2) + ` ORDER BY d.updated_at DESC,d.id DESC`
+	rows, err := s.db.QueryContext(ctx, query, respondentId, time.Now().UTC())
+	if err != nil {
+		return []ResponseDraft{}, dbError(err)
+	}
+	defer rows.Close()
+
+	var drafts []ResponseDraft
+	for rows.Next() {
+		var draft ResponseDraft
+		if err := scanResponseDraft(rows, &draft); err != nil {
+			return []ResponseDraft{}, dbError(err)
+		}
+		drafts = append(drafts, draft)
+	}
+	if err := rows.Err(); err != nil {
+		return []ResponseDraft{}, dbError(err)
+	}
+	return drafts, nil
+}
+
+func (s *ResponseDraftStore) DeleteResponseDraft(ctx context.Context, formId int, respondentId int) error {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM response_drafts WHERE form_id=$1 AND respondent_id=$2 AND (expires_at IS NULL OR expires_at > $3)`
+	result, err := s.db.ExecContext(ctx, query, formId, respondentId, time.Now().UTC())
+	if err != nil {
+		return dbError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if rowsAffected < 1 {
+		return fmt.Errorf("%w: no draft of form %d for user %d", ErrNotFound, formId, respondentId)
+	}
+	return nil
+}
+
+// SubmitResponseDraft turns the respondent's draft into a response with responseFields in one transaction,
+// the draft is gone once the response exists. formVersion is the version the answers were checked against
+func (s *ResponseDraftStore) SubmitResponseDraft(ctx context.Context, formId int, respondentId int, formVersion *int, responseFields []struct {
+	FieldValue  string
+	FormFieldId int
+}) (*FormResponse, []ResponseField, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, dbError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// deleting first means a draft submitted twice at once only makes one response
+	query := `DELETE FROM response_drafts WHERE form_id=$1 AND respondent_id=$2 AND (expires_at IS NULL OR expires_at > $3)`
+	result, err := tx.ExecContext(ctx, query, formId, respondentId, time.Now().UTC())
+	if err != nil {
+		err = dbError(err)
+		return nil, nil, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		err = dbError(err)
+		return nil, nil, err
+	}
+	if rowsAffected < 1 {
+		err = fmt.Errorf("%w: no draft of form %d for user %d", ErrNotFound, formId, respondentId)
+		return nil, nil, err
+	}
+
+	formResponse, err := insertFormResponse(ctx, tx, formId, respondentId, formVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	created, err := insertResponseFields(ctx, tx, formResponse.Id, responseFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, dbError(err)
+	}
+	return formResponse, created, nil
+}
+
+// PurgeExpiredResponseDrafts deletes the drafts that expired before expiredBefore, it returns how many it deleted
+func (s *ResponseDraftStore) PurgeExpiredResponseDrafts(ctx context.Context, expiredBefore time.Time) (int, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM response_drafts WHERE expires_at IS NOT NULL AND expires_at <= $1`, expiredBefore.UTC())
+	if err != nil {
+		return 0, dbError(err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return int(purged), nil
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// the stores wrap every error they return in one of these when the cause is known, callers
+// check with errors.Is and never have to look at driver specific errors. a wrapped sql.ErrNoRows
+// is still reachable through errors.Is as well.
+var (
+	// the row asked for doesn't exist
+	ErrNotFound = errors.New("not found")
+	// the write clashes with an existing row (a unique constraint)
+	ErrConflict = errors.New("conflict")
+	// the write was refused as invalid (a foreign key, check or not null constraint)
+	ErrValidation = errors.New("validation failed")
+)
+
+// dbError classifies an error coming back from database/sql for the postgres and sqlite drivers,
+// errors it doesn't recognise are returned unchanged
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			return fmt.Errorf("%w: %w", ErrConflict, err)
+		case "foreign_key_violation", "check_violation", "not_null_violation":
+			return fmt.Errorf("%w: %w", ErrValidation, err)
+		}
+		return err
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return fmt.Errorf("%w: %w", ErrConflict, err)
+		case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY, sqlite3.SQLITE_CONSTRAINT_CHECK, sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+			return fmt.Errorf("%w: %w", ErrValidation, err)
+		}
+	}
+	return err
+}
@@ -1,45 +1,156 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
 )
 
+const (
+	FieldTypeText     = "text"
+	FieldTypeLongText = "long_text"
+	FieldTypeNumber   = "number"
+	FieldTypeEmail    = "email"
+	FieldTypeDate     = "date"
+	FieldTypeChoice   = "choice"
+)
+
+// FieldTypes are the types a field can have, answers are checked against them on submit
+var FieldTypes = []string{FieldTypeText, FieldTypeLongText, FieldTypeNumber, FieldTypeEmail, FieldTypeDate, FieldTypeChoice}
+
 type FormField struct {
-	Id         int    `json:"id"`
-	FieldTitle string `json:"field_title"`
-	Required   bool   `json:"required"`
-	FormId     int    `json:"form_id"`
+	Id         int      `json:"id"`
+	FieldKey   *string  `json:"field_key"`
+	FieldTitle string   `json:"field_title"`
+	FieldType  string   `json:"field_type"`
+	Required   bool     `json:"required"`
+	Position   int      `json:"position"`
+	Options    []string `json:"options"`
+	FormId     int      `json:"form_id"`
+}
+
+// FieldSpec is everything about a field that can be set when it is created or updated.
+// fields are listed by position, then by id. a Position of 0 puts a new field after the existing
+// ones and leaves an updated field where it is. Options are the answers a choice field accepts.
+type FieldSpec struct {
+	FieldKey   *string
+	FieldTitle string
+	FieldType  string
+	Required   bool
+	Position   int
+	Options    []string
+}
+
+// FieldChanges are field edits ApplyFieldChanges makes together: the deletes first, then the updates
+// and then the creates, so a key freed by a deleted field can be taken by a new one
+type FieldChanges struct {
+	Delete []int
+	Update []FieldUpdate
+	Create []FieldSpec
+}
+
+type FieldUpdate struct {
+	FieldId int
+	Spec    FieldSpec
 }
 
 type FormFieldStore struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
 }
 
-func (s *FormFieldStore) GetFormFieldsByFormId(formId int) ([]FormField, error) {
-	query := `SELECT id,field_title,required,form_id FROM form_fields WHERE form_id=$1`
+const formFieldColumns = `id,field_key,field_title,field_type,required,position,options,form_id`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
-	rows, err := s.db.Query(query, formId)
+// scanFormField scans the formFieldColumns, the options are kept as a json array
+func scanFormField(row rowScanner, field *FormField) error {
+	var options sql.NullString
+	if err := row.Scan(&field.Id, &field.FieldKey, &field.FieldTitle, &field.FieldType, &field.Required,
+		&field.Position, &options, &field.FormId); err != nil {
+		return err
+	}
+	return decodeFieldOptions(options, &field.Options)
+}
+
+func decodeFieldOptions(options sql.NullString, dst *[]string) error {
+	*dst = nil
+	if !options.Valid {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(options.String), dst); err != nil {
+		return fmt.Errorf("decoding field options :- %w", err)
+	}
+	return nil
+}
+
+// encodeFieldOptions stores no options as NULL
+func encodeFieldOptions(options []string) (*string, error) {
+	if len(options) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(options)
 	if err != nil {
-		return []FormField{}, err
+		return nil, err
+	}
+	value := string(encoded)
+	return &value, nil
+}
+
+// queryer is what *sql.DB and *sql.Tx have in common, for helpers that run inside and outside transactions
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// execer is a queryer that writes as well
+type execer interface {
+	queryer
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func queryFormFields(ctx context.Context, q queryer, formId int) ([]FormField, error) {
+	query := `SELECT ` + formFieldColumns + ` FROM form_fields WHERE form_id=$1 ORDER BY position,id`
+
+	rows, err := q.QueryContext(ctx, query, formId)
+	if err != nil {
+		return nil, dbError(err)
 	}
 	defer rows.Close()
 	var formFields []FormField
 
 	for rows.Next() {
 		var formField FormField
-		if err = rows.Scan(&formField.Id, &formField.FieldTitle, &formField.Required, &formField.FormId); err != nil {
-			return []FormField{}, err
+		if err = scanFormField(rows, &formField); err != nil {
+			return nil, dbError(err)
 		}
 
 		formFields = append(formFields, formField)
 	}
 
+	return formFields, dbError(rows.Err())
+}
+
+func (s *FormFieldStore) GetFormFieldsByFormId(ctx context.Context, formId int) ([]FormField, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	formFields, err := queryFormFields(ctx, s.db, formId)
+	if err != nil {
+		return []FormField{}, err
+	}
 	return formFields, nil
 }
 
-func (s *FormFieldStore) CreateFormField(fieldTitle string, isRequired bool, formId int) (*FormField, error) {
-	tx, err := s.db.Begin()
+func (s *FormFieldStore) CreateFormField(ctx context.Context, formId int, spec FieldSpec) (*FormField, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("transaction failed to start")
 	}
@@ -50,11 +161,8 @@ func (s *FormFieldStore) CreateFormField(fieldTitle string, isRequired bool, for
 		}
 	}()
 
-	var formField FormField
-	query := `INSERT INTO form_fields(field_title,required,form_id)  
-	VALUES($1,$2,$3) RETURNING id,field_title,required,form_id`
-	row := tx.QueryRow(query, fieldTitle, isRequired, formId)
-	if err := row.Scan(&formField.Id, &formField.FieldTitle, &formField.Required, &formField.FormId); err != nil {
+	formField, err := insertFormField(ctx, tx, formId, spec)
+	if err != nil {
 		return nil, err
 	}
 
@@ -63,57 +171,175 @@ func (s *FormFieldStore) CreateFormField(fieldTitle string, isRequired bool, for
 	}
 	// when a new form_field is added to a form
 	// is_ready is updated to true when the form has more than 0 fields
+	return formField, nil
+}
+
+func insertFormField(ctx context.Context, q queryer, formId int, spec FieldSpec) (*FormField, error) {
+	options, err := encodeFieldOptions(spec.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	var formField FormField
+	query := `INSERT INTO form_fields(field_key,field_title,field_type,required,position,options,form_id)
+	VALUES($1,$2,$3,$4,
+		CASE WHEN $5 > 0 THEN $5 ELSE (SELECT COALESCE(MAX(position), 0) + 1 FROM form_fields WHERE form_id=$7) END,
+		$6,$7) RETURNING ` + formFieldColumns
+	row := q.QueryRowContext(ctx, query, spec.FieldKey, spec.FieldTitle, spec.FieldType, spec.Required, spec.Position, options, formId)
+	if err := scanFormField(row, &formField); err != nil {
+		return nil, dbError(err)
+	}
 	return &formField, nil
 }
 
-func (s *FormFieldStore) DeleteFormFieldById(fieldId int) error {
-	query := `DELETE FROM form_fields WHERE id=$1`
-	result, err := s.db.Exec(query, fieldId)
+// DeleteFormFieldById deletes the field with the answers given to it before the form had versions,
+// answers to a published version are kept since the version still describes the field
+func (s *FormFieldStore) DeleteFormFieldById(ctx context.Context, fieldId int) error {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
+		return fmt.Errorf("transaction failed to start")
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = deleteFormField(ctx, tx, fieldId); err != nil {
 		return err
 	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction")
+	}
+	return nil
+}
+
+// deleteFormField runs the deletes of DeleteFormFieldById, q should be a transaction
+func deleteFormField(ctx context.Context, q execer, fieldId int) error {
+	query := `DELETE FROM response_fields WHERE form_field_id=$1
+	AND form_response_id IN (SELECT id FROM form_responses WHERE form_version IS NULL)`
+	if _, err := q.ExecContext(ctx, query, fieldId); err != nil {
+		return dbError(err)
+	}
+
+	query = `DELETE FROM form_fields WHERE id=$1`
+	result, err := q.ExecContext(ctx, query, fieldId)
+	if err != nil {
+		return dbError(err)
+	}
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return dbError(err)
 	}
 	if rowsAffected < 1 {
-		return fmt.Errorf("field with id %d not deleted", fieldId)
+		return fmt.Errorf("%w: field with id %d not deleted", ErrNotFound, fieldId)
 	}
 	return nil
 }
 
-func (s *FormFieldStore) UpdateFormIsReady(formId int) error {
-	query2 := `UPDATE forms 
-	SET is_ready = (SELECT COUNT(*) > 0 FROM form_fields WHERE form_id=$1)
+// UpdateFormIsReady marks the form ready to take responses once it has a published version or fields
+// that will be published with the first response, editing the fields after publishing doesn't change it
+func (s *FormFieldStore) UpdateFormIsReady(ctx context.Context, formId int) error {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	return updateFormIsReady(ctx, s.db, formId)
+}
+
+func updateFormIsReady(ctx context.Context, q execer, formId int) error {
+	query := `UPDATE forms
+	SET is_ready = (published_version IS NOT NULL OR (SELECT COUNT(*) > 0 FROM form_fields WHERE form_id=$1))
 	WHERE id=$1`
-	_, err := s.db.Exec(query2, formId)
-	if err != nil {
-		return err
+	if _, err := q.ExecContext(ctx, query, formId); err != nil {
+		return dbError(err)
 	}
 	return nil
 }
 
-func (s *FormFieldStore) GetFormFieldById(fieldId int) (*FormField, error) {
+func (s *FormFieldStore) GetFormFieldById(ctx context.Context, fieldId int) (*FormField, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
 	var formField FormField
-	query := `SELECT id,field_title,required,form_id FROM form_fields WHERE id=$1`
-	row := s.db.QueryRow(query, fieldId)
-	if err := row.Scan(&formField.Id, &formField.FieldTitle, &formField.Required, &formField.FormId); err != nil {
-		return nil, err
+	query := `SELECT ` + formFieldColumns + ` FROM form_fields WHERE id=$1`
+	row := s.db.QueryRowContext(ctx, query, fieldId)
+	if err := scanFormField(row, &formField); err != nil {
+		return nil, dbError(err)
 	}
 	return &formField, nil
 }
 
-func (s *FormFieldStore) UpdateFormField(fieldId int, fieldTitle string, isRequired bool) (*FormField, error) {
+// UpdateFormField replaces everything about the field with spec
+func (s *FormFieldStore) UpdateFormField(ctx context.Context, fieldId int, spec FieldSpec) (*FormField, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	return updateFormField(ctx, s.db, fieldId, spec)
+}
+
+func updateFormField(ctx context.Context, q queryer, fieldId int, spec FieldSpec) (*FormField, error) {
+	options, err := encodeFieldOptions(spec.Options)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
         UPDATE form_fields
-        SET field_title = $1, required = $2
-        WHERE id = $3
-        RETURNING id, field_title, required, form_id
-    `
-	row := s.db.QueryRow(query, fieldTitle, isRequired, fieldId)
+        SET field_key = $1, field_title = $2, field_type = $3, required = $4,
+            position = CASE WHEN $5 > 0 THEN $5 ELSE position END, options = $6
+        WHERE id = $7
+        RETURNING ` + formFieldColumns
+	row := q.QueryRowContext(ctx, query, spec.FieldKey, spec.FieldTitle, spec.FieldType, spec.Required, spec.Position, options, fieldId)
 	var field FormField
-	if err := row.Scan(&field.Id, &field.FieldTitle, &field.Required, &field.FormId); err != nil {
-		return nil, err
+	if err := scanFormField(row, &field); err != nil {
+		return nil, dbError(err)
 	}
 	return &field, nil
 }
+
+// ApplyFieldChanges makes every change to the form's fields and updates is_ready in one transaction,
+// when one change fails the form is left as it was
+func (s *FormFieldStore) ApplyFieldChanges(ctx context.Context, formId int, changes FieldChanges) error {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, fieldId := range changes.Delete {
+		if err = deleteFormField(ctx, tx, fieldId); err != nil {
+			return err
+		}
+	}
+	for _, update := range changes.Update {
+		if _, err = updateFormField(ctx, tx, update.FieldId, update.Spec); err != nil {
+			return err
+		}
+	}
+	for _, spec := range changes.Create {
+		if _, err = insertFormField(ctx, tx, formId, spec); err != nil {
+			return err
+		}
+	}
+	if err = updateFormIsReady(ctx, tx, formId); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
@@ -1,14 +1,25 @@
 package storage
 
-import "database/sql"
-
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// FormResponse.FormVersion is the published version the response was submitted against,
+// nil for responses to forms that were never published. UpdatedAt is nil until the respondent edits it
 type FormResponse struct {
-	Id           int    `json:"id"`
-	FormId       int    `json:"form_id"`
-	RespondentId int    `json:"respondent_id"`
-	SubmittedAt  string `json:"submitted_at"`
-	Respondent   *User  `json:"respondent"`
-	Form         *Form  `json:"form"`
+	Id           int     `json:"id"`
+	FormId       int     `json:"form_id"`
+	RespondentId int     `json:"respondent_id"`
+	SubmittedAt  string  `json:"submitted_at"`
+	FormVersion  *int    `json:"form_version"`
+	UpdatedAt    *string `json:"updated_at"`
+	Respondent   *User   `json:"respondent"`
+	Form         *Form   `json:"form"`
 }
 
 type ResponseField struct {
@@ -20,24 +31,27 @@ type ResponseField struct {
 }
 
 type FormResponseStore struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
 }
 
-func (s *FormResponseStore) GetFormResponsesByRespondentId(respondentId int) ([]FormResponse, error) {
+func (s *FormResponseStore) GetFormResponsesByRespondentId(ctx context.Context, respondentId int) ([]FormResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
 
 	var formResponses []FormResponse
 
 	query := `
-SELECT fr.id,fr.form_id,fr.respondent_id,fr.submitted_at,
-f.id,f.form_title,f.form_description,f.is_ready,f.user_id,f.created_at,u.id,
-u.email,u.username,u.password,u.created_at,u.updated_at 
+SELECT fr.id,fr.form_id,fr.respondent_id,fr.submitted_at,fr.form_version,fr.updated_at,
+f.id,f.form_title,f.form_description,f.is_ready,f.user_id,f.created_at,f.workspace_id,f.form_key,f.template_visibility,f.published_version,u.id,
+u.email,u.username,u.password,u.created_at,u.updated_at,u.role,u.disabled_at,u.token_version 
 FROM form_responses AS fr INNER JOIN forms AS f ON fr.form_id=f.id INNER JOIN 
 users AS u ON fr.respondent_id=u.id
-WHERE fr.respondent_id=$1`
+WHERE fr.respondent_id=$1 AND f.deleted_at IS NULL`
 
-	rows, err := s.db.Query(query, respondentId)
+	rows, err := s.db.QueryContext(ctx, query, respondentId)
 	if err != nil {
-		return []FormResponse{}, err
+		return []FormResponse{}, dbError(err)
 	}
 
 	for rows.Next() {
@@ -45,12 +59,12 @@ WHERE fr.respondent_id=$1`
 		var respondent User
 		var form Form
 		if err := rows.Scan(&formResponse.Id, &formResponse.FormId,
-			&formResponse.RespondentId, &formResponse.SubmittedAt,
+			&formResponse.RespondentId, &formResponse.SubmittedAt, &formResponse.FormVersion, &formResponse.UpdatedAt,
 			&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId,
-			&form.CreatedAt, &respondent.Id, &respondent.Email,
+			&form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion, &respondent.Id, &respondent.Email,
 			&respondent.Username, &respondent.Password, &respondent.CreatedAt,
-			&respondent.UpdatedAt); err != nil {
-			return []FormResponse{}, err
+			&respondent.UpdatedAt, &respondent.Role, &respondent.DisabledAt, &respondent.TokenVersion); err != nil {
+			return []FormResponse{}, dbError(err)
 		}
 		formResponse.Respondent = &respondent
 		formResponse.Form = &form
@@ -61,27 +75,47 @@ WHERE fr.respondent_id=$1`
 
 }
 
-func (s *FormResponseStore) CreateFormResponse(formId int, userId int) (*FormResponse, error) {
+// formVersion is the published version of the form the respondent answered, nil for forms that were never published
+func (s *FormResponseStore) CreateFormResponse(ctx context.Context, formId int, userId int, formVersion *int) (*FormResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
 
-	var formResponse FormResponse
-	query := `INSERT INTO form_responses(form_id,respondent_id) VALUES($1,$2) RETURNING id,form_id,respondent_id,submitted_at`
-	row := s.db.QueryRow(query, formId, userId)
+	return insertFormResponse(ctx, s.db, formId, userId, formVersion)
+}
 
-	if err := row.Scan(&formResponse.Id, &formResponse.FormId, &formResponse.RespondentId, &formResponse.SubmittedAt); err != nil {
-		return nil, err
+func insertFormResponse(ctx context.Context, q queryer, formId int, userId int, formVersion *int) (*FormResponse, error) {
+	// trashed forms take no responses and the version has to be published, sqlite has no foreign key to check it
+	var formResponse FormResponse
+	query := `INSERT INTO form_responses(form_id,respondent_id,form_version)
+	SELECT CAST($1 AS INTEGER),CAST($2 AS INTEGER),CAST($3 AS INTEGER)
+	WHERE EXISTS (SELECT 1 FROM forms WHERE id=$1 AND deleted_at IS NULL)
+	AND (CAST($3 AS INTEGER) IS NULL OR EXISTS (SELECT 1 FROM form_versions WHERE form_id=$1 AND version=$3))
+	RETURNING id,form_id,respondent_id,submitted_at,form_version,updated_at`
+	row := q.QueryRowContext(ctx, query, formId, userId, formVersion)
+
+	if err := row.Scan(&formResponse.Id, &formResponse.FormId, &formResponse.RespondentId, &formResponse.SubmittedAt, &formResponse.FormVersion, &formResponse.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if formVersion == nil {
+				return nil, fmt.Errorf("%w: form %d doesn't exist", ErrValidation, formId)
+			}
+			return nil, fmt.Errorf("%w: form %d doesn't exist or has no version %d", ErrValidation, formId, *formVersion)
+		}
+		return nil, dbError(err)
 	}
 
 	return &formResponse, nil
 }
 
-func (s *FormResponseStore) CreateResponseFields(formResponseId int, responseFields []struct {
+func (s *FormResponseStore) CreateResponseFields(ctx context.Context, formResponseId int, responseFields []struct {
 	FieldValue  string
 	FormFieldId int
 }) ([]ResponseField, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
 
-	tx, err := s.db.Begin()
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return []ResponseField{}, err
+		return []ResponseField{}, dbError(err)
 	}
 	defer func() {
 		if err != nil {
@@ -89,11 +123,48 @@ func (s *FormResponseStore) CreateResponseFields(formResponseId int, responseFie
 		}
 	}()
 
-	stmt, err := tx.Prepare(`INSERT INTO response_fields(form_response_id,form_field_id,field_value) VALUES($1,$2,$3) RETURNING id,field_value,form_response_id,form_field_id`)
+	result, err := insertResponseFields(ctx, tx, formResponseId, responseFields)
 	if err != nil {
 		return []ResponseField{}, err
 	}
 
+	if err = tx.Commit(); err != nil {
+		return []ResponseField{}, dbError(err)
+	}
+	return result, nil
+}
+
+func insertResponseFields(ctx context.Context, tx *sql.Tx, formResponseId int, responseFields []struct {
+	FieldValue  string
+	FormFieldId int
+}) ([]ResponseField, error) {
+	// answers only keep the id of their field, so check the fields belong to what the respondent answered
+	var formId int
+	var formVersion *int
+	query := `SELECT fr.form_id,fr.form_version FROM form_responses AS fr INNER JOIN forms AS f ON fr.form_id=f.id
+	WHERE fr.id=$1 AND f.deleted_at IS NULL`
+	row := tx.QueryRowContext(ctx, query, formResponseId)
+	if err := row.Scan(&formId, &formVersion); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: form response %d doesn't exist", ErrValidation, formResponseId)
+		}
+		return nil, dbError(err)
+	}
+	fields, err := responseFormFields(ctx, tx, formId, formVersion)
+	if err != nil {
+		return nil, err
+	}
+	for _, respField := range responseFields {
+		if _, ok := fields[respField.FormFieldId]; !ok {
+			return nil, fmt.Errorf("%w: field %d is not a field of the form", ErrValidation, respField.FormFieldId)
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO response_fields(form_response_id,form_field_id,field_value) VALUES($1,$2,$3) RETURNING id,field_value,form_response_id,form_field_id`)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
 	defer stmt.Close()
 
 	var result []ResponseField
@@ -101,36 +172,34 @@ func (s *FormResponseStore) CreateResponseFields(formResponseId int, responseFie
 	for _, respField := range responseFields {
 
 		var responseField ResponseField
-		row := stmt.QueryRow(formResponseId, respField.FormFieldId, respField.FieldValue)
+		row := stmt.QueryRowContext(ctx, formResponseId, respField.FormFieldId, respField.FieldValue)
 		if err := row.Scan(&responseField.Id, &responseField.FieldValue, &responseField.FormResponseId, &responseField.FormFieldId); err != nil {
-			return []ResponseField{}, err
+			return nil, dbError(err)
 		}
 		result = append(result, responseField)
 
 	}
-
-	if err = tx.Commit(); err != nil {
-		return []ResponseField{}, err
-	}
 	return result, nil
 }
 
-func (s *FormResponseStore) GetFormResponsesByFormId(formId int) ([]FormResponse, error) {
+func (s *FormResponseStore) GetFormResponsesByFormId(ctx context.Context, formId int) ([]FormResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
 
 	var formResponses []FormResponse
 
 	query :=
-		`SELECT fr.id,fr.form_id,fr.respondent_id,fr.submitted_at,
-f.id,f.form_title,f.form_description,f.is_ready,f.user_id,f.created_at,u.id,
-u.email,u.username,u.password,u.created_at,u.updated_at 
+		`SELECT fr.id,fr.form_id,fr.respondent_id,fr.submitted_at,fr.form_version,fr.updated_at,
+f.id,f.form_title,f.form_description,f.is_ready,f.user_id,f.created_at,f.workspace_id,f.form_key,f.template_visibility,f.published_version,u.id,
+u.email,u.username,u.password,u.created_at,u.updated_at,u.role,u.disabled_at,u.token_version 
 FROM form_responses AS fr INNER JOIN forms AS f ON fr.form_id=f.id INNER JOIN 
 users AS u ON fr.respondent_id=u.id
-WHERE fr.form_id=$1`
+WHERE fr.form_id=$1 AND f.deleted_at IS NULL`
 
-	rows, err := s.db.Query(query, formId)
+	rows, err := s.db.QueryContext(ctx, query, formId)
 
 	if err != nil {
-		return []FormResponse{}, err
+		return []FormResponse{}, dbError(err)
 	}
 
 	defer rows.Close()
@@ -141,12 +210,12 @@ WHERE fr.form_id=$1`
 		var form Form
 
 		if err = rows.Scan(&formResponse.Id, &formResponse.FormId,
-			&formResponse.RespondentId, &formResponse.SubmittedAt,
+			&formResponse.RespondentId, &formResponse.SubmittedAt, &formResponse.FormVersion, &formResponse.UpdatedAt,
 			&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId,
-			&form.CreatedAt, &respondent.Id, &respondent.Email,
+			&form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion, &respondent.Id, &respondent.Email,
 			&respondent.Username, &respondent.Password, &respondent.CreatedAt,
-			&respondent.UpdatedAt); err != nil {
-			return []FormResponse{}, err
+			&respondent.UpdatedAt, &respondent.Role, &respondent.DisabledAt, &respondent.TokenVersion); err != nil {
+			return []FormResponse{}, dbError(err)
 		}
 
 		formResponse.Respondent = &respondent
@@ -157,48 +226,97 @@ WHERE fr.form_id=$1`
 	return formResponses, nil
 }
 
-func (s *FormResponseStore) GetFormResponseById(formResponseId int) (*FormResponse, error) {
+func (s *FormResponseStore) GetFormResponseById(ctx context.Context, formResponseId int) (*FormResponse, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
 	var formResponse FormResponse
-	query := `SELECT id,form_id,respondent_id,submitted_at FROM form_responses WHERE id=$1`
+	query := `SELECT fr.id,fr.form_id,fr.respondent_id,fr.submitted_at,fr.form_version,fr.updated_at
+	FROM form_responses AS fr INNER JOIN forms AS f ON fr.form_id=f.id WHERE fr.id=$1 AND f.deleted_at IS NULL`
 
-	row := s.db.QueryRow(query, formResponseId)
-	if err := row.Scan(&formResponse.Id, &formResponse.FormId, &formResponse.RespondentId, &formResponse.SubmittedAt); err != nil {
-		return nil, err
+	row := s.db.QueryRowContext(ctx, query, formResponseId)
+	if err := row.Scan(&formResponse.Id, &formResponse.FormId, &formResponse.RespondentId, &formResponse.SubmittedAt, &formResponse.FormVersion, &formResponse.UpdatedAt); err != nil {
+		return nil, dbError(err)
 	}
 
 	return &formResponse, nil
 }
 
-func (s *FormResponseStore) GetResponseFieldsByFormResponseId(formResponseId int) ([]ResponseField, error) {
+// responseFormFields returns the fields a response to the form answers by id, the fields of
+// the version it was submitted against or the form's current fields for responses without one
+func responseFormFields(ctx context.Context, q queryer, formId int, formVersion *int) (map[int]FormField, error) {
+	var fields []FormField
+	var err error
+	if formVersion != nil {
+		fields, err = queryVersionFields(ctx, q, formId, *formVersion)
+	} else {
+		fields, err = queryFormFields(ctx, q, formId)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	var responseFields []ResponseField
+	byId := make(map[int]FormField, len(fields))
+	for _, field := range fields {
+		byId[field.Id] = field
+	}
+	return byId, nil
+}
 
-	query := `
-	SELECT 
-		rf.id,rf.field_value,rf.form_response_id,
-		rf.form_field_id,ff.id,ff.field_title,ff.required,ff.form_id 
-	FROM 
-		response_fields  AS rf INNER JOIN form_fields AS ff 
-	ON rf.form_field_id=ff.id
-	WHERE rf.form_response_id=$1`
-
-	rows, err := s.db.Query(query, formResponseId)
+// GetResponseFieldsByFormResponseId returns the answers with the fields as the respondent saw them,
+// fields of a published version come from the version even when they were edited or deleted since
+func (s *FormResponseStore) GetResponseFieldsByFormResponseId(ctx context.Context, formResponseId int) ([]ResponseField, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var formId int
+	var formVersion *int
+	query := `SELECT fr.form_id,fr.form_version FROM form_responses AS fr INNER JOIN forms AS f ON fr.form_id=f.id
+	WHERE fr.id=$1 AND f.deleted_at IS NULL`
+	row := s.db.QueryRowContext(ctx, query, formResponseId)
+	if err := row.Scan(&formId, &formVersion); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return []ResponseField{}, nil
+		}
+		return []ResponseField{}, dbError(err)
+	}
+	fields, err := responseFormFields(ctx, s.db, formId, formVersion)
 	if err != nil {
 		return []ResponseField{}, err
 	}
 
+	query = `SELECT id,field_value,form_response_id,form_field_id FROM response_fields WHERE form_response_id=$1`
+	rows, err := s.db.QueryContext(ctx, query, formResponseId)
+	if err != nil {
+		return []ResponseField{}, dbError(err)
+	}
 	defer rows.Close()
 
+	var responseFields []ResponseField
 	for rows.Next() {
 		var responseField ResponseField
-		var formField FormField
-		if err = rows.Scan(&responseField.Id, &responseField.FieldValue, &responseField.FormResponseId,
-			&responseField.FormFieldId, &formField.Id, &formField.FieldTitle, &formField.Required, &formField.FormId); err != nil {
-			return []ResponseField{}, err
+		if err = rows.Scan(&responseField.Id, &responseField.FieldValue, &responseField.FormResponseId, &responseField.FormFieldId); err != nil {
+			return []ResponseField{}, dbError(err)
+		}
+		formField, ok := fields[responseField.FormFieldId]
+		if !ok {
+			formField = FormField{Id: responseField.FormFieldId, FormId: formId}
 		}
 		responseField.FormField = formField
 		responseFields = append(responseFields, responseField)
 	}
+	if err = rows.Err(); err != nil {
+		return []ResponseField{}, dbError(err)
+	}
 
+	sortResponseFields(responseFields)
 	return responseFields, nil
 }
+
+// sortResponseFields puts the answers in the order of their fields
+func sortResponseFields(responseFields []ResponseField) {
+	sort.SliceStable(responseFields, func(i, j int) bool {
+		a, b := responseFields[i].FormField, responseFields[j].FormField
+		return a.Position < b.Position || (a.Position == b.Position && a.Id < b.Id)
+	})
+}
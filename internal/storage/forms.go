@@ -1,28 +1,50 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 )
 
+const (
+	TemplatePrivate   = "private"
+	TemplateWorkspace = "workspace"
+	TemplatePublic    = "public"
+)
+
+// Form.TemplateVisibility is nil for regular forms. templates are forms others can create forms from:
+// private ones only by their owner, workspace ones by the members of the form's workspace, public ones by everyone.
+// Form.PublishedVersion is the latest FormVersion respondents answer, nil until the form is first published.
+// Form.DeletedAt is set for forms in the trash, only the trash queries return those.
 type Form struct {
-	Id              int         `json:"id"`
-	FormTitle       string      `json:"form_title"`
-	FormDescription string      `json:"form_description"`
-	IsReady         bool        `json:"is_ready"`
-	UserId          int         `json:"user_id"`
-	CreatedAt       string      `json:"created_at"`
-	User            *User       `json:"user"`
-	FormFields      []FormField `json:"form_fields"`
+	Id                 int         `json:"id"`
+	FormTitle          string      `json:"form_title"`
+	FormDescription    string      `json:"form_description"`
+	IsReady            bool        `json:"is_ready"`
+	UserId             int         `json:"user_id"`
+	CreatedAt          string      `json:"created_at"`
+	WorkspaceId        *int        `json:"workspace_id"`
+	FormKey            *string     `json:"form_key"`
+	TemplateVisibility *string     `json:"template_visibility"`
+	PublishedVersion   *int        `json:"published_version"`
+	DeletedAt          *string     `json:"deleted_at"`
+	User               *User       `json:"user"`
+	FormFields         []FormField `json:"form_fields"`
 }
 
 type FormStore struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
 }
 
-func (fs *FormStore) CreateForm(formTitle string, formDescription string, userId int) (*Form, error) {
+// workspaceId is nil for personal forms, formKey is nil for forms that aren't managed through a definition
+func (fs *FormStore) CreateForm(ctx context.Context, formTitle string, formDescription string, formKey *string, userId int, workspaceId *int) (*Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
 	// Start a transaction
-	tx, err := fs.db.Begin()
+	tx, err := fs.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start transaction: %v", err)
 	}
@@ -35,16 +57,16 @@ func (fs *FormStore) CreateForm(formTitle string, formDescription string, userId
 	}()
 
 	// Query to insert a new form into the database
-	query := `INSERT INTO forms (form_title, form_description,user_id) 
-	          VALUES ($1, $2, $3) RETURNING id, form_title, form_description, is_ready, user_id, created_at`
+	query := `INSERT INTO forms (form_title, form_description,user_id,workspace_id,form_key) 
+	          VALUES ($1, $2, $3, $4, $5) RETURNING id, form_title, form_description, is_ready, user_id, created_at, workspace_id, form_key, template_visibility, published_version`
 
 	// Create a Form instance to store the result
 	var form Form
 
 	// Execute the query
-	row := tx.QueryRow(query, formTitle, formDescription, userId)
-	if err := row.Scan(&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt); err != nil {
-		return nil, fmt.Errorf("failed to insert form: %v", err)
+	row := tx.QueryRowContext(ctx, query, formTitle, formDescription, userId, workspaceId, formKey)
+	if err := row.Scan(&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion); err != nil {
+		return nil, fmt.Errorf("failed to insert form: %w", dbError(err))
 	}
 
 	// Commit the transaction
@@ -55,16 +77,23 @@ func (fs *FormStore) CreateForm(formTitle string, formDescription string, userId
 	return &form, nil
 }
 
-func (fs *FormStore) GetAllForms() ([]Form, error) {
+// GetAllForms lists every personal form plus the forms of the workspaces userId is a member of,
+// other teams' workspace forms stay hidden
+func (fs *FormStore) GetAllForms(ctx context.Context, userId int) ([]Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
 	query := `		SELECT 
-			f.id, f.form_title, f.form_description, f.is_ready, f.user_id, f.created_at,
-			u.id, u.email, u.username, u.password, u.created_at, u.updated_at
+			f.id, f.form_title, f.form_description, f.is_ready, f.user_id, f.created_at, f.workspace_id, f.form_key, f.template_visibility, f.published_version,
+			u.id, u.email, u.username, u.password, u.created_at, u.updated_at, u.role, u.disabled_at, u.token_version
 		FROM forms AS f 
-		INNER JOIN users AS u ON f.user_id = u.id`
+		INNER JOIN users AS u ON f.user_id = u.id
+		WHERE f.deleted_at IS NULL AND (f.workspace_id IS NULL 
+		OR f.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id = $1))`
 
-	rows, err := fs.db.Query(query)
+	rows, err := fs.db.QueryContext(ctx, query, userId)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 	defer rows.Close()
 
@@ -76,10 +105,10 @@ func (fs *FormStore) GetAllForms() ([]Form, error) {
 
 		// Scan both form and user details into their respective structs
 		if err := rows.Scan(
-			&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt,
-			&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt,
+			&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion,
+			&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion,
 		); err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 
 		form.User = &user // Link the user to the form
@@ -89,18 +118,21 @@ func (fs *FormStore) GetAllForms() ([]Form, error) {
 	return forms, nil
 }
 
-func (fs *FormStore) GetFormsByUserId(userId int) ([]Form, error) {
+func (fs *FormStore) GetFormsByUserId(ctx context.Context, userId int) ([]Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
 	query := `
 		SELECT 
-			f.id, f.form_title, f.form_description, f.is_ready, f.user_id, f.created_at,
-			u.id, u.email, u.username, u.password, u.created_at, u.updated_at
+			f.id, f.form_title, f.form_description, f.is_ready, f.user_id, f.created_at, f.workspace_id, f.form_key, f.template_visibility, f.published_version,
+			u.id, u.email, u.username, u.password, u.created_at, u.updated_at, u.role, u.disabled_at, u.token_version
 		FROM forms AS f 
 		INNER JOIN users AS u ON f.user_id = u.id 
-		WHERE f.user_id = $1`
+		WHERE f.user_id = $1 AND f.deleted_at IS NULL`
 
-	rows, err := fs.db.Query(query, userId)
+	rows, err := fs.db.QueryContext(ctx, query, userId)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 	defer rows.Close()
 
@@ -112,10 +144,10 @@ func (fs *FormStore) GetFormsByUserId(userId int) ([]Form, error) {
 
 		// Scan both form and user details into their respective structs
 		if err := rows.Scan(
-			&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt,
-			&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt,
+			&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion,
+			&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion,
 		); err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 
 		form.User = &user // Link the user to the form
@@ -125,49 +157,92 @@ func (fs *FormStore) GetFormsByUserId(userId int) ([]Form, error) {
 	return forms, nil
 }
 
-func (fs *FormStore) GetFormById(formId int) (*Form, error) {
+func (fs *FormStore) GetFormsByWorkspaceId(ctx context.Context, workspaceId int) ([]Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT 
+			f.id, f.form_title, f.form_description, f.is_ready, f.user_id, f.created_at, f.workspace_id, f.form_key, f.template_visibility, f.published_version,
+			u.id, u.email, u.username, u.password, u.created_at, u.updated_at, u.role, u.disabled_at, u.token_version
+		FROM forms AS f 
+		INNER JOIN users AS u ON f.user_id = u.id 
+		WHERE f.workspace_id = $1 AND f.deleted_at IS NULL`
+
+	rows, err := fs.db.QueryContext(ctx, query, workspaceId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var forms []Form
+
+	for rows.Next() {
+		var form Form
+		var user User
+
+		if err := rows.Scan(
+			&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion,
+			&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion,
+		); err != nil {
+			return nil, dbError(err)
+		}
+
+		form.User = &user
+		forms = append(forms, form)
+	}
+
+	return forms, nil
+}
+
+func (fs *FormStore) GetFormById(ctx context.Context, formId int) (*Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
 	var form Form
 
 	query := `SELECT id,form_title,form_description,
-	is_ready,user_id,created_at FROM forms WHERE id=$1`
+	is_ready,user_id,created_at,workspace_id,form_key,template_visibility,published_version FROM forms WHERE id=$1 AND deleted_at IS NULL`
 
-	row := fs.db.QueryRow(query, formId)
-	if err := row.Scan(&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt); err != nil {
-		return nil, err
+	row := fs.db.QueryRowContext(ctx, query, formId)
+	if err := row.Scan(&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion); err != nil {
+		return nil, dbError(err)
 	}
 
 	return &form, nil
 }
 
-func (fs *FormStore) GetFormByIdWithFieldsAndUser(formId int) (*Form, error) {
+func (fs *FormStore) GetFormByIdWithFieldsAndUser(ctx context.Context, formId int) (*Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
 
-	form, err := fs.GetFormById(formId)
+	form, err := fs.GetFormById(ctx, formId)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 
 	var user User
-	query1 := `SELECT id,email,username,password,created_at,updated_at FROM users WHERE id=$1`
-	row := fs.db.QueryRow(query1, form.UserId)
-	if err = row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt); err != nil {
-		return nil, err
+	query1 := `SELECT id,email,username,password,created_at,updated_at,role,disabled_at,token_version FROM users WHERE id=$1`
+	row := fs.db.QueryRowContext(ctx, query1, form.UserId)
+	if err = row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+		return nil, dbError(err)
 	}
 
 	form.User = &user
 
 	// query fields form form_fields.form_id=formId
-	query2 := `SELECT id,field_title,required,form_id FROM form_fields
-	WHERE form_id=$1`
-	rows, err := fs.db.Query(query2, form.Id)
+	query2 := `SELECT ` + formFieldColumns + ` FROM form_fields
+	WHERE form_id=$1 ORDER BY position,id`
+	rows, err := fs.db.QueryContext(ctx, query2, form.Id)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 	defer rows.Close()
 	var fields []FormField
 	for rows.Next() {
 		var field FormField
-		if err = rows.Scan(&field.Id, &field.FieldTitle, &field.Required, &field.FormId); err != nil {
-			return nil, err
+		if err = scanFormField(rows, &field); err != nil {
+			return nil, dbError(err)
 		}
 		fields = append(fields, field)
 	}
@@ -176,18 +251,274 @@ func (fs *FormStore) GetFormByIdWithFieldsAndUser(formId int) (*Form, error) {
 	return form, nil
 }
 
-func (fs *FormStore) DeleteFormById(formId int) error {
-	query := `DELETE FROM forms WHERE id=$1`
-	result, err := fs.db.Exec(query, formId)
+// DeleteFormById moves the form to the trash, its fields and responses stay until PurgeTrashedForms
+// removes it for good. trashed forms are left out of every other form and response query.
+func (fs *FormStore) DeleteFormById(ctx context.Context, formId int) error {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	query := `UPDATE forms SET deleted_at=$1 WHERE id=$2 AND deleted_at IS NULL`
+	result, err := fs.db.ExecContext(ctx, query, time.Now().UTC(), formId)
 	if err != nil {
-		return err
+		return dbError(err)
 	}
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return dbError(err)
 	}
 	if rowsAffected < 1 {
-		return fmt.Errorf("Form with id %d not deleted", formId)
+		return fmt.Errorf("%w: Form with id %d not deleted", ErrNotFound, formId)
 	}
 	return nil
 }
+
+func (fs *FormStore) UpdateFormOwner(ctx context.Context, formId int, userId int) (*Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	var form Form
+	query := `UPDATE forms SET user_id=$1 WHERE id=$2 AND deleted_at IS NULL
+	RETURNING id,form_title,form_description,is_ready,user_id,created_at,workspace_id,form_key,template_visibility,published_version`
+	row := fs.db.QueryRowContext(ctx, query, userId, formId)
+	if err := row.Scan(&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion); err != nil {
+		return nil, dbError(err)
+	}
+	return &form, nil
+}
+
+// GetFormByKey returns the form a form definition with formKey refers to
+func (fs *FormStore) GetFormByKey(ctx context.Context, formKey string) (*Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	var form Form
+	query := `SELECT id,form_title,form_description,
+	is_ready,user_id,created_at,workspace_id,form_key,template_visibility,published_version FROM forms WHERE form_key=$1 AND deleted_at IS NULL`
+
+	row := fs.db.QueryRowContext(ctx, query, formKey)
+	if err := row.Scan(&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion); err != nil {
+		return nil, dbError(err)
+	}
+	return &form, nil
+}
+
+func (fs *FormStore) UpdateForm(ctx context.Context, formId int, formTitle string, formDescription string) (*Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	var form Form
+	query := `UPDATE forms SET form_title=$1, form_description=$2 WHERE id=$3 AND deleted_at IS NULL
+	RETURNING id,form_title,form_description,is_ready,user_id,created_at,workspace_id,form_key,template_visibility,published_version`
+	row := fs.db.QueryRowContext(ctx, query, formTitle, formDescription, formId)
+	if err := row.Scan(&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion); err != nil {
+		return nil, dbError(err)
+	}
+	return &form, nil
+}
+
+// SetFormTemplate turns the form into a template with the visibility, a nil visibility makes it a regular form again
+func (fs *FormStore) SetFormTemplate(ctx context.Context, formId int, visibility *string) (*Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	var form Form
+	query := `UPDATE forms SET template_visibility=$1 WHERE id=$2 AND deleted_at IS NULL
+	RETURNING id,form_title,form_description,is_ready,user_id,created_at,workspace_id,form_key,template_visibility,published_version`
+	row := fs.db.QueryRowContext(ctx, query, visibility, formId)
+	if err := row.Scan(&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion); err != nil {
+		return nil, dbError(err)
+	}
+	return &form, nil
+}
+
+// GetTemplates lists the templates userId can create forms from, their own private ones,
+// the ones of the workspaces they are a member of and every public one
+func (fs *FormStore) GetTemplates(ctx context.Context, userId int) ([]Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT 
+			f.id, f.form_title, f.form_description, f.is_ready, f.user_id, f.created_at, f.workspace_id, f.form_key, f.template_visibility, f.published_version,
+			u.id, u.email, u.username, u.password, u.created_at, u.updated_at, u.role, u.disabled_at, u.token_version
+		FROM forms AS f 
+		INNER JOIN users AS u ON f.user_id = u.id 
+		WHERE f.deleted_at IS NULL AND (f.template_visibility = 'public'
+		OR (f.template_visibility = 'private' AND f.user_id = $1)
+		OR (f.template_visibility = 'workspace' AND f.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id = $1)))
+		ORDER BY f.id`
+
+	rows, err := fs.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var forms []Form
+
+	for rows.Next() {
+		var form Form
+		var user User
+
+		if err := rows.Scan(
+			&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion,
+			&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion,
+		); err != nil {
+			return nil, dbError(err)
+		}
+
+		form.User = &user
+		forms = append(forms, form)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+	return forms, nil
+}
+
+// DuplicateForm copies the form and its fields into a new form owned by userId in one transaction.
+// the copy is a regular form without a form key, responses are not copied. it starts unpublished with
+// the source's current fields, so it is ready when there are any.
+func (fs *FormStore) DuplicateForm(ctx context.Context, formId int, formTitle string, userId int, workspaceId *int) (*Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	tx, err := fs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %v", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var form Form
+	query := `INSERT INTO forms (form_title, form_description, is_ready, user_id, workspace_id)
+	SELECT CAST($1 AS TEXT), form_description, (SELECT COUNT(*) > 0 FROM form_fields WHERE form_id=$4), CAST($2 AS INTEGER), CAST($3 AS INTEGER) FROM forms WHERE id=$4 AND deleted_at IS NULL
+	RETURNING id, form_title, form_description, is_ready, user_id, created_at, workspace_id, form_key, template_visibility, published_version`
+	row := tx.QueryRowContext(ctx, query, formTitle, userId, workspaceId, formId)
+	if err = row.Scan(&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion); err != nil {
+		return nil, fmt.Errorf("failed to copy form: %w", dbError(err))
+	}
+
+	query = `INSERT INTO form_fields (field_key, field_title, field_type, required, position, options, form_id)
+	SELECT field_key, field_title, field_type, required, position, options, CAST($1 AS INTEGER) FROM form_fields WHERE form_id=$2 ORDER BY position, id`
+	if _, err = tx.ExecContext(ctx, query, form.Id, formId); err != nil {
+		return nil, fmt.Errorf("failed to copy form fields: %w", dbError(err))
+	}
+
+	query = `SELECT ` + formFieldColumns + ` FROM form_fields WHERE form_id=$1 ORDER BY position,id`
+	rows, err := tx.QueryContext(ctx, query, form.Id)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var field FormField
+		if err = scanFormField(rows, &field); err != nil {
+			return nil, dbError(err)
+		}
+		form.FormFields = append(form.FormFields, field)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return &form, nil
+}
+
+// GetTrashedForms lists the forms in the trash userId could restore, the ones they created, the ones
+// shared with them as an owner and the ones of workspaces they own. the most recently deleted come first.
+func (fs *FormStore) GetTrashedForms(ctx context.Context, userId int) ([]Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT 
+			f.id, f.form_title, f.form_description, f.is_ready, f.user_id, f.created_at, f.workspace_id, f.form_key, f.template_visibility, f.published_version, f.deleted_at,
+			u.id, u.email, u.username, u.password, u.created_at, u.updated_at, u.role, u.disabled_at, u.token_version
+		FROM forms AS f 
+		INNER JOIN users AS u ON f.user_id = u.id 
+		WHERE f.deleted_at IS NOT NULL AND (f.user_id = $1
+		OR f.id IN (SELECT form_id FROM form_collaborators WHERE user_id = $1 AND role = 'owner')
+		OR f.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id = $1 AND role = 'owner'))
+		ORDER BY f.deleted_at DESC, f.id`
+
+	rows, err := fs.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var forms []Form
+
+	for rows.Next() {
+		var form Form
+		var user User
+
+		if err := rows.Scan(
+			&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion, &form.DeletedAt,
+			&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion,
+		); err != nil {
+			return nil, dbError(err)
+		}
+
+		form.User = &user
+		forms = append(forms, form)
+	}
+
+	return forms, nil
+}
+
+// GetTrashedFormById returns a form in the trash, forms that aren't in it are not found
+func (fs *FormStore) GetTrashedFormById(ctx context.Context, formId int) (*Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	var form Form
+	query := `SELECT id,form_title,form_description,
+	is_ready,user_id,created_at,workspace_id,form_key,template_visibility,published_version,deleted_at FROM forms WHERE id=$1 AND deleted_at IS NOT NULL`
+
+	row := fs.db.QueryRowContext(ctx, query, formId)
+	if err := row.Scan(&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion, &form.DeletedAt); err != nil {
+		return nil, dbError(err)
+	}
+	return &form, nil
+}
+
+// RestoreForm takes the form out of the trash with its fields and responses
+func (fs *FormStore) RestoreForm(ctx context.Context, formId int) (*Form, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	var form Form
+	query := `UPDATE forms SET deleted_at=NULL WHERE id=$1 AND deleted_at IS NOT NULL
+	RETURNING id,form_title,form_description,is_ready,user_id,created_at,workspace_id,form_key,template_visibility,published_version`
+	row := fs.db.QueryRowContext(ctx, query, formId)
+	if err := row.Scan(&form.Id, &form.FormTitle, &form.FormDescription, &form.IsReady, &form.UserId, &form.CreatedAt, &form.WorkspaceId, &form.FormKey, &form.TemplateVisibility, &form.PublishedVersion); err != nil {
+		return nil, dbError(err)
+	}
+	return &form, nil
+}
+
+// PurgeTrashedForms deletes the forms that went to the trash before deletedBefore for good,
+// their fields, responses and versions cascade. it returns how many forms were purged.
+func (fs *FormStore) PurgeTrashedForms(ctx context.Context, deletedBefore time.Time) (int, error) {
+	ctx, cancel := withTimeout(ctx, fs.queryTimeout)
+	defer cancel()
+
+	result, err := fs.db.ExecContext(ctx, `DELETE FROM forms WHERE deleted_at IS NOT NULL AND deleted_at < $1`, deletedBefore.UTC())
+	if err != nil {
+		return 0, dbError(err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return int(purged), nil
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// FormVersion is a published snapshot of a form, it never changes once published.
+// editing the form's fields afterwards only changes the draft until the next publish.
+type FormVersion struct {
+	Id              int         `json:"id"`
+	FormId          int         `json:"form_id"`
+	Version         int         `json:"version"`
+	FormTitle       string      `json:"form_title"`
+	FormDescription string      `json:"form_description"`
+	FormFields      []FormField `json:"form_fields"`
+	PublishedBy     *int        `json:"published_by"`
+	PublishedAt     string      `json:"published_at"`
+}
+
+type FormVersionStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+const formVersionColumns = `id,form_id,version,form_title,form_description,form_fields,published_by,published_at`
+
+// scanFormVersion scans the formVersionColumns, the fields are kept as a json array
+func scanFormVersion(row rowScanner, version *FormVersion) error {
+	var fields string
+	if err := row.Scan(&version.Id, &version.FormId, &version.Version, &version.FormTitle, &version.FormDescription,
+		&fields, &version.PublishedBy, &version.PublishedAt); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(fields), &version.FormFields); err != nil {
+		return fmt.Errorf("decoding form version fields :- %w", err)
+	}
+	return nil
+}
+
+// queryVersionFields returns the fields of a published version of the form
+func queryVersionFields(ctx context.Context, q queryer, formId int, version int) ([]FormField, error) {
+	var formVersion FormVersion
+	query := `SELECT ` + formVersionColumns + ` FROM form_versions WHERE form_id=$1 AND version=$2`
+	if err := scanFormVersion(q.QueryRowContext(ctx, query, formId, version), &formVersion); err != nil {
+		return nil, dbError(err)
+	}
+	return formVersion.FormFields, nil
+}
+
+// PublishFormVersion freezes the form's title, description and fields as its next version,
+// new responses are submitted against it. publishedBy is nil when the server publishes on its own.
+func (s *FormVersionStore) PublishFormVersion(ctx context.Context, formId int, publishedBy *int) (*FormVersion, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %v", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var formTitle, formDescription string
+	row := tx.QueryRowContext(ctx, `SELECT form_title,form_description FROM forms WHERE id=$1`, formId)
+	if err = row.Scan(&formTitle, &formDescription); err != nil {
+		return nil, dbError(err)
+	}
+
+	fields, err := queryFormFields(ctx, tx, formId)
+	if err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		fields = []FormField{}
+	}
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var formVersion FormVersion
+	query := `INSERT INTO form_versions(form_id,version,form_title,form_description,form_fields,published_by)
+	VALUES($1,(SELECT COALESCE(MAX(version), 0) + 1 FROM form_versions WHERE form_id=$1),$2,$3,$4,$5)
+	RETURNING ` + formVersionColumns
+	row = tx.QueryRowContext(ctx, query, formId, formTitle, formDescription, string(encoded), publishedBy)
+	if err = scanFormVersion(row, &formVersion); err != nil {
+		return nil, fmt.Errorf("failed to publish form: %w", dbError(err))
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE forms SET published_version=$1 WHERE id=$2`, formVersion.Version, formId); err != nil {
+		return nil, dbError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return &formVersion, nil
+}
+
+// GetFormVersionsByFormId lists every published version of the form, oldest first
+func (s *FormVersionStore) GetFormVersionsByFormId(ctx context.Context, formId int) ([]FormVersion, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + formVersionColumns + ` FROM form_versions WHERE form_id=$1 ORDER BY version`
+	rows, err := s.db.QueryContext(ctx, query, formId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var formVersions []FormVersion
+	for rows.Next() {
+		var formVersion FormVersion
+		if err := scanFormVersion(rows, &formVersion); err != nil {
+			return nil, dbError(err)
+		}
+		formVersions = append(formVersions, formVersion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+	return formVersions, nil
+}
+
+func (s *FormVersionStore) GetFormVersion(ctx context.Context, formId int, version int) (*FormVersion, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var formVersion FormVersion
+	query := `SELECT ` + formVersionColumns + ` FROM form_versions WHERE form_id=$1 AND version=$2`
+	if err := scanFormVersion(s.db.QueryRowContext(ctx, query, formId, version), &formVersion); err != nil {
+		return nil, dbError(err)
+	}
+	return &formVersion, nil
+}
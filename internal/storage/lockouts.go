@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type LoginLockout struct {
+	Id             int    `json:"id"`
+	UserId         int    `json:"user_id"`
+	IpAddress      string `json:"ip_address"`
+	FailedAttempts int    `json:"failed_attempts"`
+	LockedUntil    string `json:"locked_until"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type LoginLockoutStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func (s *LoginLockoutStore) CreateLoginLockout(ctx context.Context, userId int, ipAddress string, failedAttempts int, lockedUntil time.Time) (*LoginLockout, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var lockout LoginLockout
+	query := `INSERT INTO login_lockouts(user_id,ip_address,failed_attempts,locked_until) VALUES($1,$2,$3,$4)
+	RETURNING id,user_id,ip_address,failed_attempts,locked_until,created_at`
+	row := s.db.QueryRowContext(ctx, query, userId, ipAddress, failedAttempts, lockedUntil)
+	if err := row.Scan(&lockout.Id, &lockout.UserId, &lockout.IpAddress, &lockout.FailedAttempts, &lockout.LockedUntil, &lockout.CreatedAt); err != nil {
+		return nil, dbError(err)
+	}
+	return &lockout, nil
+}
+
+func (s *LoginLockoutStore) GetLoginLockoutsByUserId(ctx context.Context, userId int) ([]LoginLockout, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var lockouts []LoginLockout
+	query := `SELECT id,user_id,ip_address,failed_attempts,locked_until,created_at FROM login_lockouts
+	WHERE user_id=$1 ORDER BY created_at DESC`
+	rows, err := s.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return []LoginLockout{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var lockout LoginLockout
+		if err := rows.Scan(&lockout.Id, &lockout.UserId, &lockout.IpAddress, &lockout.FailedAttempts, &lockout.LockedUntil, &lockout.CreatedAt); err != nil {
+			return []LoginLockout{}, dbError(err)
+		}
+		lockouts = append(lockouts, lockout)
+	}
+
+	return lockouts, nil
+}
@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// the in-memory backend reports constraint violations the way postgres would refuse the write
+var (
+	errMemoryNotFound            = dbError(sql.ErrNoRows)
+	errMemoryUniqueViolation     = fmt.Errorf("%w: duplicate key value violates unique constraint", ErrConflict)
+	errMemoryForeignKeyViolation = fmt.Errorf("%w: insert or update violates foreign key constraint", ErrValidation)
+	errMemoryCheckViolation      = fmt.Errorf("%w: new row violates check constraint", ErrValidation)
+)
+
+// memoryDB holds every table of the in-memory backend behind one lock, the stores
+// are thin views over it so cascades can reach across tables like they do in postgres
+type memoryDB struct {
+	mu sync.RWMutex
+
+	sequences map[string]int
+
+	users             map[int]User
+	forms             map[int]Form
+	formFields        map[int]FormField
+	formResponses     map[int]FormResponse
+	responseFields    map[int]ResponseField
+	responseEdits     map[int]ResponseFieldEdit
+	responseSettings  map[int]ResponseSettings
+	responseDrafts    map[int]ResponseDraft
+	formVersions      map[int]FormVersion
+	loginLockouts     map[int]LoginLockout
+	formCollaborators map[[2]int]FormCollaborator
+	workspaces        map[int]Workspace
+	workspaceMembers  map[[2]int]WorkspaceMember
+}
+
+// NewMemoryStorage builds a storage that keeps everything in process memory, with the same
+// unique constraints, cascades and sql.ErrNoRows semantics as the postgres one.
+// it is meant for tests and local development, nothing survives a restart.
+func NewMemoryStorage() *Storage {
+	db := &memoryDB{
+		sequences:         make(map[string]int),
+		users:             make(map[int]User),
+		forms:             make(map[int]Form),
+		formFields:        make(map[int]FormField),
+		formResponses:     make(map[int]FormResponse),
+		responseFields:    make(map[int]ResponseField),
+		responseEdits:     make(map[int]ResponseFieldEdit),
+		responseSettings:  make(map[int]ResponseSettings),
+		responseDrafts:    make(map[int]ResponseDraft),
+		formVersions:      make(map[int]FormVersion),
+		loginLockouts:     make(map[int]LoginLockout),
+		formCollaborators: make(map[[2]int]FormCollaborator),
+		workspaces:        make(map[int]Workspace),
+		workspaceMembers:  make(map[[2]int]WorkspaceMember),
+	}
+	return &Storage{
+		Users:             &memoryUserStore{db: db},
+		Forms:             &memoryFormStore{db: db},
+		FormFields:        &memoryFormFieldStore{db: db},
+		FormResponse:      &memoryFormResponseStore{db: db},
+		FormVersions:      &memoryFormVersionStore{db: db},
+		ResponseDrafts:    &memoryResponseDraftStore{db: db},
+		LoginLockouts:     &memoryLoginLockoutStore{db: db},
+		FormCollaborators: &memoryFormCollaboratorStore{db: db},
+		Workspaces:        &memoryWorkspaceStore{db: db},
+		Stats:             &memoryStatsStore{db: db},
+	}
+}
+
+func (db *memoryDB) nextId(table string) int {
+	db.sequences[table]++
+	return db.sequences[table]
+}
+
+func memoryNow() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+func sortedIds[V any](m map[int]V) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// the cascade helpers expect db.mu to be held for writing
+
+func (db *memoryDB) deleteUserCascade(userId int) {
+	for _, id := range sortedIds(db.forms) {
+		if db.forms[id].UserId == userId {
+			db.deleteFormCascade(id)
+		}
+	}
+	for _, id := range sortedIds(db.formResponses) {
+		if db.formResponses[id].RespondentId == userId {
+			db.deleteFormResponseCascade(id)
+		}
+	}
+	for id, draft := range db.responseDrafts {
+		if draft.RespondentId == userId {
+			delete(db.responseDrafts, id)
+		}
+	}
+	for id, lockout := range db.loginLockouts {
+		if lockout.UserId == userId {
+			delete(db.loginLockouts, id)
+		}
+	}
+	for key := range db.formCollaborators {
+		if key[1] == userId {
+			delete(db.formCollaborators, key)
+		}
+	}
+	for key := range db.workspaceMembers {
+		if key[1] == userId {
+			delete(db.workspaceMembers, key)
+		}
+	}
+	for id, workspace := range db.workspaces {
+		if workspace.CreatedBy != nil && *workspace.CreatedBy == userId {
+			workspace.CreatedBy = nil
+			db.workspaces[id] = workspace
+		}
+	}
+	for id, formVersion := range db.formVersions {
+		if formVersion.PublishedBy != nil && *formVersion.PublishedBy == userId {
+			formVersion.PublishedBy = nil
+			db.formVersions[id] = formVersion
+		}
+	}
+	delete(db.users, userId)
+}
+
+func (db *memoryDB) deleteFormCascade(formId int) {
+	for _, id := range sortedIds(db.formFields) {
+		if db.formFields[id].FormId == formId {
+			db.deleteFormFieldCascade(id)
+		}
+	}
+	for _, id := range sortedIds(db.formResponses) {
+		if db.formResponses[id].FormId == formId {
+			db.deleteFormResponseCascade(id)
+		}
+	}
+	for key := range db.formCollaborators {
+		if key[0] == formId {
+			delete(db.formCollaborators, key)
+		}
+	}
+	for id, formVersion := range db.formVersions {
+		if formVersion.FormId == formId {
+			delete(db.formVersions, id)
+		}
+	}
+	delete(db.responseSettings, formId)
+	for id, draft := range db.responseDrafts {
+		if draft.FormId == formId {
+			delete(db.responseDrafts, id)
+		}
+	}
+	delete(db.forms, formId)
+}
+
+// deleteFormFieldCascade keeps the answers to published versions like DeleteFormFieldById does
+func (db *memoryDB) deleteFormFieldCascade(fieldId int) {
+	for id, field := range db.responseFields {
+		if field.FormFieldId == fieldId && db.formResponses[field.FormResponseId].FormVersion == nil {
+			delete(db.responseFields, id)
+		}
+	}
+	delete(db.formFields, fieldId)
+}
+
+func (db *memoryDB) deleteFormResponseCascade(formResponseId int) {
+	for id, field := range db.responseFields {
+		if field.FormResponseId == formResponseId {
+			delete(db.responseFields, id)
+		}
+	}
+	for id, edit := range db.responseEdits {
+		if edit.FormResponseId == formResponseId {
+			delete(db.responseEdits, id)
+		}
+	}
+	delete(db.formResponses, formResponseId)
+}
+
+// handOverWorkspaceForms mirrors handOverWorkspaceFormsQuery
+func (db *memoryDB) handOverWorkspaceForms(userId int) {
+	rank := map[string]int{WorkspaceRoleOwner: 0, WorkspaceRoleAdmin: 1, WorkspaceRoleMember: 2}
+	for id, form := range db.forms {
+		if form.UserId != userId || form.WorkspaceId == nil {
+			continue
+		}
+		var successor *WorkspaceMember
+		for key, member := range db.workspaceMembers {
+			if key[0] != *form.WorkspaceId || key[1] == userId {
+				continue
+			}
+			if successor == nil || rank[member.Role] < rank[successor.Role] ||
+				(rank[member.Role] == rank[successor.Role] && member.CreatedAt < successor.CreatedAt) {
+				m := member
+				successor = &m
+			}
+		}
+		if successor != nil {
+			form.UserId = successor.UserId
+			db.forms[id] = form
+		}
+	}
+}
+
+// liveForm returns the form unless it is in the trash, like the form queries that skip deleted_at rows
+func (db *memoryDB) liveForm(formId int) (Form, bool) {
+	form, ok := db.forms[formId]
+	if !ok || form.DeletedAt != nil {
+		return Form{}, false
+	}
+	return form, true
+}
+
+// fieldsOfForm lists the fields of a form by position like the form_fields queries do
+func (db *memoryDB) fieldsOfForm(formId int) []FormField {
+	var fields []FormField
+	for _, id := range sortedIds(db.formFields) {
+		if field := db.formFields[id]; field.FormId == formId {
+			fields = append(fields, field)
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Position < fields[j].Position
+	})
+	return fields
+}
+
+func (db *memoryDB) formWithUser(form Form) Form {
+	if user, ok := db.users[form.UserId]; ok {
+		form.User = &user
+	}
+	return form
+}
+
+type memoryStatsStore struct {
+	db *memoryDB
+}
+
+func (s *memoryStatsStore) GetSystemStats(ctx context.Context) (*SystemStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	dayAgo := time.Now().Add(-24 * time.Hour)
+	within24h := func(timestamp string) bool {
+		t, err := time.Parse(time.RFC3339Nano, timestamp)
+		return err == nil && t.After(dayAgo)
+	}
+
+	var stats SystemStats
+	for _, user := range s.db.users {
+		stats.Users++
+		if user.Role == RoleAdmin {
+			stats.Admins++
+		}
+		if user.DisabledAt != nil {
+			stats.DisabledUsers++
+		}
+	}
+	for _, form := range s.db.forms {
+		if form.DeletedAt != nil {
+			continue
+		}
+		stats.Forms++
+		if form.IsReady {
+			stats.ReadyForms++
+		}
+	}
+	for _, formResponse := range s.db.formResponses {
+		stats.FormResponses++
+		if within24h(formResponse.SubmittedAt) {
+			stats.FormResponses24h++
+		}
+	}
+	for _, lockout := range s.db.loginLockouts {
+		if within24h(lockout.CreatedAt) {
+			stats.LoginLockouts24h++
+		}
+	}
+	return &stats, nil
+}
+
+// formVersion finds a published version of the form, db.mu has to be held
+func (db *memoryDB) formVersion(formId int, version int) (FormVersion, bool) {
+	for _, formVersion := range db.formVersions {
+		if formVersion.FormId == formId && formVersion.Version == version {
+			return formVersion, true
+		}
+	}
+	return FormVersion{}, false
+}
+
+// responseFormFields mirrors responseFormFields of the sql stores, db.mu has to be held
+func (db *memoryDB) responseFormFields(formResponse FormResponse) map[int]FormField {
+	var fields []FormField
+	if formResponse.FormVersion != nil {
+		formVersion, _ := db.formVersion(formResponse.FormId, *formResponse.FormVersion)
+		fields = formVersion.FormFields
+	} else {
+		fields = db.fieldsOfForm(formResponse.FormId)
+	}
+	byId := make(map[int]FormField, len(fields))
+	for _, field := range fields {
+		byId[field.Id] = field
+	}
+	return byId
+}
@@ -0,0 +1,816 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+)
+
+type memoryFormStore struct {
+	db *memoryDB
+}
+
+func (s *memoryFormStore) CreateForm(ctx context.Context, formTitle string, formDescription string, formKey *string, userId int, workspaceId *int) (*Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userId]; !ok {
+		return nil, fmt.Errorf("failed to insert form: %w", errMemoryForeignKeyViolation)
+	}
+	if workspaceId != nil {
+		if _, ok := s.db.workspaces[*workspaceId]; !ok {
+			return nil, fmt.Errorf("failed to insert form: %w", errMemoryForeignKeyViolation)
+		}
+		id := *workspaceId
+		workspaceId = &id
+	}
+	if formKey != nil {
+		for _, form := range s.db.forms {
+			if form.FormKey != nil && *form.FormKey == *formKey {
+				return nil, fmt.Errorf("failed to insert form: %w", errMemoryUniqueViolation)
+			}
+		}
+		key := *formKey
+		formKey = &key
+	}
+
+	form := Form{
+		Id:              s.db.nextId("forms"),
+		FormTitle:       formTitle,
+		FormDescription: formDescription,
+		UserId:          userId,
+		CreatedAt:       memoryNow(),
+		WorkspaceId:     workspaceId,
+		FormKey:         formKey,
+	}
+	s.db.forms[form.Id] = form
+	return &form, nil
+}
+
+func (s *memoryFormStore) GetAllForms(ctx context.Context, userId int) ([]Form, error) {
+	return s.listForms(ctx, func(form Form) bool {
+		if form.WorkspaceId == nil {
+			return true
+		}
+		_, isMember := s.db.workspaceMembers[[2]int{*form.WorkspaceId, userId}]
+		return isMember
+	})
+}
+
+func (s *memoryFormStore) GetFormsByUserId(ctx context.Context, userId int) ([]Form, error) {
+	return s.listForms(ctx, func(form Form) bool { return form.UserId == userId })
+}
+
+func (s *memoryFormStore) GetFormsByWorkspaceId(ctx context.Context, workspaceId int) ([]Form, error) {
+	return s.listForms(ctx, func(form Form) bool { return form.WorkspaceId != nil && *form.WorkspaceId == workspaceId })
+}
+
+func (s *memoryFormStore) listForms(ctx context.Context, match func(Form) bool) ([]Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var forms []Form
+	for _, id := range sortedIds(s.db.forms) {
+		if form := s.db.forms[id]; form.DeletedAt == nil && match(form) {
+			forms = append(forms, s.db.formWithUser(form))
+		}
+	}
+	return forms, nil
+}
+
+func (s *memoryFormStore) GetFormById(ctx context.Context, formId int) (*Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	form, ok := s.db.liveForm(formId)
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	return &form, nil
+}
+
+func (s *memoryFormStore) GetFormByIdWithFieldsAndUser(ctx context.Context, formId int) (*Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	form, ok := s.db.liveForm(formId)
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	form = s.db.formWithUser(form)
+	form.FormFields = s.db.fieldsOfForm(formId)
+	return &form, nil
+}
+
+func (s *memoryFormStore) GetFormByKey(ctx context.Context, formKey string) (*Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	for _, form := range s.db.forms {
+		if form.DeletedAt == nil && form.FormKey != nil && *form.FormKey == formKey {
+			return &form, nil
+		}
+	}
+	return nil, errMemoryNotFound
+}
+
+func (s *memoryFormStore) UpdateForm(ctx context.Context, formId int, formTitle string, formDescription string) (*Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	form, ok := s.db.liveForm(formId)
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	form.FormTitle = formTitle
+	form.FormDescription = formDescription
+	s.db.forms[formId] = form
+	return &form, nil
+}
+
+func (s *memoryFormStore) DeleteFormById(ctx context.Context, formId int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	form, ok := s.db.liveForm(formId)
+	if !ok {
+		return fmt.Errorf("%w: Form with id %d not deleted", ErrNotFound, formId)
+	}
+	deletedAt := memoryNow()
+	form.DeletedAt = &deletedAt
+	s.db.forms[formId] = form
+	return nil
+}
+
+func (s *memoryFormStore) UpdateFormOwner(ctx context.Context, formId int, userId int) (*Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	form, ok := s.db.liveForm(formId)
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	if _, ok := s.db.users[userId]; !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+	form.UserId = userId
+	s.db.forms[formId] = form
+	return &form, nil
+}
+
+func (s *memoryFormStore) SetFormTemplate(ctx context.Context, formId int, visibility *string) (*Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	form, ok := s.db.liveForm(formId)
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	if visibility != nil {
+		if *visibility != TemplatePrivate && *visibility != TemplateWorkspace && *visibility != TemplatePublic {
+			return nil, errMemoryCheckViolation
+		}
+		v := *visibility
+		visibility = &v
+	}
+	form.TemplateVisibility = visibility
+	s.db.forms[formId] = form
+	return &form, nil
+}
+
+func (s *memoryFormStore) GetTemplates(ctx context.Context, userId int) ([]Form, error) {
+	return s.listForms(ctx, func(form Form) bool {
+		if form.TemplateVisibility == nil {
+			return false
+		}
+		switch *form.TemplateVisibility {
+		case TemplatePublic:
+			return true
+		case TemplatePrivate:
+			return form.UserId == userId
+		case TemplateWorkspace:
+			if form.WorkspaceId == nil {
+				return false
+			}
+			_, isMember := s.db.workspaceMembers[[2]int{*form.WorkspaceId, userId}]
+			return isMember
+		}
+		return false
+	})
+}
+
+func (s *memoryFormStore) DuplicateForm(ctx context.Context, formId int, formTitle string, userId int, workspaceId *int) (*Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	source, ok := s.db.liveForm(formId)
+	if !ok {
+		return nil, fmt.Errorf("failed to copy form: %w", errMemoryNotFound)
+	}
+	if _, ok := s.db.users[userId]; !ok {
+		return nil, fmt.Errorf("failed to copy form: %w", errMemoryForeignKeyViolation)
+	}
+	if workspaceId != nil {
+		if _, ok := s.db.workspaces[*workspaceId]; !ok {
+			return nil, fmt.Errorf("failed to copy form: %w", errMemoryForeignKeyViolation)
+		}
+		id := *workspaceId
+		workspaceId = &id
+	}
+
+	form := Form{
+		Id:              s.db.nextId("forms"),
+		FormTitle:       formTitle,
+		FormDescription: source.FormDescription,
+		IsReady:         len(s.db.fieldsOfForm(formId)) > 0,
+		UserId:          userId,
+		CreatedAt:       memoryNow(),
+		WorkspaceId:     workspaceId,
+	}
+	s.db.forms[form.Id] = form
+
+	for _, field := range s.db.fieldsOfForm(formId) {
+		field.Id = s.db.nextId("form_fields")
+		field.FormId = form.Id
+		if field.FieldKey != nil {
+			key := *field.FieldKey
+			field.FieldKey = &key
+		}
+		field.Options = slices.Clone(field.Options)
+		s.db.formFields[field.Id] = field
+		form.FormFields = append(form.FormFields, field)
+	}
+	return &form, nil
+}
+
+func (s *memoryFormStore) GetTrashedForms(ctx context.Context, userId int) ([]Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var forms []Form
+	for _, form := range s.db.forms {
+		if form.DeletedAt == nil {
+			continue
+		}
+		owner := form.UserId == userId
+		if collaborator, ok := s.db.formCollaborators[[2]int{form.Id, userId}]; ok && collaborator.Role == CollaboratorOwner {
+			owner = true
+		}
+		if form.WorkspaceId != nil {
+			if member, ok := s.db.workspaceMembers[[2]int{*form.WorkspaceId, userId}]; ok && member.Role == WorkspaceRoleOwner {
+				owner = true
+			}
+		}
+		if owner {
+			forms = append(forms, s.db.formWithUser(form))
+		}
+	}
+	sort.Slice(forms, func(i, j int) bool {
+		if *forms[i].DeletedAt != *forms[j].DeletedAt {
+			return *forms[i].DeletedAt > *forms[j].DeletedAt
+		}
+		return forms[i].Id < forms[j].Id
+	})
+	return forms, nil
+}
+
+func (s *memoryFormStore) GetTrashedFormById(ctx context.Context, formId int) (*Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	form, ok := s.db.forms[formId]
+	if !ok || form.DeletedAt == nil {
+		return nil, errMemoryNotFound
+	}
+	return &form, nil
+}
+
+func (s *memoryFormStore) RestoreForm(ctx context.Context, formId int) (*Form, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	form, ok := s.db.forms[formId]
+	if !ok || form.DeletedAt == nil {
+		return nil, errMemoryNotFound
+	}
+	form.DeletedAt = nil
+	s.db.forms[formId] = form
+	return &form, nil
+}
+
+func (s *memoryFormStore) PurgeTrashedForms(ctx context.Context, deletedBefore time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	purged := 0
+	for _, id := range sortedIds(s.db.forms) {
+		form := s.db.forms[id]
+		if form.DeletedAt == nil {
+			continue
+		}
+		deletedAt, err := time.Parse(time.RFC3339Nano, *form.DeletedAt)
+		if err != nil {
+			return purged, err
+		}
+		if deletedAt.Before(deletedBefore) {
+			s.db.deleteFormCascade(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+type memoryFormFieldStore struct {
+	db *memoryDB
+}
+
+func (s *memoryFormFieldStore) GetFormFieldsByFormId(ctx context.Context, formId int) ([]FormField, error) {
+	if err := ctx.Err(); err != nil {
+		return []FormField{}, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.fieldsOfForm(formId), nil
+}
+
+func (s *memoryFormFieldStore) CreateFormField(ctx context.Context, formId int, spec FieldSpec) (*FormField, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.forms[formId]; !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+	field := FormField{Id: s.db.nextId("form_fields"), FormId: formId}
+	if spec.Position <= 0 {
+		for _, existing := range s.db.formFields {
+			if existing.FormId == formId && existing.Position > spec.Position {
+				spec.Position = existing.Position
+			}
+		}
+		spec.Position++
+	}
+	if err := s.db.applyFieldSpec(&field, spec); err != nil {
+		return nil, err
+	}
+	s.db.formFields[field.Id] = field
+	return &field, nil
+}
+
+func (s *memoryFormFieldStore) DeleteFormFieldById(ctx context.Context, fieldId int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.formFields[fieldId]; !ok {
+		return fmt.Errorf("%w: field with id %d not deleted", ErrNotFound, fieldId)
+	}
+	s.db.deleteFormFieldCascade(fieldId)
+	return nil
+}
+
+func (s *memoryFormFieldStore) UpdateFormIsReady(ctx context.Context, formId int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	form, ok := s.db.forms[formId]
+	if !ok {
+		return nil
+	}
+	form.IsReady = form.PublishedVersion != nil
+	for _, field := range s.db.formFields {
+		if field.FormId == formId {
+			form.IsReady = true
+			break
+		}
+	}
+	s.db.forms[formId] = form
+	return nil
+}
+
+func (s *memoryFormFieldStore) GetFormFieldById(ctx context.Context, fieldId int) (*FormField, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	field, ok := s.db.formFields[fieldId]
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	return &field, nil
+}
+
+func (s *memoryFormFieldStore) UpdateFormField(ctx context.Context, fieldId int, spec FieldSpec) (*FormField, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	field, ok := s.db.formFields[fieldId]
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	if spec.Position <= 0 {
+		spec.Position = field.Position
+	}
+	if err := s.db.applyFieldSpec(&field, spec); err != nil {
+		return nil, err
+	}
+	s.db.formFields[fieldId] = field
+	return &field, nil
+}
+
+func (s *memoryFormFieldStore) ApplyFieldChanges(ctx context.Context, formId int, changes FieldChanges) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.forms[formId]; !ok {
+		return errMemoryForeignKeyViolation
+	}
+	// a failing change puts the form's fields back like the rolled back tx would, the answers
+	// to deleted fields are only dropped once every change went through
+	before := maps.Clone(s.db.formFields)
+	if err := s.db.applyFieldChanges(formId, changes); err != nil {
+		s.db.formFields = before
+		return err
+	}
+	for _, fieldId := range changes.Delete {
+		s.db.deleteFormFieldCascade(fieldId)
+	}
+
+	form := s.db.forms[formId]
+	form.IsReady = form.PublishedVersion != nil || len(s.db.fieldsOfForm(formId)) > 0
+	s.db.forms[formId] = form
+	return nil
+}
+
+// applyFieldChanges makes the changes of ApplyFieldChanges to db.formFields alone, db.mu has to be held for writing
+func (db *memoryDB) applyFieldChanges(formId int, changes FieldChanges) error {
+	for _, fieldId := range changes.Delete {
+		if _, ok := db.formFields[fieldId]; !ok {
+			return fmt.Errorf("%w: field with id %d not deleted", ErrNotFound, fieldId)
+		}
+		delete(db.formFields, fieldId)
+	}
+	for _, update := range changes.Update {
+		field, ok := db.formFields[update.FieldId]
+		if !ok {
+			return errMemoryNotFound
+		}
+		spec := update.Spec
+		if spec.Position <= 0 {
+			spec.Position = field.Position
+		}
+		if err := db.applyFieldSpec(&field, spec); err != nil {
+			return err
+		}
+		db.formFields[field.Id] = field
+	}
+	for _, spec := range changes.Create {
+		field := FormField{Id: db.nextId("form_fields"), FormId: formId}
+		if spec.Position <= 0 {
+			for _, existing := range db.formFields {
+				if existing.FormId == formId && existing.Position > spec.Position {
+					spec.Position = existing.Position
+				}
+			}
+			spec.Position++
+		}
+		if err := db.applyFieldSpec(&field, spec); err != nil {
+			return err
+		}
+		db.formFields[field.Id] = field
+	}
+	return nil
+}
+
+// applyFieldSpec copies spec into field with the checks of the form_fields table, db.mu has to be held for writing
+func (db *memoryDB) applyFieldSpec(field *FormField, spec FieldSpec) error {
+	if !slices.Contains(FieldTypes, spec.FieldType) {
+		return errMemoryCheckViolation
+	}
+	if spec.FieldKey != nil {
+		for _, existing := range db.formFields {
+			if existing.Id != field.Id && existing.FormId == field.FormId && existing.FieldKey != nil && *existing.FieldKey == *spec.FieldKey {
+				return errMemoryUniqueViolation
+			}
+		}
+		key := *spec.FieldKey
+		spec.FieldKey = &key
+	}
+
+	field.FieldKey = spec.FieldKey
+	field.FieldTitle = spec.FieldTitle
+	field.FieldType = spec.FieldType
+	field.Required = spec.Required
+	field.Position = spec.Position
+	field.Options = nil
+	if len(spec.Options) > 0 {
+		field.Options = slices.Clone(spec.Options)
+	}
+	return nil
+}
+
+type memoryFormCollaboratorStore struct {
+	db *memoryDB
+}
+
+func (s *memoryFormCollaboratorStore) AddFormCollaborator(ctx context.Context, formId int, userId int, role string) (*FormCollaborator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if role != CollaboratorViewer && role != CollaboratorEditor && role != CollaboratorOwner {
+		return nil, errMemoryCheckViolation
+	}
+	if _, ok := s.db.forms[formId]; !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+	if _, ok := s.db.users[userId]; !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+
+	key := [2]int{formId, userId}
+	collaborator, ok := s.db.formCollaborators[key]
+	if !ok {
+		collaborator = FormCollaborator{FormId: formId, UserId: userId, CreatedAt: memoryNow()}
+	}
+	collaborator.Role = role
+	s.db.formCollaborators[key] = collaborator
+	return &collaborator, nil
+}
+
+func (s *memoryFormCollaboratorStore) GetFormCollaborator(ctx context.Context, formId int, userId int) (*FormCollaborator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	collaborator, ok := s.db.formCollaborators[[2]int{formId, userId}]
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	return &collaborator, nil
+}
+
+func (s *memoryFormCollaboratorStore) GetFormCollaboratorsByFormId(ctx context.Context, formId int) ([]FormCollaborator, error) {
+	if err := ctx.Err(); err != nil {
+		return []FormCollaborator{}, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var collaborators []FormCollaborator
+	for key, collaborator := range s.db.formCollaborators {
+		if key[0] != formId {
+			continue
+		}
+		if user, ok := s.db.users[key[1]]; ok {
+			collaborator.User = &user
+		}
+		collaborators = append(collaborators, collaborator)
+	}
+	sort.Slice(collaborators, func(i, j int) bool {
+		if collaborators[i].CreatedAt != collaborators[j].CreatedAt {
+			return collaborators[i].CreatedAt < collaborators[j].CreatedAt
+		}
+		return collaborators[i].UserId < collaborators[j].UserId
+	})
+	return collaborators, nil
+}
+
+func (s *memoryFormCollaboratorStore) DeleteFormCollaborator(ctx context.Context, formId int, userId int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := [2]int{formId, userId}
+	if _, ok := s.db.formCollaborators[key]; !ok {
+		return fmt.Errorf("%w: collaborator with id %d not removed from form %d", ErrNotFound, userId, formId)
+	}
+	delete(s.db.formCollaborators, key)
+	return nil
+}
+
+type memoryWorkspaceStore struct {
+	db *memoryDB
+}
+
+func (s *memoryWorkspaceStore) CreateWorkspace(ctx context.Context, workspaceName string, userId int) (*Workspace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userId]; !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+	createdBy := userId
+	workspace := Workspace{
+		Id:            s.db.nextId("workspaces"),
+		WorkspaceName: workspaceName,
+		CreatedBy:     &createdBy,
+		CreatedAt:     memoryNow(),
+	}
+	s.db.workspaces[workspace.Id] = workspace
+	s.db.workspaceMembers[[2]int{workspace.Id, userId}] = WorkspaceMember{
+		WorkspaceId: workspace.Id,
+		UserId:      userId,
+		Role:        WorkspaceRoleOwner,
+		CreatedAt:   workspace.CreatedAt,
+	}
+	return &workspace, nil
+}
+
+func (s *memoryWorkspaceStore) GetWorkspaceById(ctx context.Context, workspaceId int) (*Workspace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	workspace, ok := s.db.workspaces[workspaceId]
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	return &workspace, nil
+}
+
+func (s *memoryWorkspaceStore) GetWorkspacesByUserId(ctx context.Context, userId int) ([]WorkspaceMembership, error) {
+	if err := ctx.Err(); err != nil {
+		return []WorkspaceMembership{}, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var workspaces []WorkspaceMembership
+	for _, id := range sortedIds(s.db.workspaces) {
+		if member, ok := s.db.workspaceMembers[[2]int{id, userId}]; ok {
+			workspaces = append(workspaces, WorkspaceMembership{Workspace: s.db.workspaces[id], Role: member.Role})
+		}
+	}
+	return workspaces, nil
+}
+
+func (s *memoryWorkspaceStore) GetWorkspaceMember(ctx context.Context, workspaceId int, userId int) (*WorkspaceMember, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	member, ok := s.db.workspaceMembers[[2]int{workspaceId, userId}]
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	return &member, nil
+}
+
+func (s *memoryWorkspaceStore) GetWorkspaceMembers(ctx context.Context, workspaceId int) ([]WorkspaceMember, error) {
+	if err := ctx.Err(); err != nil {
+		return []WorkspaceMember{}, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var members []WorkspaceMember
+	for key, member := range s.db.workspaceMembers {
+		if key[0] != workspaceId {
+			continue
+		}
+		if user, ok := s.db.users[key[1]]; ok {
+			member.User = &user
+		}
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].CreatedAt != members[j].CreatedAt {
+			return members[i].CreatedAt < members[j].CreatedAt
+		}
+		return members[i].UserId < members[j].UserId
+	})
+	return members, nil
+}
+
+func (s *memoryWorkspaceStore) AddWorkspaceMember(ctx context.Context, workspaceId int, userId int, role string) (*WorkspaceMember, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if role != WorkspaceRoleMember && role != WorkspaceRoleAdmin && role != WorkspaceRoleOwner {
+		return nil, errMemoryCheckViolation
+	}
+	if _, ok := s.db.workspaces[workspaceId]; !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+	if _, ok := s.db.users[userId]; !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+
+	key := [2]int{workspaceId, userId}
+	member, ok := s.db.workspaceMembers[key]
+	if !ok {
+		member = WorkspaceMember{WorkspaceId: workspaceId, UserId: userId, CreatedAt: memoryNow()}
+	}
+	member.Role = role
+	s.db.workspaceMembers[key] = member
+	return &member, nil
+}
+
+func (s *memoryWorkspaceStore) DeleteWorkspaceMember(ctx context.Context, workspaceId int, userId int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := [2]int{workspaceId, userId}
+	if _, ok := s.db.workspaceMembers[key]; !ok {
+		return fmt.Errorf("%w: member with id %d not removed from workspace %d", ErrNotFound, userId, workspaceId)
+	}
+	delete(s.db.workspaceMembers, key)
+	return nil
+}
+
+func (s *memoryWorkspaceStore) CountWorkspaceOwners(ctx context.Context, workspaceId int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	count := 0
+	for key, member := range s.db.workspaceMembers {
+		if key[0] == workspaceId && member.Role == WorkspaceRoleOwner {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,603 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+type memoryFormResponseStore struct {
+	db *memoryDB
+}
+
+func (s *memoryFormResponseStore) CreateFormResponse(ctx context.Context, formId int, userId int, formVersion *int) (*FormResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return s.db.insertFormResponse(formId, userId, formVersion)
+}
+
+// insertFormResponse and insertResponseFields expect db.mu to be held for writing
+
+func (db *memoryDB) insertFormResponse(formId int, userId int, formVersion *int) (*FormResponse, error) {
+	if _, ok := db.liveForm(formId); !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+	if _, ok := db.users[userId]; !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+	if formVersion != nil {
+		if _, ok := db.formVersion(formId, *formVersion); !ok {
+			return nil, errMemoryForeignKeyViolation
+		}
+		version := *formVersion
+		formVersion = &version
+	}
+	formResponse := FormResponse{
+		Id:           db.nextId("form_responses"),
+		FormId:       formId,
+		RespondentId: userId,
+		SubmittedAt:  memoryNow(),
+		FormVersion:  formVersion,
+	}
+	db.formResponses[formResponse.Id] = formResponse
+	return &formResponse, nil
+}
+
+func (s *memoryFormResponseStore) CreateResponseFields(ctx context.Context, formResponseId int, responseFields []struct {
+	FieldValue  string
+	FormFieldId int
+}) ([]ResponseField, error) {
+	if err := ctx.Err(); err != nil {
+		return []ResponseField{}, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	result, err := s.db.insertResponseFields(formResponseId, responseFields)
+	if err != nil {
+		return []ResponseField{}, err
+	}
+	return result, nil
+}
+
+func (db *memoryDB) insertResponseFields(formResponseId int, responseFields []struct {
+	FieldValue  string
+	FormFieldId int
+}) ([]ResponseField, error) {
+	// check every row first so a bad field leaves nothing behind, like the rolled back tx would
+	formResponse, ok := db.formResponses[formResponseId]
+	if _, live := db.liveForm(formResponse.FormId); !ok || !live {
+		return nil, errMemoryForeignKeyViolation
+	}
+	fields := db.responseFormFields(formResponse)
+	for _, respField := range responseFields {
+		if _, ok := fields[respField.FormFieldId]; !ok {
+			return nil, fmt.Errorf("%w: field %d is not a field of the form", ErrValidation, respField.FormFieldId)
+		}
+	}
+
+	var result []ResponseField
+	for _, respField := range responseFields {
+		responseField := ResponseField{
+			Id:             db.nextId("response_fields"),
+			FieldValue:     respField.FieldValue,
+			FormResponseId: formResponseId,
+			FormFieldId:    respField.FormFieldId,
+		}
+		db.responseFields[responseField.Id] = responseField
+		result = append(result, responseField)
+	}
+	return result, nil
+}
+
+func (s *memoryFormResponseStore) GetFormResponsesByFormId(ctx context.Context, formId int) ([]FormResponse, error) {
+	return s.listFormResponses(ctx, func(formResponse FormResponse) bool { return formResponse.FormId == formId })
+}
+
+func (s *memoryFormResponseStore) GetFormResponsesByRespondentId(ctx context.Context, respondentId int) ([]FormResponse, error) {
+	return s.listFormResponses(ctx, func(formResponse FormResponse) bool { return formResponse.RespondentId == respondentId })
+}
+
+func (s *memoryFormResponseStore) listFormResponses(ctx context.Context, match func(FormResponse) bool) ([]FormResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return []FormResponse{}, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var formResponses []FormResponse
+	for _, id := range sortedIds(s.db.formResponses) {
+		formResponse := s.db.formResponses[id]
+		form, live := s.db.liveForm(formResponse.FormId)
+		if !live || !match(formResponse) {
+			continue
+		}
+		respondent := s.db.users[formResponse.RespondentId]
+		formResponse.Respondent = &respondent
+		formResponse.Form = &form
+		formResponses = append(formResponses, formResponse)
+	}
+	return formResponses, nil
+}
+
+func (s *memoryFormResponseStore) GetFormResponseById(ctx context.Context, formResponseId int) (*FormResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	formResponse, ok := s.db.formResponses[formResponseId]
+	if _, live := s.db.liveForm(formResponse.FormId); !ok || !live {
+		return nil, errMemoryNotFound
+	}
+	return &formResponse, nil
+}
+
+func (s *memoryFormResponseStore) GetResponseFieldsByFormResponseId(ctx context.Context, formResponseId int) ([]ResponseField, error) {
+	if err := ctx.Err(); err != nil {
+		return []ResponseField{}, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	formResponse, ok := s.db.formResponses[formResponseId]
+	if _, live := s.db.liveForm(formResponse.FormId); !ok || !live {
+		return []ResponseField{}, nil
+	}
+	return s.db.answersWithFields(formResponse), nil
+}
+
+// answersWithFields returns the answers of a response with the fields they answered, in the order of the fields
+func (db *memoryDB) answersWithFields(formResponse FormResponse) []ResponseField {
+	fields := db.responseFormFields(formResponse)
+
+	var responseFields []ResponseField
+	for _, id := range sortedIds(db.responseFields) {
+		responseField := db.responseFields[id]
+		if responseField.FormResponseId != formResponse.Id {
+			continue
+		}
+		formField, ok := fields[responseField.FormFieldId]
+		if !ok {
+			formField = FormField{Id: responseField.FormFieldId, FormId: formResponse.FormId}
+		}
+		responseField.FormField = formField
+		responseFields = append(responseFields, responseField)
+	}
+	sortResponseFields(responseFields)
+	return responseFields
+}
+
+func (s *memoryFormResponseStore) UpdateResponseFields(ctx context.Context, formResponseId int, responseFields []struct {
+	FieldValue  string
+	FormFieldId int
+}) ([]ResponseField, error) {
+	if err := ctx.Err(); err != nil {
+		return []ResponseField{}, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	formResponse, ok := s.db.formResponses[formResponseId]
+	if _, live := s.db.liveForm(formResponse.FormId); !ok || !live {
+		return []ResponseField{}, errMemoryNotFound
+	}
+	fields := s.db.responseFormFields(formResponse)
+	for _, respField := range responseFields {
+		if _, ok := fields[respField.FormFieldId]; !ok {
+			return []ResponseField{}, fmt.Errorf("%w: field %d is not a field of the form", ErrValidation, respField.FormFieldId)
+		}
+	}
+
+	var existing []ResponseField
+	for _, id := range sortedIds(s.db.responseFields) {
+		if answer := s.db.responseFields[id]; answer.FormResponseId == formResponseId {
+			existing = append(existing, answer)
+		}
+	}
+
+	now := memoryNow()
+	changed := false
+	keep := func(answer ResponseField) {
+		changed = true
+		edit := ResponseFieldEdit{
+			Id:             s.db.nextId("response_field_edits"),
+			FormResponseId: formResponseId,
+			FormFieldId:    answer.FormFieldId,
+			FieldValue:     answer.FieldValue,
+			EditedAt:       now,
+		}
+		s.db.responseEdits[edit.Id] = edit
+	}
+
+	// pairs old and new answers up the way FormResponseStore.UpdateResponseFields does
+	replaced := make(map[int]bool)
+	for _, respField := range responseFields {
+		var old *ResponseField
+		for i := range existing {
+			if !replaced[existing[i].Id] && existing[i].FormFieldId == respField.FormFieldId {
+				old = &existing[i]
+				break
+			}
+		}
+
+		if old == nil {
+			changed = true
+			answer := ResponseField{
+				Id:             s.db.nextId("response_fields"),
+				FieldValue:     respField.FieldValue,
+				FormResponseId: formResponseId,
+				FormFieldId:    respField.FormFieldId,
+			}
+			s.db.responseFields[answer.Id] = answer
+			continue
+		}
+
+		replaced[old.Id] = true
+		if old.FieldValue == respField.FieldValue {
+			continue
+		}
+		keep(*old)
+		old.FieldValue = respField.FieldValue
+		s.db.responseFields[old.Id] = *old
+	}
+
+	for _, old := range existing {
+		if replaced[old.Id] {
+			continue
+		}
+		keep(old)
+		delete(s.db.responseFields, old.Id)
+	}
+
+	if changed {
+		formResponse.UpdatedAt = &now
+		s.db.formResponses[formResponseId] = formResponse
+	}
+	return s.db.answersWithFields(formResponse), nil
+}
+
+func (s *memoryFormResponseStore) DeleteFormResponseById(ctx context.Context, formResponseId int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	formResponse, ok := s.db.formResponses[formResponseId]
+	if _, live := s.db.liveForm(formResponse.FormId); !ok || !live {
+		return fmt.Errorf("%w: form response with id %d not deleted", ErrNotFound, formResponseId)
+	}
+	s.db.deleteFormResponseCascade(formResponseId)
+	return nil
+}
+
+func (s *memoryFormResponseStore) GetResponseFieldEdits(ctx context.Context, formResponseId int) ([]ResponseFieldEdit, error) {
+	if err := ctx.Err(); err != nil {
+		return []ResponseFieldEdit{}, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	formResponse, ok := s.db.formResponses[formResponseId]
+	if _, live := s.db.liveForm(formResponse.FormId); !ok || !live {
+		return []ResponseFieldEdit{}, nil
+	}
+
+	// ids grow with time, so id order is edited_at order
+	var edits []ResponseFieldEdit
+	for _, id := range sortedIds(s.db.responseEdits) {
+		if edit := s.db.responseEdits[id]; edit.FormResponseId == formResponseId {
+			edits = append(edits, edit)
+		}
+	}
+	return edits, nil
+}
+
+func (s *memoryFormResponseStore) GetResponseSettings(ctx context.Context, formId int) (*ResponseSettings, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	if _, live := s.db.liveForm(formId); !live {
+		return nil, errMemoryNotFound
+	}
+	settings, ok := s.db.responseSettings[formId]
+	if !ok {
+		settings = ResponseSettings{FormId: formId}
+	}
+	return &settings, nil
+}
+
+func (s *memoryFormResponseStore) SetResponseSettings(ctx context.Context, formId int, allowEdits bool, editWindow *int, allowWithdrawal bool) (*ResponseSettings, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, live := s.db.liveForm(formId); !live {
+		return nil, errMemoryNotFound
+	}
+	if editWindow != nil {
+		if *editWindow <= 0 {
+			return nil, errMemoryCheckViolation
+		}
+		window := *editWindow
+		editWindow = &window
+	}
+	updatedAt := memoryNow()
+	settings := ResponseSettings{
+		FormId:          formId,
+		AllowEdits:      allowEdits,
+		EditWindow:      editWindow,
+		AllowWithdrawal: allowWithdrawal,
+		UpdatedAt:       &updatedAt,
+	}
+	s.db.responseSettings[formId] = settings
+	return &settings, nil
+}
+
+type memoryFormVersionStore struct {
+	db *memoryDB
+}
+
+func (s *memoryFormVersionStore) PublishFormVersion(ctx context.Context, formId int, publishedBy *int) (*FormVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	form, ok := s.db.forms[formId]
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	if publishedBy != nil {
+		if _, ok := s.db.users[*publishedBy]; !ok {
+			return nil, fmt.Errorf("failed to publish form: %w", errMemoryForeignKeyViolation)
+		}
+		userId := *publishedBy
+		publishedBy = &userId
+	}
+
+	formVersion := FormVersion{
+		Id:              s.db.nextId("form_versions"),
+		FormId:          formId,
+		Version:         1,
+		FormTitle:       form.FormTitle,
+		FormDescription: form.FormDescription,
+		FormFields:      []FormField{},
+		PublishedBy:     publishedBy,
+		PublishedAt:     memoryNow(),
+	}
+	for _, existing := range s.db.formVersions {
+		if existing.FormId == formId && existing.Version >= formVersion.Version {
+			formVersion.Version = existing.Version + 1
+		}
+	}
+	for _, field := range s.db.fieldsOfForm(formId) {
+		if field.FieldKey != nil {
+			key := *field.FieldKey
+			field.FieldKey = &key
+		}
+		field.Options = slices.Clone(field.Options)
+		formVersion.FormFields = append(formVersion.FormFields, field)
+	}
+	s.db.formVersions[formVersion.Id] = formVersion
+
+	version := formVersion.Version
+	form.PublishedVersion = &version
+	s.db.forms[formId] = form
+	return &formVersion, nil
+}
+
+func (s *memoryFormVersionStore) GetFormVersionsByFormId(ctx context.Context, formId int) ([]FormVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var formVersions []FormVersion
+	for _, id := range sortedIds(s.db.formVersions) {
+		if formVersion := s.db.formVersions[id]; formVersion.FormId == formId {
+			formVersions = append(formVersions, formVersion)
+		}
+	}
+	sort.SliceStable(formVersions, func(i, j int) bool { return formVersions[i].Version < formVersions[j].Version })
+	return formVersions, nil
+}
+
+func (s *memoryFormVersionStore) GetFormVersion(ctx context.Context, formId int, version int) (*FormVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	formVersion, ok := s.db.formVersion(formId, version)
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	return &formVersion, nil
+}
+
+type memoryResponseDraftStore struct {
+	db *memoryDB
+}
+
+// draftExpired is true for drafts whose expires_at is not after now
+func draftExpired(draft ResponseDraft, now time.Time) bool {
+	if draft.ExpiresAt == nil {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339Nano, *draft.ExpiresAt)
+	return err != nil || !expiresAt.After(now)
+}
+
+// liveDraft is false for expired drafts and drafts of forms in the trash, like liveDraftWhere
+func (db *memoryDB) liveDraft(draft ResponseDraft, now time.Time) bool {
+	_, live := db.liveForm(draft.FormId)
+	return live && !draftExpired(draft, now)
+}
+
+// draftOf finds the respondent's draft for the form, expired or not
+func (db *memoryDB) draftOf(formId int, respondentId int) (ResponseDraft, bool) {
+	for _, draft := range db.responseDrafts {
+		if draft.FormId == formId && draft.RespondentId == respondentId {
+			return draft, true
+		}
+	}
+	return ResponseDraft{}, false
+}
+
+func (s *memoryResponseDraftStore) SaveResponseDraft(ctx context.Context, formId int, respondentId int, answers []DraftAnswer, expiresAt *time.Time) (*ResponseDraft, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.forms[formId]; !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+	if _, ok := s.db.users[respondentId]; !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+
+	now := time.Now()
+	draft, ok := s.db.draftOf(formId, respondentId)
+	if !ok {
+		draft = ResponseDraft{Id: s.db.nextId("response_drafts"), FormId: formId, RespondentId: respondentId, CreatedAt: memoryNow()}
+	} else if draftExpired(draft, now) {
+		draft.CreatedAt = memoryNow()
+	}
+	draft.ResponseFields = append([]DraftAnswer{}, answers...)
+	draft.UpdatedAt = memoryNow()
+	draft.ExpiresAt = nil
+	if expiresAt != nil {
+		expires := expiresAt.UTC().Format(time.RFC3339Nano)
+		draft.ExpiresAt = &expires
+	}
+	s.db.responseDrafts[draft.Id] = draft
+	return &draft, nil
+}
+
+func (s *memoryResponseDraftStore) GetResponseDraft(ctx context.Context, formId int, respondentId int) (*ResponseDraft, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	draft, ok := s.db.draftOf(formId, respondentId)
+	if !ok || !s.db.liveDraft(draft, time.Now()) {
+		return nil, errMemoryNotFound
+	}
+	return &draft, nil
+}
+
+func (s *memoryResponseDraftStore) GetResponseDraftsByRespondentId(ctx context.Context, respondentId int) ([]ResponseDraft, error) {
+	if err := ctx.Err(); err != nil {
+		return []ResponseDraft{}, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	now := time.Now()
+	var drafts []ResponseDraft
+	for _, id := range sortedIds(s.db.responseDrafts) {
+		draft := s.db.responseDrafts[id]
+		if draft.RespondentId == respondentId && s.db.liveDraft(draft, now) {
+			drafts = append(drafts, draft)
+		}
+	}
+	sort.SliceStable(drafts, func(i, j int) bool {
+		if drafts[i].UpdatedAt != drafts[j].UpdatedAt {
+			return drafts[i].UpdatedAt > drafts[j].UpdatedAt
+		}
+		return drafts[i].Id > drafts[j].Id
+	})
+	return drafts, nil
+}
+
+func (s *memoryResponseDraftStore) DeleteResponseDraft(ctx context.Context, formId int, respondentId int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	draft, ok := s.db.draftOf(formId, respondentId)
+	if !ok || draftExpired(draft, time.Now()) {
+		return fmt.Errorf("%w: no draft of form %d for user %d", ErrNotFound, formId, respondentId)
+	}
+	delete(s.db.responseDrafts, draft.Id)
+	return nil
+}
+
+func (s *memoryResponseDraftStore) SubmitResponseDraft(ctx context.Context, formId int, respondentId int, formVersion *int, responseFields []struct {
+	FieldValue  string
+	FormFieldId int
+}) (*FormResponse, []ResponseField, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	draft, ok := s.db.draftOf(formId, respondentId)
+	if !ok || !s.db.liveDraft(draft, time.Now()) {
+		return nil, nil, fmt.Errorf("%w: no draft of form %d for user %d", ErrNotFound, formId, respondentId)
+	}
+	// check the answers before anything is written, like the rolled back tx would leave the draft
+	if formVersion != nil {
+		if _, ok := s.db.formVersion(formId, *formVersion); !ok {
+			return nil, nil, errMemoryForeignKeyViolation
+		}
+	}
+	fields := s.db.responseFormFields(FormResponse{FormId: formId, FormVersion: formVersion})
+	for _, respField := range responseFields {
+		if _, ok := fields[respField.FormFieldId]; !ok {
+			return nil, nil, fmt.Errorf("%w: field %d is not a field of the form", ErrValidation, respField.FormFieldId)
+		}
+	}
+
+	formResponse, err := s.db.insertFormResponse(formId, respondentId, formVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	created, err := s.db.insertResponseFields(formResponse.Id, responseFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	delete(s.db.responseDrafts, draft.Id)
+	return formResponse, created, nil
+}
+
+func (s *memoryResponseDraftStore) PurgeExpiredResponseDrafts(ctx context.Context, expiredBefore time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	purged := 0
+	for id, draft := range s.db.responseDrafts {
+		if draft.ExpiresAt != nil && draftExpired(draft, expiredBefore) {
+			delete(s.db.responseDrafts, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
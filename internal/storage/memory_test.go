@@ -0,0 +1,14 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/dhruv15803/internal/storage"
+	"github.com/dhruv15803/internal/storage/storagetest"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	if err := storagetest.TestStorage(storage.NewMemoryStorage); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type memoryUserStore struct {
+	db *memoryDB
+}
+
+func (s *memoryUserStore) GetUserById(ctx context.Context, userId int) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	user, ok := s.db.users[userId]
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	return &user, nil
+}
+
+func (s *memoryUserStore) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	return s.findUser(ctx, func(user User) bool { return user.Email == email })
+}
+
+func (s *memoryUserStore) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	return s.findUser(ctx, func(user User) bool { return user.Username == username })
+}
+
+func (s *memoryUserStore) findUser(ctx context.Context, match func(User) bool) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	for _, id := range sortedIds(s.db.users) {
+		if user := s.db.users[id]; match(user) {
+			return &user, nil
+		}
+	}
+	return nil, errMemoryNotFound
+}
+
+func (s *memoryUserStore) GetUsersByUsernameOrEmail(ctx context.Context, username string, email string) ([]User, error) {
+	if err := ctx.Err(); err != nil {
+		return []User{}, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var users []User
+	for _, id := range sortedIds(s.db.users) {
+		if user := s.db.users[id]; user.Email == email || user.Username == username {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// usernameOrEmailTaken enforces the unique constraints on users, db.mu has to be held
+func (s *memoryUserStore) usernameOrEmailTaken(username string, email string, exceptUserId int) bool {
+	for id, user := range s.db.users {
+		if id != exceptUserId && (user.Username == username || user.Email == email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *memoryUserStore) CreateUser(ctx context.Context, username string, email string, hashedPassword string) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if s.usernameOrEmailTaken(username, email, 0) {
+		return nil, errMemoryUniqueViolation
+	}
+
+	now := memoryNow()
+	user := User{
+		Id:        s.db.nextId("users"),
+		Email:     email,
+		Username:  username,
+		Password:  hashedPassword,
+		CreatedAt: now,
+		UpdatedAt: &now,
+		Role:      RoleUser,
+	}
+	s.db.users[user.Id] = user
+	return &user, nil
+}
+
+func (s *memoryUserStore) UpdateUserProfile(ctx context.Context, userId int, username string, email string) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	user, ok := s.db.users[userId]
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	if s.usernameOrEmailTaken(username, email, userId) {
+		return nil, errMemoryUniqueViolation
+	}
+
+	now := memoryNow()
+	user.Username = username
+	user.Email = email
+	user.UpdatedAt = &now
+	s.db.users[userId] = user
+	return &user, nil
+}
+
+func (s *memoryUserStore) UpdateUserPassword(ctx context.Context, userId int, hashedPassword string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	user, ok := s.db.users[userId]
+	if !ok {
+		return errMemoryNotFound
+	}
+	now := memoryNow()
+	user.Password = hashedPassword
+	user.TokenVersion++
+	user.UpdatedAt = &now
+	s.db.users[userId] = user
+	return nil
+}
+
+func (s *memoryUserStore) DeleteUserById(ctx context.Context, userId int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userId]; !ok {
+		return fmt.Errorf("%w: user with id %d not deleted", ErrNotFound, userId)
+	}
+	s.db.handOverWorkspaceForms(userId)
+	s.db.deleteUserCascade(userId)
+	return nil
+}
+
+func (s *memoryUserStore) DeleteUserAndTransferForms(ctx context.Context, userId int, newOwnerId int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userId]; !ok {
+		return fmt.Errorf("%w: user with id %d not deleted", ErrNotFound, userId)
+	}
+	if newOwnerId == userId {
+		return fmt.Errorf("%w: forms can't be transferred to the account being deleted", ErrValidation)
+	}
+	newOwner, ok := s.db.users[newOwnerId]
+	if !ok {
+		return fmt.Errorf("%w: user with id %d not found", ErrNotFound, newOwnerId)
+	}
+	if newOwner.DisabledAt != nil {
+		return fmt.Errorf("%w: user %d is disabled and can't take over forms", ErrValidation, newOwnerId)
+	}
+
+	s.db.handOverWorkspaceForms(userId)
+	for id, form := range s.db.forms {
+		if form.UserId == userId {
+			form.UserId = newOwnerId
+			s.db.forms[id] = form
+		}
+	}
+	s.db.deleteUserCascade(userId)
+	return nil
+}
+
+func (s *memoryUserStore) SearchUsers(ctx context.Context, query string, limit int, offset int) ([]User, error) {
+	if err := ctx.Err(); err != nil {
+		return []User{}, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	query = strings.ToLower(query)
+	var users []User
+	for _, id := range sortedIds(s.db.users) {
+		user := s.db.users[id]
+		if query != "" && !strings.Contains(strings.ToLower(user.Email), query) && !strings.Contains(strings.ToLower(user.Username), query) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if len(users) >= limit {
+			break
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (s *memoryUserStore) SetUserDisabled(ctx context.Context, userId int, disabled bool) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	user, ok := s.db.users[userId]
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	now := memoryNow()
+	if !disabled {
+		user.DisabledAt = nil
+	} else if user.DisabledAt == nil {
+		user.DisabledAt = &now
+	}
+	user.UpdatedAt = &now
+	s.db.users[userId] = user
+	return &user, nil
+}
+
+func (s *memoryUserStore) SetUserRole(ctx context.Context, userId int, role string) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	user, ok := s.db.users[userId]
+	if !ok {
+		return nil, errMemoryNotFound
+	}
+	if role != RoleUser && role != RoleAdmin {
+		return nil, errMemoryCheckViolation
+	}
+	now := memoryNow()
+	user.Role = role
+	user.UpdatedAt = &now
+	s.db.users[userId] = user
+	return &user, nil
+}
+
+type memoryLoginLockoutStore struct {
+	db *memoryDB
+}
+
+func (s *memoryLoginLockoutStore) CreateLoginLockout(ctx context.Context, userId int, ipAddress string, failedAttempts int, lockedUntil time.Time) (*LoginLockout, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userId]; !ok {
+		return nil, errMemoryForeignKeyViolation
+	}
+	lockout := LoginLockout{
+		Id:             s.db.nextId("login_lockouts"),
+		UserId:         userId,
+		IpAddress:      ipAddress,
+		FailedAttempts: failedAttempts,
+		LockedUntil:    lockedUntil.UTC().Format(time.RFC3339Nano),
+		CreatedAt:      memoryNow(),
+	}
+	s.db.loginLockouts[lockout.Id] = lockout
+	return &lockout, nil
+}
+
+func (s *memoryLoginLockoutStore) GetLoginLockoutsByUserId(ctx context.Context, userId int) ([]LoginLockout, error) {
+	if err := ctx.Err(); err != nil {
+		return []LoginLockout{}, err
+	}
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var lockouts []LoginLockout
+	ids := sortedIds(s.db.loginLockouts)
+	sort.Sort(sort.Reverse(sort.IntSlice(ids)))
+	for _, id := range ids {
+		if lockout := s.db.loginLockouts[id]; lockout.UserId == userId {
+			lockouts = append(lockouts, lockout)
+		}
+	}
+	return lockouts, nil
+}
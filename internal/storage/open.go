@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// database/sql driver names returned by Open
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Open connects to the database a DB_CONN string points at, sqlite://path/to/file.db opens a sqlite
+// file and anything else is handed to postgres. driver tells the caller which of the two it got.
+func Open(dbConn string) (db *sql.DB, driver string, err error) {
+	if path, ok := strings.CutPrefix(dbConn, "sqlite://"); ok {
+		db, err = OpenSQLite(path)
+		return db, DriverSQLite, err
+	}
+
+	db, err = sql.Open(DriverPostgres, dbConn)
+	if err != nil {
+		return nil, DriverPostgres, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, DriverPostgres, err
+	}
+	return db, DriverPostgres, nil
+}
@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ResponseSettings say what respondents may do with their response once it is submitted.
+// EditWindow is how many seconds after submitting a response can still be edited, nil leaves edits open for good.
+// UpdatedAt is nil for forms whose owners never changed the settings, they allow neither
+type ResponseSettings struct {
+	FormId          int     `json:"form_id"`
+	AllowEdits      bool    `json:"allow_edits"`
+	EditWindow      *int    `json:"edit_window"`
+	AllowWithdrawal bool    `json:"allow_withdrawal"`
+	UpdatedAt       *string `json:"updated_at"`
+}
+
+// ResponseFieldEdit is an answer as it was before the respondent edited their response
+type ResponseFieldEdit struct {
+	Id             int    `json:"id"`
+	FormResponseId int    `json:"form_response_id"`
+	FormFieldId    int    `json:"form_field_id"`
+	FieldValue     string `json:"field_value"`
+	EditedAt       string `json:"edited_at"`
+}
+
+func (s *FormResponseStore) GetResponseSettings(ctx context.Context, formId int) (*ResponseSettings, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var settings ResponseSettings
+	query := `SELECT f.id,COALESCE(rs.allow_edits,FALSE),rs.edit_window,COALESCE(rs.allow_withdrawal,FALSE),rs.updated_at
+	FROM forms AS f LEFT JOIN form_response_settings AS rs ON rs.form_id=f.id
+	WHERE f.id=$1 AND f.deleted_at IS NULL`
+	row := s.db.QueryRowContext(ctx, query, formId)
+	if err := row.Scan(&settings.FormId, &settings.AllowEdits, &settings.EditWindow, &settings.AllowWithdrawal, &settings.UpdatedAt); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &settings, nil
+}
+
+func (s *FormResponseStore) SetResponseSettings(ctx context.Context, formId int, allowEdits bool, editWindow *int, allowWithdrawal bool) (*ResponseSettings, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var settings ResponseSettings
+	query := `INSERT INTO form_response_settings(form_id,allow_edits,edit_window,allow_withdrawal)
+	SELECT id,CAST($2 AS BOOLEAN),CAST($3 AS INTEGER),CAST($4 AS BOOLEAN) FROM forms WHERE id=$1 AND deleted_at IS NULL
+	ON CONFLICT(form_id) DO UPDATE SET allow_edits=EXCLUDED.allow_edits,edit_window=EXCLUDED.edit_window,
+	allow_withdrawal=EXCLUDED.allow_withdrawal,updated_at=CURRENT_TIMESTAMP
+	RETURNING form_id,allow_edits,edit_window,allow_withdrawal,updated_at`
+	row := s.db.QueryRowContext(ctx, query, formId, allowEdits, editWindow, allowWithdrawal)
+	if err := row.Scan(&settings.FormId, &settings.AllowEdits, &settings.EditWindow, &settings.AllowWithdrawal, &settings.UpdatedAt); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &settings, nil
+}
+
+// UpdateResponseFields replaces the answers of a response with responseFields. answers that change or
+// are left out are kept as edits first, so the response's history shows what the respondent had answered
+func (s *FormResponseStore) UpdateResponseFields(ctx context.Context, formResponseId int, responseFields []struct {
+	FieldValue  string
+	FormFieldId int
+}) ([]ResponseField, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return []ResponseField{}, dbError(err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var formId int
+	var formVersion *int
+	query := `SELECT fr.form_id,fr.form_version FROM form_responses AS fr INNER JOIN forms AS f ON fr.form_id=f.id
+	WHERE fr.id=$1 AND f.deleted_at IS NULL`
+	row := tx.QueryRowContext(ctx, query, formResponseId)
+	if err = row.Scan(&formId, &formVersion); err != nil {
+		err = dbError(err)
+		return []ResponseField{}, err
+	}
+	fields, err := responseFormFields(ctx, tx, formId, formVersion)
+	if err != nil {
+		return []ResponseField{}, err
+	}
+	for _, respField := range responseFields {
+		if _, ok := fields[respField.FormFieldId]; !ok {
+			err = fmt.Errorf("%w: field %d is not a field of the form", ErrValidation, respField.FormFieldId)
+			return []ResponseField{}, err
+		}
+	}
+
+	existing, err := queryAnswers(ctx, tx, formResponseId)
+	if err != nil {
+		return []ResponseField{}, err
+	}
+
+	now := time.Now().UTC()
+	changed := false
+	keep := func(answer ResponseField) error {
+		changed = true
+		_, err := tx.ExecContext(ctx, `INSERT INTO response_field_edits(form_response_id,form_field_id,field_value,edited_at) VALUES($1,$2,$3,$4)`,
+			formResponseId, answer.FormFieldId, answer.FieldValue, now)
+		return dbError(err)
+	}
+
+	// every new answer takes the place of an old answer to the same field, if there is one left
+	replaced := make(map[int]bool)
+	for _, respField := range responseFields {
+		var old *ResponseField
+		for i := range existing {
+			if !replaced[existing[i].Id] && existing[i].FormFieldId == respField.FormFieldId {
+				old = &existing[i]
+				break
+			}
+		}
+
+		if old == nil {
+			changed = true
+			_, err = tx.ExecContext(ctx, `INSERT INTO response_fields(form_response_id,form_field_id,field_value) VALUES($1,$2,$3)`,
+				formResponseId, respField.FormFieldId, respField.FieldValue)
+			if err != nil {
+				err = dbError(err)
+				return []ResponseField{}, err
+			}
+			continue
+		}
+
+		replaced[old.Id] = true
+		if old.FieldValue == respField.FieldValue {
+			continue
+		}
+		if err = keep(*old); err != nil {
+			return []ResponseField{}, err
+		}
+		if _, err = tx.ExecContext(ctx, `UPDATE response_fields SET field_value=$1 WHERE id=$2`, respField.FieldValue, old.Id); err != nil {
+			err = dbError(err)
+			return []ResponseField{}, err
+		}
+	}
+
+	for _, old := range existing {
+		if replaced[old.Id] {
+			continue
+		}
+		if err = keep(old); err != nil {
+			return []ResponseField{}, err
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM response_fields WHERE id=$1`, old.Id); err != nil {
+			err = dbError(err)
+			return []ResponseField{}, err
+		}
+	}
+
+	if changed {
+		if _, err = tx.ExecContext(ctx, `UPDATE form_responses SET updated_at=$1 WHERE id=$2`, now, formResponseId); err != nil {
+			err = dbError(err)
+			return []ResponseField{}, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return []ResponseField{}, dbError(err)
+	}
+	return s.GetResponseFieldsByFormResponseId(ctx, formResponseId)
+}
+
+// queryAnswers lists the answers of a response in the order they were given
+func queryAnswers(ctx context.Context, q queryer, formResponseId int) ([]ResponseField, error) {
+	rows, err := q.QueryContext(ctx, `SELECT id,field_value,form_response_id,form_field_id FROM response_fields
+	WHERE form_response_id=$1 ORDER BY id`, formResponseId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	var answers []ResponseField
+	for rows.Next() {
+		var answer ResponseField
+		if err := rows.Scan(&answer.Id, &answer.FieldValue, &answer.FormResponseId, &answer.FormFieldId); err != nil {
+			return nil, dbError(err)
+		}
+		answers = append(answers, answer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+	return answers, nil
+}
+
+// DeleteFormResponseById withdraws a response, its answers and their edits go with it
+func (s *FormResponseStore) DeleteFormResponseById(ctx context.Context, formResponseId int) error {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM form_responses WHERE id=$1 AND form_id IN (SELECT id FROM forms WHERE deleted_at IS NULL)`
+	result, err := s.db.ExecContext(ctx, query, formResponseId)
+	if err != nil {
+		return dbError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if rowsAffected < 1 {
+		return fmt.Errorf("%w: form response with id %d not deleted", ErrNotFound, formResponseId)
+	}
+	return nil
+}
+
+// GetResponseFieldEdits returns the answers edits replaced, oldest first
+func (s *FormResponseStore) GetResponseFieldEdits(ctx context.Context, formResponseId int) ([]ResponseFieldEdit, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `SELECT e.id,e.form_response_id,e.form_field_id,e.field_value,e.edited_at
+	FROM response_field_edits AS e INNER JOIN form_responses AS fr ON e.form_response_id=fr.id
+	INNER JOIN forms AS f ON fr.form_id=f.id
+	WHERE e.form_response_id=$1 AND f.deleted_at IS NULL ORDER BY e.edited_at,e.id`
+	rows, err := s.db.QueryContext(ctx, query, formResponseId)
+	if err != nil {
+		return []ResponseFieldEdit{}, dbError(err)
+	}
+	defer rows.Close()
+
+	var edits []ResponseFieldEdit
+	for rows.Next() {
+		var edit ResponseFieldEdit
+		if err := rows.Scan(&edit.Id, &edit.FormResponseId, &edit.FormFieldId, &edit.FieldValue, &edit.EditedAt); err != nil {
+			return []ResponseFieldEdit{}, dbError(err)
+		}
+		edits = append(edits, edit)
+	}
+	if err := rows.Err(); err != nil {
+		return []ResponseFieldEdit{}, dbError(err)
+	}
+	return edits, nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"database/sql"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// OpenSQLite opens the sqlite database file at path (created when missing) for use with NewStorage.
+// the queries are shared with postgres, sqlite accepts the same $N placeholders and RETURNING clauses,
+// the differences live in the schema (see cmd/migrate/migrations/sqlite).
+//
+// foreign keys are switched on for every connection since sqlite leaves them off by default and the
+// cascades depend on them, writers wait on each other instead of failing with SQLITE_BUSY.
+func OpenSQLite(path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	// take the write lock when a transaction begins, upgrading a read lock later can't wait on busy_timeout
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
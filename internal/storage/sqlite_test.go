@@ -0,0 +1,101 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dhruv15803/cmd/migrate/migrations"
+	"github.com/dhruv15803/internal/migrate"
+	"github.com/dhruv15803/internal/storage"
+	"github.com/dhruv15803/internal/storage/storagetest"
+)
+
+func TestSQLiteStorage(t *testing.T) {
+	if err := storagetest.TestStorage(newSQLiteStorage(t, false)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSQLiteMigrationsRoundTrip rolls every migration back before applying them again, so the down
+// migrations have to leave a schema the up migrations and the suite still accept
+func TestSQLiteMigrationsRoundTrip(t *testing.T) {
+	if err := storagetest.TestStorage(newSQLiteStorage(t, true)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newSQLiteStorage hands the suite a freshly migrated database file for every check
+func newSQLiteStorage(t *testing.T, roundTrip bool) func() *storage.Storage {
+	dir := t.TempDir()
+	n := 0
+	return func() *storage.Storage {
+		n++
+		db := openMigratedSQLite(t, filepath.Join(dir, fmt.Sprintf("%d.db", n)), roundTrip)
+		return storage.NewStorage(db, 5*time.Second)
+	}
+}
+
+func openMigratedSQLite(t *testing.T, path string, roundTrip bool) *sql.DB {
+	db, err := storage.OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	fsys, err := migrations.FS("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := migrate.New(db, "sqlite", fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if roundTrip {
+		if err := migrator.Goto(ctx, 0); err != nil {
+			t.Fatalf("rolling back every migration: %v", err)
+		}
+		if err := migrator.Up(ctx); err != nil {
+			t.Fatalf("migrating up again: %v", err)
+		}
+	}
+	return db
+}
+
+// the app can't delete a workspace yet, the schema still has to keep its forms when one is deleted by hand
+func TestSQLiteWorkspaceDeleteKeepsForms(t *testing.T) {
+	db := openMigratedSQLite(t, filepath.Join(t.TempDir(), "forms.db"), false)
+	s := storage.NewStorage(db, 5*time.Second)
+	ctx := context.Background()
+
+	alice, err := s.Users.CreateUser(ctx, "alice", "alice@example.com", "hashed-alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	workspace, err := s.Workspaces.CreateWorkspace(ctx, "team", alice.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := s.Forms.CreateForm(ctx, "internal", "description", nil, alice.Id, &workspace.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM workspaces WHERE id = $1`, workspace.Id); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := s.Forms.GetFormById(ctx, form.Id)
+	if err != nil {
+		t.Fatalf("the form went with its workspace: %v", err)
+	}
+	if kept.WorkspaceId != nil {
+		t.Fatalf("the form still points at deleted workspace %d", *kept.WorkspaceId)
+	}
+}
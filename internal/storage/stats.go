@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type SystemStats struct {
+	Users         int `json:"users"`
+	Admins        int `json:"admins"`
+	DisabledUsers int `json:"disabled_users"`
+	// Forms and ReadyForms leave out the forms in the trash
+	Forms            int `json:"forms"`
+	ReadyForms       int `json:"ready_forms"`
+	FormResponses    int `json:"form_responses"`
+	FormResponses24h int `json:"form_responses_24h"`
+	LoginLockouts24h int `json:"login_lockouts_24h"`
+}
+
+type StatsStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func (s *StatsStore) GetSystemStats(ctx context.Context) (*SystemStats, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	// the cutoff is computed here instead of with NOW() - INTERVAL so the query runs on sqlite as well
+	dayAgo := time.Now().Add(-24 * time.Hour).UTC()
+
+	var stats SystemStats
+	query := `SELECT
+		(SELECT COUNT(*) FROM users),
+		(SELECT COUNT(*) FROM users WHERE role='admin'),
+		(SELECT COUNT(*) FROM users WHERE disabled_at IS NOT NULL),
+		(SELECT COUNT(*) FROM forms WHERE deleted_at IS NULL),
+		(SELECT COUNT(*) FROM forms WHERE is_ready AND deleted_at IS NULL),
+		(SELECT COUNT(*) FROM form_responses),
+		(SELECT COUNT(*) FROM form_responses WHERE submitted_at > $1),
+		(SELECT COUNT(*) FROM login_lockouts WHERE created_at > $1)`
+	row := s.db.QueryRowContext(ctx, query, dayAgo)
+	if err := row.Scan(&stats.Users, &stats.Admins, &stats.DisabledUsers, &stats.Forms, &stats.ReadyForms,
+		&stats.FormResponses, &stats.FormResponses24h, &stats.LoginLockouts24h); err != nil {
+		return nil, dbError(err)
+	}
+	return &stats, nil
+}
@@ -1,49 +1,135 @@
 package storage
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"time"
+)
 
 type Storage struct {
 	Users interface {
-		GetUserById(userId int) (*User, error)
-		GetUserByEmail(email string) (*User, error)
-		GetUserByUsername(username string) (*User, error)
-		GetUsersByUsernameOrEmail(username string, email string) ([]User, error)
-		CreateUser(username string, email string, hashedPassword string) (*User, error)
+		GetUserById(ctx context.Context, userId int) (*User, error)
+		GetUserByEmail(ctx context.Context, email string) (*User, error)
+		GetUserByUsername(ctx context.Context, username string) (*User, error)
+		GetUsersByUsernameOrEmail(ctx context.Context, username string, email string) ([]User, error)
+		CreateUser(ctx context.Context, username string, email string, hashedPassword string) (*User, error)
+		UpdateUserProfile(ctx context.Context, userId int, username string, email string) (*User, error)
+		UpdateUserPassword(ctx context.Context, userId int, hashedPassword string) error
+		DeleteUserById(ctx context.Context, userId int) error
+		DeleteUserAndTransferForms(ctx context.Context, userId int, newOwnerId int) error
+		SearchUsers(ctx context.Context, query string, limit int, offset int) ([]User, error)
+		SetUserDisabled(ctx context.Context, userId int, disabled bool) (*User, error)
+		SetUserRole(ctx context.Context, userId int, role string) (*User, error)
 	}
 	Forms interface {
-		CreateForm(formTitle string, formDescription string, userId int) (*Form, error)
-		GetFormsByUserId(userId int) ([]Form, error)
-		GetAllForms() ([]Form, error)
-		GetFormById(formId int) (*Form, error)
-		GetFormByIdWithFieldsAndUser(formId int) (*Form, error)
-		DeleteFormById(formId int) error
+		CreateForm(ctx context.Context, formTitle string, formDescription string, formKey *string, userId int, workspaceId *int) (*Form, error)
+		GetFormsByUserId(ctx context.Context, userId int) ([]Form, error)
+		GetFormsByWorkspaceId(ctx context.Context, workspaceId int) ([]Form, error)
+		GetAllForms(ctx context.Context, userId int) ([]Form, error)
+		GetFormById(ctx context.Context, formId int) (*Form, error)
+		GetFormByIdWithFieldsAndUser(ctx context.Context, formId int) (*Form, error)
+		GetFormByKey(ctx context.Context, formKey string) (*Form, error)
+		UpdateForm(ctx context.Context, formId int, formTitle string, formDescription string) (*Form, error)
+		DeleteFormById(ctx context.Context, formId int) error
+		UpdateFormOwner(ctx context.Context, formId int, userId int) (*Form, error)
+		SetFormTemplate(ctx context.Context, formId int, visibility *string) (*Form, error)
+		GetTemplates(ctx context.Context, userId int) ([]Form, error)
+		DuplicateForm(ctx context.Context, formId int, formTitle string, userId int, workspaceId *int) (*Form, error)
+		GetTrashedForms(ctx context.Context, userId int) ([]Form, error)
+		GetTrashedFormById(ctx context.Context, formId int) (*Form, error)
+		RestoreForm(ctx context.Context, formId int) (*Form, error)
+		PurgeTrashedForms(ctx context.Context, deletedBefore time.Time) (int, error)
 	}
 	FormFields interface {
-		CreateFormField(fieldTitle string, isRequired bool, formId int) (*FormField, error)
-		DeleteFormFieldById(fieldId int) error
-		UpdateFormIsReady(formId int) error
-		GetFormFieldById(fieldId int) (*FormField, error)
-		UpdateFormField(fieldId int, fieldTitle string, isRequired bool) (*FormField, error)
-		GetFormFieldsByFormId(formId int) ([]FormField, error)
+		CreateFormField(ctx context.Context, formId int, spec FieldSpec) (*FormField, error)
+		DeleteFormFieldById(ctx context.Context, fieldId int) error
+		UpdateFormIsReady(ctx context.Context, formId int) error
+		GetFormFieldById(ctx context.Context, fieldId int) (*FormField, error)
+		UpdateFormField(ctx context.Context, fieldId int, spec FieldSpec) (*FormField, error)
+		GetFormFieldsByFormId(ctx context.Context, formId int) ([]FormField, error)
+		ApplyFieldChanges(ctx context.Context, formId int, changes FieldChanges) error
 	}
 	FormResponse interface {
-		CreateFormResponse(formId int, userId int) (*FormResponse, error)
-		CreateResponseFields(formResponseId int, responseFields []struct {
+		CreateFormResponse(ctx context.Context, formId int, userId int, formVersion *int) (*FormResponse, error)
+		CreateResponseFields(ctx context.Context, formResponseId int, responseFields []struct {
 			FieldValue  string
 			FormFieldId int
 		}) ([]ResponseField, error)
-		GetFormResponsesByFormId(formId int) ([]FormResponse, error)
-		GetFormResponseById(FormResponseId int) (*FormResponse, error)
-		GetResponseFieldsByFormResponseId(formResponseId int) ([]ResponseField, error)
-		GetFormResponsesByRespondentId(respondentId int) ([]FormResponse, error)
+		GetFormResponsesByFormId(ctx context.Context, formId int) ([]FormResponse, error)
+		GetFormResponseById(ctx context.Context, FormResponseId int) (*FormResponse, error)
+		GetResponseFieldsByFormResponseId(ctx context.Context, formResponseId int) ([]ResponseField, error)
+		GetFormResponsesByRespondentId(ctx context.Context, respondentId int) ([]FormResponse, error)
+		UpdateResponseFields(ctx context.Context, formResponseId int, responseFields []struct {
+			FieldValue  string
+			FormFieldId int
+		}) ([]ResponseField, error)
+		DeleteFormResponseById(ctx context.Context, formResponseId int) error
+		GetResponseFieldEdits(ctx context.Context, formResponseId int) ([]ResponseFieldEdit, error)
+		GetResponseSettings(ctx context.Context, formId int) (*ResponseSettings, error)
+		SetResponseSettings(ctx context.Context, formId int, allowEdits bool, editWindow *int, allowWithdrawal bool) (*ResponseSettings, error)
+	}
+	FormVersions interface {
+		PublishFormVersion(ctx context.Context, formId int, publishedBy *int) (*FormVersion, error)
+		GetFormVersionsByFormId(ctx context.Context, formId int) ([]FormVersion, error)
+		GetFormVersion(ctx context.Context, formId int, version int) (*FormVersion, error)
+	}
+	ResponseDrafts interface {
+		SaveResponseDraft(ctx context.Context, formId int, respondentId int, answers []DraftAnswer, expiresAt *time.Time) (*ResponseDraft, error)
+		GetResponseDraft(ctx context.Context, formId int, respondentId int) (*ResponseDraft, error)
+		GetResponseDraftsByRespondentId(ctx context.Context, respondentId int) ([]ResponseDraft, error)
+		DeleteResponseDraft(ctx context.Context, formId int, respondentId int) error
+		SubmitResponseDraft(ctx context.Context, formId int, respondentId int, formVersion *int, responseFields []struct {
+			FieldValue  string
+			FormFieldId int
+		}) (*FormResponse, []ResponseField, error)
+		PurgeExpiredResponseDrafts(ctx context.Context, expiredBefore time.Time) (int, error)
+	}
+	LoginLockouts interface {
+		CreateLoginLockout(ctx context.Context, userId int, ipAddress string, failedAttempts int, lockedUntil time.Time) (*LoginLockout, error)
+		GetLoginLockoutsByUserId(ctx context.Context, userId int) ([]LoginLockout, error)
+	}
+	FormCollaborators interface {
+		AddFormCollaborator(ctx context.Context, formId int, userId int, role string) (*FormCollaborator, error)
+		GetFormCollaborator(ctx context.Context, formId int, userId int) (*FormCollaborator, error)
+		GetFormCollaboratorsByFormId(ctx context.Context, formId int) ([]FormCollaborator, error)
+		DeleteFormCollaborator(ctx context.Context, formId int, userId int) error
+	}
+	Workspaces interface {
+		CreateWorkspace(ctx context.Context, workspaceName string, userId int) (*Workspace, error)
+		GetWorkspaceById(ctx context.Context, workspaceId int) (*Workspace, error)
+		GetWorkspacesByUserId(ctx context.Context, userId int) ([]WorkspaceMembership, error)
+		GetWorkspaceMember(ctx context.Context, workspaceId int, userId int) (*WorkspaceMember, error)
+		GetWorkspaceMembers(ctx context.Context, workspaceId int) ([]WorkspaceMember, error)
+		AddWorkspaceMember(ctx context.Context, workspaceId int, userId int, role string) (*WorkspaceMember, error)
+		DeleteWorkspaceMember(ctx context.Context, workspaceId int, userId int) error
+		CountWorkspaceOwners(ctx context.Context, workspaceId int) (int, error)
+	}
+	Stats interface {
+		GetSystemStats(ctx context.Context) (*SystemStats, error)
 	}
 }
 
-func NewStorage(db *sql.DB) *Storage {
+// NewStorage builds the postgres backed storage. queryTimeout bounds every query on top of
+// the caller's context, 0 leaves queries bounded by the caller's context alone.
+func NewStorage(db *sql.DB, queryTimeout time.Duration) *Storage {
 	return &Storage{
-		Users:        &UserStore{db: db},
-		Forms:        &FormStore{db: db},
-		FormFields:   &FormFieldStore{db: db},
-		FormResponse: &FormResponseStore{db: db},
+		Users:             &UserStore{db: db, queryTimeout: queryTimeout},
+		Forms:             &FormStore{db: db, queryTimeout: queryTimeout},
+		FormFields:        &FormFieldStore{db: db, queryTimeout: queryTimeout},
+		FormResponse:      &FormResponseStore{db: db, queryTimeout: queryTimeout},
+		FormVersions:      &FormVersionStore{db: db, queryTimeout: queryTimeout},
+		ResponseDrafts:    &ResponseDraftStore{db: db, queryTimeout: queryTimeout},
+		LoginLockouts:     &LoginLockoutStore{db: db, queryTimeout: queryTimeout},
+		FormCollaborators: &FormCollaboratorStore{db: db, queryTimeout: queryTimeout},
+		Workspaces:        &WorkspaceStore{db: db, queryTimeout: queryTimeout},
+		Stats:             &StatsStore{db: db, queryTimeout: queryTimeout},
+	}
+}
+
+// withTimeout bounds ctx by the per-query deadline
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, timeout)
 }
@@ -0,0 +1,1627 @@
+// Package storagetest checks that a storage backend behaves the way the handlers expect,
+// unique constraints, foreign keys, cascades and sql.ErrNoRows for missing rows.
+//
+// errors are expected to be classified with the storage sentinel errors (ErrNotFound, ErrConflict,
+// ErrValidation) so handlers never see driver errors.
+//
+// it follows testing/fstest, TestStorage reports what is wrong as an error so the same
+// suite can run from a test, a benchmark or a one-off program against any backend:
+//
+//	if err := storagetest.TestStorage(storage.NewMemoryStorage); err != nil {
+//		t.Fatal(err)
+//	}
+package storagetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dhruv15803/internal/storage"
+)
+
+type check struct {
+	name string
+	run  func(ctx context.Context, s *storage.Storage) error
+}
+
+var checks = []check{
+	{"users/unique email and username", checkUserUniqueness},
+	{"users/missing rows", checkUserNotFound},
+	{"users/profile, role and disabled", checkUserUpdates},
+	{"users/delete cascades", checkUserDeleteCascades},
+	{"users/delete and transfer forms", checkUserDeleteTransfersForms},
+	{"users/search", checkUserSearch},
+	{"forms/foreign keys", checkFormForeignKeys},
+	{"forms/fields and readiness", checkFormFields},
+	{"forms/delete cascades", checkFormDeleteCascades},
+	{"forms/trash and restore", checkFormTrash},
+	{"forms/keys, field types and order", checkFormDefinitions},
+	{"forms/templates and duplication", checkFormTemplates},
+	{"forms/versions keep their answers", checkFormVersions},
+	{"forms/field changes are all or nothing", checkFieldChangesAtomic},
+	{"responses/fields are all or nothing", checkResponseFieldsAtomic},
+	{"responses/joins", checkResponseJoins},
+	{"responses/edits and withdrawal", checkResponseEdits},
+	{"responses/drafts", checkResponseDrafts},
+	{"collaborators/upsert", checkCollaboratorUpsert},
+	{"workspaces/form visibility", checkWorkspaceVisibility},
+	{"workspaces/forms handed over on delete", checkWorkspaceHandOver},
+}
+
+// TestStorage runs every check against a storage returned by newStorage. newStorage is called
+// once per check and has to hand back an empty store, the checks assume ids and rows of their own.
+// all failing checks are reported together.
+func TestStorage(newStorage func() *storage.Storage) error {
+	var errs []error
+	for _, c := range checks {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := c.run(ctx, newStorage()); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+		cancel()
+	}
+	return errors.Join(errs...)
+}
+
+func createUser(ctx context.Context, s *storage.Storage, name string) (*storage.User, error) {
+	user, err := s.Users.CreateUser(ctx, name, name+"@example.com", "hashed-"+name)
+	if err != nil {
+		return nil, fmt.Errorf("CreateUser(%s): %w", name, err)
+	}
+	return user, nil
+}
+
+func expectNoRows(what string, err error) error {
+	if !errors.Is(err, storage.ErrNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: expected storage.ErrNotFound wrapping sql.ErrNoRows, got %v", what, err)
+	}
+	return nil
+}
+
+func expectError(what string, err error, target error) error {
+	if !errors.Is(err, target) {
+		return fmt.Errorf("%s: expected %v, got %v", what, target, err)
+	}
+	return nil
+}
+
+func checkUserUniqueness(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	if alice.Id == 0 || alice.CreatedAt == "" || alice.Role != storage.RoleUser {
+		return fmt.Errorf("CreateUser returned %+v, want id, created_at and the user role filled in", alice)
+	}
+	_, err = s.Users.CreateUser(ctx, "alice", "other@example.com", "x")
+	if err := expectError("duplicate username", err, storage.ErrConflict); err != nil {
+		return err
+	}
+	_, err = s.Users.CreateUser(ctx, "other", "alice@example.com", "x")
+	if err := expectError("duplicate email", err, storage.ErrConflict); err != nil {
+		return err
+	}
+
+	users, err := s.Users.GetUsersByUsernameOrEmail(ctx, "alice", "nobody@example.com")
+	if err != nil {
+		return err
+	}
+	if len(users) != 1 || users[0].Id != alice.Id {
+		return fmt.Errorf("GetUsersByUsernameOrEmail returned %d users, want alice only", len(users))
+	}
+	return nil
+}
+
+func checkUserNotFound(ctx context.Context, s *storage.Storage) error {
+	_, err := s.Users.GetUserById(ctx, 4242)
+	if err := expectNoRows("GetUserById", err); err != nil {
+		return err
+	}
+	_, err = s.Users.GetUserByEmail(ctx, "nobody@example.com")
+	if err := expectNoRows("GetUserByEmail", err); err != nil {
+		return err
+	}
+	_, err = s.Users.GetUserByUsername(ctx, "nobody")
+	if err := expectNoRows("GetUserByUsername", err); err != nil {
+		return err
+	}
+	_, err = s.Users.SetUserRole(ctx, 4242, storage.RoleAdmin)
+	if err := expectNoRows("SetUserRole", err); err != nil {
+		return err
+	}
+	return expectError("DeleteUserById", s.Users.DeleteUserById(ctx, 4242), storage.ErrNotFound)
+}
+
+func checkUserUpdates(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	if _, err := createUser(ctx, s, "bob"); err != nil {
+		return err
+	}
+
+	_, err = s.Users.UpdateUserProfile(ctx, alice.Id, "bob", "alice@example.com")
+	if err := expectError("UpdateUserProfile with a taken username", err, storage.ErrConflict); err != nil {
+		return err
+	}
+	updated, err := s.Users.UpdateUserProfile(ctx, alice.Id, "alice2", "alice2@example.com")
+	if err != nil {
+		return err
+	}
+	if updated.Username != "alice2" || updated.Email != "alice2@example.com" {
+		return fmt.Errorf("UpdateUserProfile returned %s/%s", updated.Username, updated.Email)
+	}
+
+	_, err = s.Users.SetUserRole(ctx, alice.Id, "superuser")
+	if err := expectError("SetUserRole with an unknown role", err, storage.ErrValidation); err != nil {
+		return err
+	}
+	if updated, err = s.Users.SetUserRole(ctx, alice.Id, storage.RoleAdmin); err != nil {
+		return err
+	}
+	if updated.Role != storage.RoleAdmin {
+		return fmt.Errorf("role is %q after SetUserRole", updated.Role)
+	}
+
+	if updated, err = s.Users.SetUserDisabled(ctx, alice.Id, true); err != nil {
+		return err
+	}
+	if updated.DisabledAt == nil {
+		return errors.New("disabled_at not set after disabling")
+	}
+	if updated, err = s.Users.SetUserDisabled(ctx, alice.Id, false); err != nil {
+		return err
+	}
+	if updated.DisabledAt != nil {
+		return errors.New("disabled_at still set after enabling")
+	}
+
+	before, err := s.Users.GetUserById(ctx, alice.Id)
+	if err != nil {
+		return err
+	}
+	if err := s.Users.UpdateUserPassword(ctx, alice.Id, "new-hash"); err != nil {
+		return err
+	}
+	fetched, err := s.Users.GetUserById(ctx, alice.Id)
+	if err != nil {
+		return err
+	}
+	if fetched.Password != "new-hash" {
+		return errors.New("UpdateUserPassword did not store the new hash")
+	}
+	if fetched.TokenVersion != before.TokenVersion+1 {
+		return fmt.Errorf("token version is %d after a password change, want %d", fetched.TokenVersion, before.TokenVersion+1)
+	}
+	return nil
+}
+
+// textField is the spec of a plain text field added after the existing ones
+func textField(title string, required bool) storage.FieldSpec {
+	return storage.FieldSpec{FieldTitle: title, FieldType: storage.FieldTypeText, Required: required}
+}
+
+// seedForm creates a form with a single field and one response to it
+func seedForm(ctx context.Context, s *storage.Storage, ownerId int, respondentId int) (*storage.Form, *storage.FormField, *storage.FormResponse, error) {
+	form, err := s.Forms.CreateForm(ctx, "feedback", "tell us", nil, ownerId, nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("CreateForm: %w", err)
+	}
+	field, err := s.FormFields.CreateFormField(ctx, form.Id, textField("how was it", true))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("CreateFormField: %w", err)
+	}
+	formResponse, err := s.FormResponse.CreateFormResponse(ctx, form.Id, respondentId, nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("CreateFormResponse: %w", err)
+	}
+	_, err = s.FormResponse.CreateResponseFields(ctx, formResponse.Id, []struct {
+		FieldValue  string
+		FormFieldId int
+	}{{FieldValue: "great", FormFieldId: field.Id}})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("CreateResponseFields: %w", err)
+	}
+	return form, field, formResponse, nil
+}
+
+func checkUserDeleteCascades(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	aliceForm, _, _, err := seedForm(ctx, s, alice.Id, bob.Id)
+	if err != nil {
+		return err
+	}
+	bobForm, _, aliceResponse, err := seedForm(ctx, s, bob.Id, alice.Id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.LoginLockouts.CreateLoginLockout(ctx, alice.Id, "127.0.0.1", 10, time.Now().Add(time.Minute)); err != nil {
+		return err
+	}
+	if _, err := s.FormCollaborators.AddFormCollaborator(ctx, bobForm.Id, alice.Id, storage.CollaboratorEditor); err != nil {
+		return err
+	}
+
+	if err := s.Users.DeleteUserById(ctx, alice.Id); err != nil {
+		return err
+	}
+
+	_, err = s.Users.GetUserById(ctx, alice.Id)
+	if err := expectNoRows("GetUserById after delete", err); err != nil {
+		return err
+	}
+	_, err = s.Forms.GetFormById(ctx, aliceForm.Id)
+	if err := expectNoRows("form of a deleted user", err); err != nil {
+		return err
+	}
+	_, err = s.FormResponse.GetFormResponseById(ctx, aliceResponse.Id)
+	if err := expectNoRows("response of a deleted user", err); err != nil {
+		return err
+	}
+	lockouts, err := s.LoginLockouts.GetLoginLockoutsByUserId(ctx, alice.Id)
+	if err != nil {
+		return err
+	}
+	if len(lockouts) != 0 {
+		return fmt.Errorf("%d lockouts survived their user", len(lockouts))
+	}
+	_, err = s.FormCollaborators.GetFormCollaborator(ctx, bobForm.Id, alice.Id)
+	if err := expectNoRows("collaborator row of a deleted user", err); err != nil {
+		return err
+	}
+	if _, err := s.Forms.GetFormById(ctx, bobForm.Id); err != nil {
+		return fmt.Errorf("another user's form went missing: %w", err)
+	}
+	return nil
+}
+
+func checkUserDeleteTransfersForms(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	carol, err := createUser(ctx, s, "carol")
+	if err != nil {
+		return err
+	}
+	form, _, _, err := seedForm(ctx, s, alice.Id, bob.Id)
+	if err != nil {
+		return err
+	}
+
+	// forms only go to another account that can still use them
+	err = s.Users.DeleteUserAndTransferForms(ctx, alice.Id, alice.Id)
+	if err := expectError("transferring forms to the deleted account", err, storage.ErrValidation); err != nil {
+		return err
+	}
+	err = s.Users.DeleteUserAndTransferForms(ctx, alice.Id, 4242)
+	if err := expectError("transferring forms to a missing user", err, storage.ErrNotFound); err != nil {
+		return err
+	}
+	if _, err := s.Users.SetUserDisabled(ctx, carol.Id, true); err != nil {
+		return err
+	}
+	err = s.Users.DeleteUserAndTransferForms(ctx, alice.Id, carol.Id)
+	if err := expectError("transferring forms to a disabled user", err, storage.ErrValidation); err != nil {
+		return err
+	}
+	if _, err := s.Users.GetUserById(ctx, alice.Id); err != nil {
+		return fmt.Errorf("a refused transfer deleted the user: %w", err)
+	}
+
+	if err := s.Users.DeleteUserAndTransferForms(ctx, alice.Id, bob.Id); err != nil {
+		return err
+	}
+	transferred, err := s.Forms.GetFormById(ctx, form.Id)
+	if err != nil {
+		return fmt.Errorf("transferred form: %w", err)
+	}
+	if transferred.UserId != bob.Id {
+		return fmt.Errorf("form belongs to user %d, want %d", transferred.UserId, bob.Id)
+	}
+	responses, err := s.FormResponse.GetFormResponsesByFormId(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if len(responses) != 1 {
+		return fmt.Errorf("transferred form has %d responses, want 1", len(responses))
+	}
+	return nil
+}
+
+func checkUserSearch(ctx context.Context, s *storage.Storage) error {
+	for _, name := range []string{"ann_lee", "annxlee", "Bob"} {
+		if _, err := createUser(ctx, s, name); err != nil {
+			return err
+		}
+	}
+
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"ann_lee", "annxlee", "Bob"}},
+		{"ANN", []string{"ann_lee", "annxlee"}},
+		{"bob@", []string{"Bob"}},
+		// LIKE wildcards in the query are matched literally
+		{"_", []string{"ann_lee"}},
+		{"n_l", []string{"ann_lee"}},
+		{"%", nil},
+		{`\`, nil},
+	} {
+		users, err := s.Users.SearchUsers(ctx, tt.query, 10, 0)
+		if err != nil {
+			return fmt.Errorf("SearchUsers(%q): %w", tt.query, err)
+		}
+		var got []string
+		for _, user := range users {
+			got = append(got, user.Username)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			return fmt.Errorf("SearchUsers(%q) found %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	page, err := s.Users.SearchUsers(ctx, "ann", 1, 1)
+	if err != nil {
+		return err
+	}
+	if len(page) != 1 || page[0].Username != "annxlee" {
+		return fmt.Errorf("the second page of one found %+v, want annxlee", page)
+	}
+	return nil
+}
+
+func checkFormForeignKeys(ctx context.Context, s *storage.Storage) error {
+	_, err := s.Forms.CreateForm(ctx, "orphan", "", nil, 4242, nil)
+	if err := expectError("CreateForm for a missing user", err, storage.ErrValidation); err != nil {
+		return err
+	}
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	missingWorkspace := 4242
+	if _, err := s.Forms.CreateForm(ctx, "orphan", "", nil, alice.Id, &missingWorkspace); err == nil {
+		return errors.New("CreateForm accepted a missing workspace")
+	}
+	if _, err := s.FormFields.CreateFormField(ctx, 4242, textField("orphan", false)); err == nil {
+		return errors.New("CreateFormField accepted a missing form")
+	}
+	if _, err := s.FormResponse.CreateFormResponse(ctx, 4242, alice.Id, nil); err == nil {
+		return errors.New("CreateFormResponse accepted a missing form")
+	}
+
+	_, err = s.Forms.GetFormById(ctx, 4242)
+	if err := expectNoRows("GetFormById", err); err != nil {
+		return err
+	}
+	_, err = s.Forms.GetFormByIdWithFieldsAndUser(ctx, 4242)
+	if err := expectNoRows("GetFormByIdWithFieldsAndUser", err); err != nil {
+		return err
+	}
+	_, err = s.FormFields.GetFormFieldById(ctx, 4242)
+	if err := expectNoRows("GetFormFieldById", err); err != nil {
+		return err
+	}
+	_, err = s.FormResponse.GetFormResponseById(ctx, 4242)
+	if err := expectNoRows("GetFormResponseById", err); err != nil {
+		return err
+	}
+	return expectError("DeleteFormById", s.Forms.DeleteFormById(ctx, 4242), storage.ErrNotFound)
+}
+
+func checkFormFields(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	form, err := s.Forms.CreateForm(ctx, "feedback", "tell us", nil, alice.Id, nil)
+	if err != nil {
+		return err
+	}
+	if form.IsReady {
+		return errors.New("a new form is ready before it has fields")
+	}
+
+	field, err := s.FormFields.CreateFormField(ctx, form.Id, textField("how was it", true))
+	if err != nil {
+		return err
+	}
+	if err := s.FormFields.UpdateFormIsReady(ctx, form.Id); err != nil {
+		return err
+	}
+	full, err := s.Forms.GetFormByIdWithFieldsAndUser(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if !full.IsReady {
+		return errors.New("form with a field is not ready")
+	}
+	if len(full.FormFields) != 1 || full.FormFields[0].Id != field.Id {
+		return fmt.Errorf("form came back with %d fields, want 1", len(full.FormFields))
+	}
+	if full.User == nil || full.User.Id != alice.Id {
+		return errors.New("form came back without its user")
+	}
+
+	updated, err := s.FormFields.UpdateFormField(ctx, field.Id, textField("what went wrong", false))
+	if err != nil {
+		return err
+	}
+	if updated.FieldTitle != "what went wrong" || updated.Required {
+		return fmt.Errorf("UpdateFormField returned %+v", updated)
+	}
+	_, err = s.FormFields.UpdateFormField(ctx, 4242, textField("x", false))
+	if err := expectNoRows("UpdateFormField", err); err != nil {
+		return err
+	}
+
+	if err := s.FormFields.DeleteFormFieldById(ctx, field.Id); err != nil {
+		return err
+	}
+	if err := s.FormFields.DeleteFormFieldById(ctx, field.Id); err == nil {
+		return errors.New("deleting a field twice did not fail")
+	}
+	if err := s.FormFields.UpdateFormIsReady(ctx, form.Id); err != nil {
+		return err
+	}
+	form, err = s.Forms.GetFormById(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if form.IsReady {
+		return errors.New("form without fields is still ready")
+	}
+	return nil
+}
+
+func checkFormDefinitions(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	key := "team/retro"
+	form, err := s.Forms.CreateForm(ctx, "retro", "sprint retro", &key, alice.Id, nil)
+	if err != nil {
+		return err
+	}
+	if form.FormKey == nil || *form.FormKey != key {
+		return fmt.Errorf("CreateForm returned form key %v", form.FormKey)
+	}
+	// keys are unique across owners
+	_, err = s.Forms.CreateForm(ctx, "retro", "", &key, bob.Id, nil)
+	if err := expectError("CreateForm with a taken key", err, storage.ErrConflict); err != nil {
+		return err
+	}
+	if _, err := s.Forms.CreateForm(ctx, "unkeyed", "", nil, bob.Id, nil); err != nil {
+		return err
+	}
+	if _, err := s.Forms.CreateForm(ctx, "unkeyed", "", nil, bob.Id, nil); err != nil {
+		return fmt.Errorf("forms without a key clash: %w", err)
+	}
+	byKey, err := s.Forms.GetFormByKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	if byKey.Id != form.Id {
+		return fmt.Errorf("GetFormByKey returned form %d, want %d", byKey.Id, form.Id)
+	}
+	_, err = s.Forms.GetFormByKey(ctx, "missing")
+	if err := expectNoRows("GetFormByKey", err); err != nil {
+		return err
+	}
+	updated, err := s.Forms.UpdateForm(ctx, form.Id, "retrospective", "what went well")
+	if err != nil {
+		return err
+	}
+	if updated.FormTitle != "retrospective" || updated.FormDescription != "what went well" || updated.FormKey == nil {
+		return fmt.Errorf("UpdateForm returned %+v", updated)
+	}
+	_, err = s.Forms.UpdateForm(ctx, 4242, "x", "x")
+	if err := expectNoRows("UpdateForm", err); err != nil {
+		return err
+	}
+
+	moodKey, notesKey := "mood", "notes"
+	mood, err := s.FormFields.CreateFormField(ctx, form.Id, storage.FieldSpec{
+		FieldKey: &moodKey, FieldTitle: "mood", FieldType: storage.FieldTypeChoice, Required: true, Options: []string{"good", "meh", "bad"},
+	})
+	if err != nil {
+		return err
+	}
+	if mood.FieldType != storage.FieldTypeChoice || len(mood.Options) != 3 || mood.FieldKey == nil || mood.Position != 1 {
+		return fmt.Errorf("CreateFormField returned %+v", mood)
+	}
+	notes, err := s.FormFields.CreateFormField(ctx, form.Id, storage.FieldSpec{FieldKey: &notesKey, FieldTitle: "notes", FieldType: storage.FieldTypeLongText})
+	if err != nil {
+		return err
+	}
+	if notes.Position != 2 || notes.Options != nil {
+		return fmt.Errorf("the second field came back with position %d and options %v", notes.Position, notes.Options)
+	}
+	_, err = s.FormFields.CreateFormField(ctx, form.Id, storage.FieldSpec{FieldKey: &notesKey, FieldTitle: "again", FieldType: storage.FieldTypeText})
+	if err := expectError("CreateFormField with a taken key", err, storage.ErrConflict); err != nil {
+		return err
+	}
+	_, err = s.FormFields.CreateFormField(ctx, form.Id, storage.FieldSpec{FieldTitle: "odd", FieldType: "colour"})
+	if err := expectError("CreateFormField with an unknown type", err, storage.ErrValidation); err != nil {
+		return err
+	}
+
+	// move notes in front of mood
+	if _, err := s.FormFields.UpdateFormField(ctx, notes.Id, storage.FieldSpec{FieldKey: &notesKey, FieldTitle: "notes", FieldType: storage.FieldTypeLongText, Position: 1}); err != nil {
+		return err
+	}
+	moved, err := s.FormFields.UpdateFormField(ctx, mood.Id, storage.FieldSpec{
+		FieldKey: &moodKey, FieldTitle: "mood", FieldType: storage.FieldTypeChoice, Position: 2, Options: []string{"good", "bad"},
+	})
+	if err != nil {
+		return err
+	}
+	if len(moved.Options) != 2 || moved.Required {
+		return fmt.Errorf("UpdateFormField returned %+v", moved)
+	}
+	fields, err := s.FormFields.GetFormFieldsByFormId(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if len(fields) != 2 || fields[0].Id != notes.Id || fields[1].Id != mood.Id {
+		return fmt.Errorf("GetFormFieldsByFormId did not list the fields by position: %+v", fields)
+	}
+	full, err := s.Forms.GetFormByIdWithFieldsAndUser(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if len(full.FormFields) != 2 || full.FormFields[0].Id != notes.Id || len(full.FormFields[1].Options) != 2 {
+		return fmt.Errorf("GetFormByIdWithFieldsAndUser did not list the fields by position: %+v", full.FormFields)
+	}
+	return nil
+}
+
+func checkFormTemplates(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	workspace, err := s.Workspaces.CreateWorkspace(ctx, "team", alice.Id)
+	if err != nil {
+		return err
+	}
+
+	key := "team/one-on-one"
+	source, err := s.Forms.CreateForm(ctx, "1:1", "weekly 1:1 feedback", &key, alice.Id, &workspace.Id)
+	if err != nil {
+		return err
+	}
+	moodKey := "mood"
+	if _, err := s.FormFields.CreateFormField(ctx, source.Id, storage.FieldSpec{
+		FieldKey: &moodKey, FieldTitle: "mood", FieldType: storage.FieldTypeChoice, Required: true, Options: []string{"good", "bad"},
+	}); err != nil {
+		return err
+	}
+	if _, err := s.FormFields.CreateFormField(ctx, source.Id, textField("notes", false)); err != nil {
+		return err
+	}
+	if err := s.FormFields.UpdateFormIsReady(ctx, source.Id); err != nil {
+		return err
+	}
+
+	visibility := storage.TemplateWorkspace
+	template, err := s.Forms.SetFormTemplate(ctx, source.Id, &visibility)
+	if err != nil {
+		return err
+	}
+	if template.TemplateVisibility == nil || *template.TemplateVisibility != storage.TemplateWorkspace {
+		return fmt.Errorf("SetFormTemplate returned visibility %v", template.TemplateVisibility)
+	}
+	invalid := "everyone"
+	_, err = s.Forms.SetFormTemplate(ctx, source.Id, &invalid)
+	if err := expectError("SetFormTemplate with an unknown visibility", err, storage.ErrValidation); err != nil {
+		return err
+	}
+	_, err = s.Forms.SetFormTemplate(ctx, 4242, &visibility)
+	if err := expectNoRows("SetFormTemplate", err); err != nil {
+		return err
+	}
+
+	templates, err := s.Forms.GetTemplates(ctx, bob.Id)
+	if err != nil {
+		return err
+	}
+	if len(templates) != 0 {
+		return fmt.Errorf("an outsider sees %d workspace templates, want 0", len(templates))
+	}
+	if _, err := s.Workspaces.AddWorkspaceMember(ctx, workspace.Id, bob.Id, storage.WorkspaceRoleMember); err != nil {
+		return err
+	}
+	if templates, err = s.Forms.GetTemplates(ctx, bob.Id); err != nil {
+		return err
+	}
+	if len(templates) != 1 || templates[0].Id != source.Id || templates[0].User == nil {
+		return fmt.Errorf("a member sees templates %+v, want form %d", templates, source.Id)
+	}
+	visibility = storage.TemplatePrivate
+	if _, err := s.Forms.SetFormTemplate(ctx, source.Id, &visibility); err != nil {
+		return err
+	}
+	if templates, err = s.Forms.GetTemplates(ctx, bob.Id); err != nil {
+		return err
+	}
+	if len(templates) != 0 {
+		return fmt.Errorf("another user sees %d private templates, want 0", len(templates))
+	}
+	if templates, err = s.Forms.GetTemplates(ctx, alice.Id); err != nil {
+		return err
+	}
+	if len(templates) != 1 {
+		return fmt.Errorf("the owner sees %d of their private templates, want 1", len(templates))
+	}
+
+	copied, err := s.Forms.DuplicateForm(ctx, source.Id, "1:1 week 2", bob.Id, nil)
+	if err != nil {
+		return err
+	}
+	if copied.Id == source.Id || copied.UserId != bob.Id || copied.WorkspaceId != nil || copied.FormTitle != "1:1 week 2" ||
+		copied.FormDescription != source.FormDescription || !copied.IsReady || copied.FormKey != nil || copied.TemplateVisibility != nil {
+		return fmt.Errorf("DuplicateForm returned %+v", copied)
+	}
+	if len(copied.FormFields) != 2 {
+		return fmt.Errorf("DuplicateForm copied %d fields, want 2", len(copied.FormFields))
+	}
+	first := copied.FormFields[0]
+	if first.FormId != copied.Id || first.FieldKey == nil || *first.FieldKey != moodKey || first.FieldType != storage.FieldTypeChoice ||
+		!first.Required || len(first.Options) != 2 || copied.FormFields[1].FieldTitle != "notes" {
+		return fmt.Errorf("DuplicateForm copied fields %+v", copied.FormFields)
+	}
+	// the copy's fields are its own
+	if err := s.FormFields.DeleteFormFieldById(ctx, first.Id); err != nil {
+		return err
+	}
+	sourceFields, err := s.FormFields.GetFormFieldsByFormId(ctx, source.Id)
+	if err != nil {
+		return err
+	}
+	if len(sourceFields) != 2 {
+		return fmt.Errorf("deleting a copied field left the template with %d fields, want 2", len(sourceFields))
+	}
+	if _, err := s.Forms.DuplicateForm(ctx, source.Id, "1:1 in team", alice.Id, &workspace.Id); err != nil {
+		return err
+	}
+	_, err = s.Forms.DuplicateForm(ctx, 4242, "x", alice.Id, nil)
+	if err := expectNoRows("DuplicateForm", err); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkFormVersions checks that answers to a published version keep the field as it was published,
+// while answers given before versioning still go with their field
+func checkFormVersions(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	form, field, unversioned, err := seedForm(ctx, s, alice.Id, bob.Id)
+	if err != nil {
+		return err
+	}
+
+	first, err := s.FormVersions.PublishFormVersion(ctx, form.Id, &alice.Id)
+	if err != nil {
+		return err
+	}
+	if first.Version != 1 || len(first.FormFields) != 1 || first.FormFields[0].Id != field.Id || first.FormTitle != form.FormTitle {
+		return fmt.Errorf("PublishFormVersion returned %+v, want version 1 with the form's field", first)
+	}
+	missingVersion := 2
+	_, err = s.FormResponse.CreateFormResponse(ctx, form.Id, bob.Id, &missingVersion)
+	if err := expectError("response to a missing version", err, storage.ErrValidation); err != nil {
+		return err
+	}
+	formResponse, err := s.FormResponse.CreateFormResponse(ctx, form.Id, bob.Id, &first.Version)
+	if err != nil {
+		return err
+	}
+	if formResponse.FormVersion == nil || *formResponse.FormVersion != 1 {
+		return fmt.Errorf("CreateFormResponse returned version %v, want 1", formResponse.FormVersion)
+	}
+	_, err = s.FormResponse.CreateResponseFields(ctx, formResponse.Id, []struct {
+		FieldValue  string
+		FormFieldId int
+	}{{FieldValue: "fine", FormFieldId: field.Id}})
+	if err != nil {
+		return err
+	}
+
+	// fields added to the draft aren't part of the published version
+	added, err := s.FormFields.CreateFormField(ctx, form.Id, textField("anything else", false))
+	if err != nil {
+		return err
+	}
+	_, err = s.FormResponse.CreateResponseFields(ctx, formResponse.Id, []struct {
+		FieldValue  string
+		FormFieldId int
+	}{{FieldValue: "no", FormFieldId: added.Id}})
+	if err := expectError("answer to a draft field", err, storage.ErrValidation); err != nil {
+		return err
+	}
+
+	if _, err := s.FormFields.UpdateFormField(ctx, field.Id, textField("renamed", true)); err != nil {
+		return err
+	}
+	if err := s.FormFields.DeleteFormFieldById(ctx, field.Id); err != nil {
+		return err
+	}
+	responseFields, err := s.FormResponse.GetResponseFieldsByFormResponseId(ctx, formResponse.Id)
+	if err != nil {
+		return err
+	}
+	if len(responseFields) != 1 || responseFields[0].FieldValue != "fine" || responseFields[0].FormField.FieldTitle != "how was it" {
+		return fmt.Errorf("answers to version 1 came back as %+v, want the field as it was published", responseFields)
+	}
+	responseFields, err = s.FormResponse.GetResponseFieldsByFormResponseId(ctx, unversioned.Id)
+	if err != nil {
+		return err
+	}
+	if len(responseFields) != 0 {
+		return fmt.Errorf("%d answers from before versioning survived their field", len(responseFields))
+	}
+
+	second, err := s.FormVersions.PublishFormVersion(ctx, form.Id, nil)
+	if err != nil {
+		return err
+	}
+	if second.Version != 2 || len(second.FormFields) != 1 || second.FormFields[0].Id != added.Id || second.PublishedBy != nil {
+		return fmt.Errorf("second PublishFormVersion returned %+v", second)
+	}
+	published, err := s.Forms.GetFormById(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if published.PublishedVersion == nil || *published.PublishedVersion != 2 {
+		return fmt.Errorf("form has published version %v, want 2", published.PublishedVersion)
+	}
+	// emptying the draft leaves the published version taking responses
+	if err := s.FormFields.DeleteFormFieldById(ctx, added.Id); err != nil {
+		return err
+	}
+	if err := s.FormFields.UpdateFormIsReady(ctx, form.Id); err != nil {
+		return err
+	}
+	published, err = s.Forms.GetFormById(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if !published.IsReady {
+		return errors.New("a published form without draft fields isn't ready")
+	}
+	old, err := s.FormVersions.GetFormVersion(ctx, form.Id, 1)
+	if err != nil {
+		return err
+	}
+	if len(old.FormFields) != 1 || old.FormFields[0].FieldTitle != "how was it" {
+		return fmt.Errorf("version 1 changed after publishing: %+v", old)
+	}
+	_, err = s.FormVersions.GetFormVersion(ctx, form.Id, 3)
+	if err := expectNoRows("GetFormVersion of a missing version", err); err != nil {
+		return err
+	}
+
+	if err := s.Users.DeleteUserAndTransferForms(ctx, alice.Id, bob.Id); err != nil {
+		return err
+	}
+	old, err = s.FormVersions.GetFormVersion(ctx, form.Id, 1)
+	if err != nil {
+		return err
+	}
+	if old.PublishedBy != nil {
+		return fmt.Errorf("version 1 is still published by deleted user %d", *old.PublishedBy)
+	}
+
+	if err := s.Forms.DeleteFormById(ctx, form.Id); err != nil {
+		return err
+	}
+	if _, err := s.Forms.PurgeTrashedForms(ctx, time.Now().Add(time.Minute)); err != nil {
+		return err
+	}
+	versions, err := s.FormVersions.GetFormVersionsByFormId(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if len(versions) != 0 {
+		return fmt.Errorf("%d versions survived their form", len(versions))
+	}
+	return nil
+}
+
+func checkFormDeleteCascades(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	form, field, formResponse, err := seedForm(ctx, s, alice.Id, bob.Id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.FormCollaborators.AddFormCollaborator(ctx, form.Id, bob.Id, storage.CollaboratorViewer); err != nil {
+		return err
+	}
+
+	// deleting only trashes the form, purging it is what cascades
+	if err := s.Forms.DeleteFormById(ctx, form.Id); err != nil {
+		return err
+	}
+	purged, err := s.Forms.PurgeTrashedForms(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		return err
+	}
+	if purged != 1 {
+		return fmt.Errorf("PurgeTrashedForms purged %d forms, want 1", purged)
+	}
+	_, err = s.Forms.GetTrashedFormById(ctx, form.Id)
+	if err := expectNoRows("purged form", err); err != nil {
+		return err
+	}
+	_, err = s.FormFields.GetFormFieldById(ctx, field.Id)
+	if err := expectNoRows("field of a deleted form", err); err != nil {
+		return err
+	}
+	_, err = s.FormResponse.GetFormResponseById(ctx, formResponse.Id)
+	if err := expectNoRows("response of a deleted form", err); err != nil {
+		return err
+	}
+	responseFields, err := s.FormResponse.GetResponseFieldsByFormResponseId(ctx, formResponse.Id)
+	if err != nil {
+		return err
+	}
+	if len(responseFields) != 0 {
+		return fmt.Errorf("%d response fields survived their form", len(responseFields))
+	}
+	_, err = s.FormCollaborators.GetFormCollaborator(ctx, form.Id, bob.Id)
+	return expectNoRows("collaborator of a deleted form", err)
+}
+
+// checkFormTrash checks that trashed forms and their responses are left out everywhere until restored
+func checkFormTrash(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	carol, err := createUser(ctx, s, "carol")
+	if err != nil {
+		return err
+	}
+	form, field, formResponse, err := seedForm(ctx, s, alice.Id, bob.Id)
+	if err != nil {
+		return err
+	}
+	key := "retro"
+	keyed, err := s.Forms.CreateForm(ctx, "keyed", "", &key, alice.Id, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := s.FormCollaborators.AddFormCollaborator(ctx, form.Id, bob.Id, storage.CollaboratorOwner); err != nil {
+		return err
+	}
+	if _, err := s.FormCollaborators.AddFormCollaborator(ctx, form.Id, carol.Id, storage.CollaboratorViewer); err != nil {
+		return err
+	}
+	if err := s.FormFields.UpdateFormIsReady(ctx, form.Id); err != nil {
+		return err
+	}
+	stats, err := s.Stats.GetSystemStats(ctx)
+	if err != nil {
+		return err
+	}
+	if stats.Forms != 2 || stats.ReadyForms != 1 {
+		return fmt.Errorf("stats count %d forms and %d ready, want 2 and 1", stats.Forms, stats.ReadyForms)
+	}
+
+	if err := s.Forms.DeleteFormById(ctx, form.Id); err != nil {
+		return err
+	}
+	if err := s.Forms.DeleteFormById(ctx, keyed.Id); err != nil {
+		return err
+	}
+	err = s.Forms.DeleteFormById(ctx, form.Id)
+	if err := expectError("deleting a trashed form again", err, storage.ErrNotFound); err != nil {
+		return err
+	}
+
+	_, err = s.Forms.GetFormById(ctx, form.Id)
+	if err := expectNoRows("GetFormById of a trashed form", err); err != nil {
+		return err
+	}
+	_, err = s.Forms.GetFormByKey(ctx, key)
+	if err := expectNoRows("GetFormByKey of a trashed form", err); err != nil {
+		return err
+	}
+	_, err = s.Forms.UpdateForm(ctx, form.Id, "renamed", "")
+	if err := expectNoRows("UpdateForm of a trashed form", err); err != nil {
+		return err
+	}
+	for name, list := range map[string]func() ([]storage.Form, error){
+		"GetAllForms":      func() ([]storage.Form, error) { return s.Forms.GetAllForms(ctx, alice.Id) },
+		"GetFormsByUserId": func() ([]storage.Form, error) { return s.Forms.GetFormsByUserId(ctx, alice.Id) },
+	} {
+		forms, err := list()
+		if err != nil {
+			return err
+		}
+		if len(forms) != 0 {
+			return fmt.Errorf("%s listed %d trashed forms", name, len(forms))
+		}
+	}
+	if stats, err = s.Stats.GetSystemStats(ctx); err != nil {
+		return err
+	}
+	if stats.Forms != 0 || stats.ReadyForms != 0 {
+		return fmt.Errorf("stats count %d forms and %d ready in the trash", stats.Forms, stats.ReadyForms)
+	}
+	_, err = s.FormResponse.GetFormResponseById(ctx, formResponse.Id)
+	if err := expectNoRows("response to a trashed form", err); err != nil {
+		return err
+	}
+	byRespondent, err := s.FormResponse.GetFormResponsesByRespondentId(ctx, bob.Id)
+	if err != nil {
+		return err
+	}
+	if len(byRespondent) != 0 {
+		return fmt.Errorf("GetFormResponsesByRespondentId listed %d responses to a trashed form", len(byRespondent))
+	}
+	_, err = s.FormResponse.CreateFormResponse(ctx, form.Id, bob.Id, nil)
+	if err := expectError("response to a trashed form", err, storage.ErrValidation); err != nil {
+		return err
+	}
+
+	for _, owner := range []*storage.User{alice, bob} {
+		trashed, err := s.Forms.GetTrashedForms(ctx, owner.Id)
+		if err != nil {
+			return err
+		}
+		listed := false
+		for _, trashedForm := range trashed {
+			listed = listed || trashedForm.Id == form.Id && trashedForm.DeletedAt != nil
+		}
+		if !listed {
+			return fmt.Errorf("GetTrashedForms(%s) returned %+v, want the trashed form with its deleted_at", owner.Username, trashed)
+		}
+	}
+	trashed, err := s.Forms.GetTrashedForms(ctx, carol.Id)
+	if err != nil {
+		return err
+	}
+	if len(trashed) != 0 {
+		return fmt.Errorf("a viewer sees %d forms in the trash, want none", len(trashed))
+	}
+
+	// forms deleted after the cutoff are kept
+	purged, err := s.Forms.PurgeTrashedForms(ctx, time.Now().Add(-time.Minute))
+	if err != nil {
+		return err
+	}
+	if purged != 0 {
+		return fmt.Errorf("PurgeTrashedForms purged %d forms deleted after the cutoff", purged)
+	}
+
+	restored, err := s.Forms.RestoreForm(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if restored.Id != form.Id || restored.DeletedAt != nil {
+		return fmt.Errorf("RestoreForm returned %+v", restored)
+	}
+	_, err = s.Forms.RestoreForm(ctx, form.Id)
+	if err := expectNoRows("restoring a form that isn't in the trash", err); err != nil {
+		return err
+	}
+	responseFields, err := s.FormResponse.GetResponseFieldsByFormResponseId(ctx, formResponse.Id)
+	if err != nil {
+		return err
+	}
+	if len(responseFields) != 1 || responseFields[0].FormField.Id != field.Id {
+		return fmt.Errorf("restored response has answers %+v, want the one it was submitted with", responseFields)
+	}
+	trashed, err = s.Forms.GetTrashedForms(ctx, alice.Id)
+	if err != nil {
+		return err
+	}
+	if len(trashed) != 1 || trashed[0].Id != keyed.Id {
+		return fmt.Errorf("GetTrashedForms returned %d forms after the restore, want the keyed one", len(trashed))
+	}
+
+	// the key stays taken while its form is in the trash
+	_, err = s.Forms.CreateForm(ctx, "keyed again", "", &key, alice.Id, nil)
+	return expectError("reusing the key of a trashed form", err, storage.ErrConflict)
+}
+
+func checkFieldChangesAtomic(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	form, field, formResponse, err := seedForm(ctx, s, alice.Id, alice.Id)
+	if err != nil {
+		return err
+	}
+	moodKey := "mood"
+	mood := storage.FieldSpec{FieldKey: &moodKey, FieldTitle: "mood", FieldType: storage.FieldTypeText, Position: 1}
+
+	// the second create clashes with the first, the delete before it is undone as well
+	err = s.FormFields.ApplyFieldChanges(ctx, form.Id, storage.FieldChanges{Delete: []int{field.Id}, Create: []storage.FieldSpec{mood, mood}})
+	if err := expectError("ApplyFieldChanges with a taken key", err, storage.ErrConflict); err != nil {
+		return err
+	}
+	err = s.FormFields.ApplyFieldChanges(ctx, form.Id, storage.FieldChanges{
+		Create: []storage.FieldSpec{mood},
+		Update: []storage.FieldUpdate{{FieldId: 4242, Spec: mood}},
+	})
+	if err := expectError("ApplyFieldChanges updating a missing field", err, storage.ErrNotFound); err != nil {
+		return err
+	}
+	fields, err := s.FormFields.GetFormFieldsByFormId(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if len(fields) != 1 || fields[0].Id != field.Id {
+		return fmt.Errorf("failed field changes left %d fields, want the seeded one", len(fields))
+	}
+	responseFields, err := s.FormResponse.GetResponseFieldsByFormResponseId(ctx, formResponse.Id)
+	if err != nil {
+		return err
+	}
+	if len(responseFields) != 1 {
+		return fmt.Errorf("failed field changes dropped the answer to a field they didn't delete")
+	}
+
+	if err := s.FormFields.ApplyFieldChanges(ctx, form.Id, storage.FieldChanges{
+		Delete: []int{field.Id},
+		Create: []storage.FieldSpec{mood},
+	}); err != nil {
+		return err
+	}
+	fields, err = s.FormFields.GetFormFieldsByFormId(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if len(fields) != 1 || fields[0].FieldKey == nil || *fields[0].FieldKey != moodKey {
+		return fmt.Errorf("ApplyFieldChanges left fields %+v, want only mood", fields)
+	}
+	if responseFields, err = s.FormResponse.GetResponseFieldsByFormResponseId(ctx, formResponse.Id); err != nil {
+		return err
+	}
+	if len(responseFields) != 0 {
+		return fmt.Errorf("the unversioned answer to a deleted field was kept")
+	}
+	got, err := s.Forms.GetFormById(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if !got.IsReady {
+		return errors.New("a form with fields isn't ready after ApplyFieldChanges")
+	}
+
+	if err := s.FormFields.ApplyFieldChanges(ctx, form.Id, storage.FieldChanges{Delete: []int{fields[0].Id}}); err != nil {
+		return err
+	}
+	if got, err = s.Forms.GetFormById(ctx, form.Id); err != nil {
+		return err
+	}
+	if got.IsReady {
+		return errors.New("an unpublished form without fields is still ready after ApplyFieldChanges")
+	}
+	return nil
+}
+
+func checkResponseFieldsAtomic(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	form, field, _, err := seedForm(ctx, s, alice.Id, alice.Id)
+	if err != nil {
+		return err
+	}
+	formResponse, err := s.FormResponse.CreateFormResponse(ctx, form.Id, alice.Id, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.FormResponse.CreateResponseFields(ctx, formResponse.Id, []struct {
+		FieldValue  string
+		FormFieldId int
+	}{{FieldValue: "fine", FormFieldId: field.Id}, {FieldValue: "lost", FormFieldId: 4242}})
+	if err == nil {
+		return errors.New("CreateResponseFields accepted a missing form field")
+	}
+	responseFields, err := s.FormResponse.GetResponseFieldsByFormResponseId(ctx, formResponse.Id)
+	if err != nil {
+		return err
+	}
+	if len(responseFields) != 0 {
+		return fmt.Errorf("%d response fields were kept from a failed batch", len(responseFields))
+	}
+	return nil
+}
+
+// answers builds the rows CreateResponseFields and UpdateResponseFields take, one field id and value after the other
+func answers(fieldsAndValues ...any) []struct {
+	FieldValue  string
+	FormFieldId int
+} {
+	var rows []struct {
+		FieldValue  string
+		FormFieldId int
+	}
+	for i := 0; i+1 < len(fieldsAndValues); i += 2 {
+		rows = append(rows, struct {
+			FieldValue  string
+			FormFieldId int
+		}{FieldValue: fieldsAndValues[i+1].(string), FormFieldId: fieldsAndValues[i].(int)})
+	}
+	return rows
+}
+
+func checkResponseEdits(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	form, field, formResponse, err := seedForm(ctx, s, alice.Id, bob.Id)
+	if err != nil {
+		return err
+	}
+	other, err := s.FormFields.CreateFormField(ctx, form.Id, textField("anything else", false))
+	if err != nil {
+		return err
+	}
+
+	settings, err := s.FormResponse.GetResponseSettings(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if settings.AllowEdits || settings.AllowWithdrawal || settings.EditWindow != nil || settings.UpdatedAt != nil {
+		return fmt.Errorf("GetResponseSettings of a new form returned %+v, want nothing allowed", settings)
+	}
+	window := 3600
+	settings, err = s.FormResponse.SetResponseSettings(ctx, form.Id, true, &window, true)
+	if err != nil {
+		return err
+	}
+	if !settings.AllowEdits || !settings.AllowWithdrawal || settings.EditWindow == nil || *settings.EditWindow != window || settings.UpdatedAt == nil {
+		return fmt.Errorf("SetResponseSettings returned %+v", settings)
+	}
+	settings, err = s.FormResponse.SetResponseSettings(ctx, form.Id, true, nil, false)
+	if err != nil {
+		return err
+	}
+	if settings.EditWindow != nil || settings.AllowWithdrawal {
+		return fmt.Errorf("second SetResponseSettings returned %+v, want no window and no withdrawal", settings)
+	}
+	zero := 0
+	_, err = s.FormResponse.SetResponseSettings(ctx, form.Id, true, &zero, false)
+	if err := expectError("an edit window of 0", err, storage.ErrValidation); err != nil {
+		return err
+	}
+	_, err = s.FormResponse.GetResponseSettings(ctx, 4242)
+	if err := expectNoRows("GetResponseSettings of a missing form", err); err != nil {
+		return err
+	}
+
+	responseFields, err := s.FormResponse.UpdateResponseFields(ctx, formResponse.Id, answers(field.Id, "better", other.Id, "more"))
+	if err != nil {
+		return err
+	}
+	if len(responseFields) != 2 || responseFields[0].FieldValue != "better" || responseFields[1].FieldValue != "more" {
+		return fmt.Errorf("UpdateResponseFields returned %+v", responseFields)
+	}
+	edited, err := s.FormResponse.GetFormResponseById(ctx, formResponse.Id)
+	if err != nil {
+		return err
+	}
+	if edited.UpdatedAt == nil {
+		return errors.New("an edited response has no updated_at")
+	}
+
+	// an edit that changes nothing isn't kept, a removed answer is
+	if _, err := s.FormResponse.UpdateResponseFields(ctx, formResponse.Id, answers(field.Id, "better", other.Id, "more")); err != nil {
+		return err
+	}
+	if _, err := s.FormResponse.UpdateResponseFields(ctx, formResponse.Id, answers(field.Id, "better")); err != nil {
+		return err
+	}
+	_, err = s.FormResponse.UpdateResponseFields(ctx, formResponse.Id, answers(field.Id, "worse", 4242, "lost"))
+	if err := expectError("an edit answering a missing field", err, storage.ErrValidation); err != nil {
+		return err
+	}
+	edits, err := s.FormResponse.GetResponseFieldEdits(ctx, formResponse.Id)
+	if err != nil {
+		return err
+	}
+	if len(edits) != 2 || edits[0].FieldValue != "great" || edits[0].FormFieldId != field.Id || edits[1].FieldValue != "more" || edits[1].FormFieldId != other.Id {
+		return fmt.Errorf("GetResponseFieldEdits returned %+v, want great then more", edits)
+	}
+	responseFields, err = s.FormResponse.GetResponseFieldsByFormResponseId(ctx, formResponse.Id)
+	if err != nil {
+		return err
+	}
+	if len(responseFields) != 1 || responseFields[0].FieldValue != "better" {
+		return fmt.Errorf("answers after the edits are %+v, want only better", responseFields)
+	}
+	_, err = s.FormResponse.UpdateResponseFields(ctx, 4242, answers(field.Id, "nobody"))
+	if err := expectNoRows("UpdateResponseFields of a missing response", err); err != nil {
+		return err
+	}
+
+	if err := s.FormResponse.DeleteFormResponseById(ctx, formResponse.Id); err != nil {
+		return err
+	}
+	_, err = s.FormResponse.GetFormResponseById(ctx, formResponse.Id)
+	if err := expectNoRows("GetFormResponseById of a withdrawn response", err); err != nil {
+		return err
+	}
+	edits, err = s.FormResponse.GetResponseFieldEdits(ctx, formResponse.Id)
+	if err != nil {
+		return err
+	}
+	if len(edits) != 0 {
+		return fmt.Errorf("%d edits survived their response", len(edits))
+	}
+	err = s.FormResponse.DeleteFormResponseById(ctx, formResponse.Id)
+	if err := expectError("withdrawing a response twice", err, storage.ErrNotFound); err != nil {
+		return err
+	}
+	return nil
+}
+
+func checkResponseDrafts(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	carol, err := createUser(ctx, s, "carol")
+	if err != nil {
+		return err
+	}
+	form, field, _, err := seedForm(ctx, s, alice.Id, bob.Id)
+	if err != nil {
+		return err
+	}
+	other, err := s.FormFields.CreateFormField(ctx, form.Id, textField("anything else", false))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ResponseDrafts.GetResponseDraft(ctx, form.Id, carol.Id)
+	if err := expectNoRows("GetResponseDraft before saving one", err); err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(time.Hour)
+	draft, err := s.ResponseDrafts.SaveResponseDraft(ctx, form.Id, carol.Id, []storage.DraftAnswer{{FormFieldId: other.Id, FieldValue: "half"}}, &expiresAt)
+	if err != nil {
+		return err
+	}
+	if len(draft.ResponseFields) != 1 || draft.ResponseFields[0].FieldValue != "half" || draft.ExpiresAt == nil {
+		return fmt.Errorf("SaveResponseDraft returned %+v", draft)
+	}
+	// saving again replaces the answers of the same draft
+	saved, err := s.ResponseDrafts.SaveResponseDraft(ctx, form.Id, carol.Id, []storage.DraftAnswer{{FormFieldId: field.Id, FieldValue: "fine"}, {FormFieldId: other.Id, FieldValue: "done"}}, nil)
+	if err != nil {
+		return err
+	}
+	if saved.Id != draft.Id || len(saved.ResponseFields) != 2 || saved.ExpiresAt != nil {
+		return fmt.Errorf("second SaveResponseDraft returned %+v, want draft %d with 2 answers and no expiry", saved, draft.Id)
+	}
+	if _, err := s.ResponseDrafts.SaveResponseDraft(ctx, 4242, carol.Id, nil, nil); err == nil {
+		return errors.New("SaveResponseDraft saved a draft of a missing form")
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	if _, err := s.ResponseDrafts.SaveResponseDraft(ctx, form.Id, bob.Id, nil, &expired); err != nil {
+		return err
+	}
+	_, err = s.ResponseDrafts.GetResponseDraft(ctx, form.Id, bob.Id)
+	if err := expectNoRows("GetResponseDraft of an expired draft", err); err != nil {
+		return err
+	}
+	drafts, err := s.ResponseDrafts.GetResponseDraftsByRespondentId(ctx, bob.Id)
+	if err != nil {
+		return err
+	}
+	if len(drafts) != 0 {
+		return fmt.Errorf("GetResponseDraftsByRespondentId listed %d expired drafts", len(drafts))
+	}
+	purged, err := s.ResponseDrafts.PurgeExpiredResponseDrafts(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if purged != 1 {
+		return fmt.Errorf("PurgeExpiredResponseDrafts purged %d drafts, want 1", purged)
+	}
+	drafts, err = s.ResponseDrafts.GetResponseDraftsByRespondentId(ctx, carol.Id)
+	if err != nil {
+		return err
+	}
+	if len(drafts) != 1 || drafts[0].Id != draft.Id {
+		return fmt.Errorf("GetResponseDraftsByRespondentId returned %+v, want carol's draft", drafts)
+	}
+
+	// a draft that doesn't submit is left as it was
+	_, _, err = s.ResponseDrafts.SubmitResponseDraft(ctx, form.Id, carol.Id, nil, answers(field.Id, "fine", 4242, "lost"))
+	if err := expectError("submitting an answer to a missing field", err, storage.ErrValidation); err != nil {
+		return err
+	}
+	if _, err := s.ResponseDrafts.GetResponseDraft(ctx, form.Id, carol.Id); err != nil {
+		return fmt.Errorf("the draft is gone after a failed submit: %w", err)
+	}
+
+	formResponse, responseFields, err := s.ResponseDrafts.SubmitResponseDraft(ctx, form.Id, carol.Id, nil, answers(field.Id, "fine", other.Id, "done"))
+	if err != nil {
+		return err
+	}
+	if formResponse.RespondentId != carol.Id || len(responseFields) != 2 || responseFields[0].FormResponseId != formResponse.Id {
+		return fmt.Errorf("SubmitResponseDraft returned %+v with %+v", formResponse, responseFields)
+	}
+	_, err = s.ResponseDrafts.GetResponseDraft(ctx, form.Id, carol.Id)
+	if err := expectNoRows("GetResponseDraft of a submitted draft", err); err != nil {
+		return err
+	}
+	_, _, err = s.ResponseDrafts.SubmitResponseDraft(ctx, form.Id, carol.Id, nil, answers(field.Id, "again"))
+	if err := expectError("submitting a draft twice", err, storage.ErrNotFound); err != nil {
+		return err
+	}
+	byRespondent, err := s.FormResponse.GetFormResponsesByRespondentId(ctx, carol.Id)
+	if err != nil {
+		return err
+	}
+	if len(byRespondent) != 1 {
+		return fmt.Errorf("carol has %d responses after submitting twice, want 1", len(byRespondent))
+	}
+
+	if _, err := s.ResponseDrafts.SaveResponseDraft(ctx, form.Id, carol.Id, nil, nil); err != nil {
+		return err
+	}
+	if err := s.ResponseDrafts.DeleteResponseDraft(ctx, form.Id, carol.Id); err != nil {
+		return err
+	}
+	err = s.ResponseDrafts.DeleteResponseDraft(ctx, form.Id, carol.Id)
+	if err := expectError("discarding a draft twice", err, storage.ErrNotFound); err != nil {
+		return err
+	}
+	return nil
+}
+
+func checkResponseJoins(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	form, field, formResponse, err := seedForm(ctx, s, alice.Id, bob.Id)
+	if err != nil {
+		return err
+	}
+
+	byForm, err := s.FormResponse.GetFormResponsesByFormId(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if len(byForm) != 1 || byForm[0].Id != formResponse.Id {
+		return fmt.Errorf("GetFormResponsesByFormId returned %d responses, want 1", len(byForm))
+	}
+	if byForm[0].Respondent == nil || byForm[0].Respondent.Id != bob.Id || byForm[0].Form == nil || byForm[0].Form.Id != form.Id {
+		return errors.New("response came back without its respondent or form")
+	}
+
+	byRespondent, err := s.FormResponse.GetFormResponsesByRespondentId(ctx, bob.Id)
+	if err != nil {
+		return err
+	}
+	if len(byRespondent) != 1 || byRespondent[0].Id != formResponse.Id {
+		return fmt.Errorf("GetFormResponsesByRespondentId returned %d responses, want 1", len(byRespondent))
+	}
+
+	responseFields, err := s.FormResponse.GetResponseFieldsByFormResponseId(ctx, formResponse.Id)
+	if err != nil {
+		return err
+	}
+	if len(responseFields) != 1 || responseFields[0].FieldValue != "great" || responseFields[0].FormField.Id != field.Id {
+		return fmt.Errorf("GetResponseFieldsByFormResponseId returned %+v", responseFields)
+	}
+	return nil
+}
+
+func checkCollaboratorUpsert(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	form, err := s.Forms.CreateForm(ctx, "feedback", "", nil, alice.Id, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.FormCollaborators.AddFormCollaborator(ctx, form.Id, bob.Id, "janitor"); err == nil {
+		return errors.New("AddFormCollaborator accepted an unknown role")
+	}
+	if _, err := s.FormCollaborators.AddFormCollaborator(ctx, form.Id, bob.Id, storage.CollaboratorViewer); err != nil {
+		return err
+	}
+	if _, err := s.FormCollaborators.AddFormCollaborator(ctx, form.Id, bob.Id, storage.CollaboratorEditor); err != nil {
+		return err
+	}
+	collaborators, err := s.FormCollaborators.GetFormCollaboratorsByFormId(ctx, form.Id)
+	if err != nil {
+		return err
+	}
+	if len(collaborators) != 1 || collaborators[0].Role != storage.CollaboratorEditor {
+		return fmt.Errorf("adding a collaborator twice left %d rows, want 1 editor", len(collaborators))
+	}
+	if collaborators[0].User == nil || collaborators[0].User.Id != bob.Id {
+		return errors.New("collaborator came back without its user")
+	}
+
+	if err := s.FormCollaborators.DeleteFormCollaborator(ctx, form.Id, bob.Id); err != nil {
+		return err
+	}
+	if err := s.FormCollaborators.DeleteFormCollaborator(ctx, form.Id, bob.Id); err == nil {
+		return errors.New("removing a collaborator twice did not fail")
+	}
+	return nil
+}
+
+func checkWorkspaceVisibility(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	workspace, err := s.Workspaces.CreateWorkspace(ctx, "team", alice.Id)
+	if err != nil {
+		return err
+	}
+	owner, err := s.Workspaces.GetWorkspaceMember(ctx, workspace.Id, alice.Id)
+	if err != nil {
+		return fmt.Errorf("creator is not a member: %w", err)
+	}
+	if owner.Role != storage.WorkspaceRoleOwner {
+		return fmt.Errorf("creator has role %q, want owner", owner.Role)
+	}
+
+	if _, err := s.Forms.CreateForm(ctx, "public", "", nil, alice.Id, nil); err != nil {
+		return err
+	}
+	if _, err := s.Forms.CreateForm(ctx, "internal", "", nil, alice.Id, &workspace.Id); err != nil {
+		return err
+	}
+
+	forms, err := s.Forms.GetAllForms(ctx, bob.Id)
+	if err != nil {
+		return err
+	}
+	if len(forms) != 1 || forms[0].FormTitle != "public" {
+		return fmt.Errorf("an outsider sees %d forms, want only the public one", len(forms))
+	}
+
+	if _, err := s.Workspaces.AddWorkspaceMember(ctx, workspace.Id, bob.Id, storage.WorkspaceRoleMember); err != nil {
+		return err
+	}
+	if forms, err = s.Forms.GetAllForms(ctx, bob.Id); err != nil {
+		return err
+	}
+	if len(forms) != 2 {
+		return fmt.Errorf("a member sees %d forms, want 2", len(forms))
+	}
+	workspaceForms, err := s.Forms.GetFormsByWorkspaceId(ctx, workspace.Id)
+	if err != nil {
+		return err
+	}
+	if len(workspaceForms) != 1 || workspaceForms[0].FormTitle != "internal" {
+		return fmt.Errorf("GetFormsByWorkspaceId returned %d forms, want 1", len(workspaceForms))
+	}
+
+	owners, err := s.Workspaces.CountWorkspaceOwners(ctx, workspace.Id)
+	if err != nil {
+		return err
+	}
+	if owners != 1 {
+		return fmt.Errorf("workspace has %d owners, want 1", owners)
+	}
+	memberships, err := s.Workspaces.GetWorkspacesByUserId(ctx, bob.Id)
+	if err != nil {
+		return err
+	}
+	if len(memberships) != 1 || memberships[0].Role != storage.WorkspaceRoleMember {
+		return fmt.Errorf("GetWorkspacesByUserId returned %+v", memberships)
+	}
+	return nil
+}
+
+func checkWorkspaceHandOver(ctx context.Context, s *storage.Storage) error {
+	alice, err := createUser(ctx, s, "alice")
+	if err != nil {
+		return err
+	}
+	bob, err := createUser(ctx, s, "bob")
+	if err != nil {
+		return err
+	}
+	workspace, err := s.Workspaces.CreateWorkspace(ctx, "team", alice.Id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.Workspaces.AddWorkspaceMember(ctx, workspace.Id, bob.Id, storage.WorkspaceRoleAdmin); err != nil {
+		return err
+	}
+	form, err := s.Forms.CreateForm(ctx, "internal", "", nil, alice.Id, &workspace.Id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Users.DeleteUserById(ctx, alice.Id); err != nil {
+		return err
+	}
+	kept, err := s.Forms.GetFormById(ctx, form.Id)
+	if err != nil {
+		return fmt.Errorf("workspace form was deleted with its author: %w", err)
+	}
+	if kept.UserId != bob.Id {
+		return fmt.Errorf("workspace form belongs to user %d, want %d", kept.UserId, bob.Id)
+	}
+	_, err = s.Workspaces.GetWorkspaceMember(ctx, workspace.Id, alice.Id)
+	return expectNoRows("membership of a deleted user", err)
+}
@@ -1,59 +1,84 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
 )
 
 type User struct {
-	Id        int     `json:"id"`
-	Email     string  `json:"email"`
-	Username  string  `json:"username"`
-	Password  string  `json:"-"`
-	CreatedAt string  `json:"created_at"`
-	UpdatedAt *string `json:"updated_at"`
+	Id         int     `json:"id"`
+	Email      string  `json:"email"`
+	Username   string  `json:"username"`
+	Password   string  `json:"-"`
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  *string `json:"updated_at"`
+	Role       string  `json:"role"`
+	DisabledAt *string `json:"disabled_at"`
+	// TokenVersion goes up with every password change, tokens issued for an older version are revoked
+	TokenVersion int `json:"-"`
 }
 
 type UserStore struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
 }
 
-func (s *UserStore) GetUserById(userId int) (*User, error) {
+func (s *UserStore) GetUserById(ctx context.Context, userId int) (*User, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
 	var user User
-	query := `SELECT id,email,username,password,created_at,updated_at FROM users WHERE id=$1`
-	row := s.db.QueryRow(query, userId)
-	if err := row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt); err != nil {
-		return nil, err
+	query := `SELECT id,email,username,password,created_at,updated_at,role,disabled_at,token_version FROM users WHERE id=$1`
+	row := s.db.QueryRowContext(ctx, query, userId)
+	if err := row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+		return nil, dbError(err)
 	}
 	return &user, nil
 }
 
-func (s *UserStore) GetUserByEmail(email string) (*User, error) {
+func (s *UserStore) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
 	var user User
-	query := `SELECT id,email,username,password,created_at,updated_at FROM users WHERE email=$1`
-	row := s.db.QueryRow(query, email)
-	if err := row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt); err != nil {
-		return nil, err
+	query := `SELECT id,email,username,password,created_at,updated_at,role,disabled_at,token_version FROM users WHERE email=$1`
+	row := s.db.QueryRowContext(ctx, query, email)
+	if err := row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+		return nil, dbError(err)
 	}
 	return &user, nil
 }
 
-func (s *UserStore) GetUserByUsername(username string) (*User, error) {
+func (s *UserStore) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
 	var user User
-	query := `SELECT id,email,username,password,created_at,updated_at FROM users WHERE username=$1`
-	row := s.db.QueryRow(query, username)
-	if err := row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt); err != nil {
-		return nil, err
+	query := `SELECT id,email,username,password,created_at,updated_at,role,disabled_at,token_version FROM users WHERE username=$1`
+	row := s.db.QueryRowContext(ctx, query, username)
+	if err := row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+		return nil, dbError(err)
 	}
 	return &user, nil
 }
 
-func (s *UserStore) GetUsersByUsernameOrEmail(username string, email string) ([]User, error) {
+func (s *UserStore) GetUsersByUsernameOrEmail(ctx context.Context, username string, email string) ([]User, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
 	var users []User
-	query := `SELECT id,email,username,password,created_at,updated_at FROM users WHERE email=$1 OR username=$2`
-	rows, err := s.db.Query(query, email, username)
+	query := `SELECT id,email,username,password,created_at,updated_at,role,disabled_at,token_version FROM users WHERE email=$1 OR username=$2`
+	rows, err := s.db.QueryContext(ctx, query, email, username)
 	if err != nil {
-		return []User{}, err
+		return []User{}, dbError(err)
 	}
 
 	defer rows.Close()
@@ -61,8 +86,8 @@ func (s *UserStore) GetUsersByUsernameOrEmail(username string, email string) ([]
 	for rows.Next() {
 		var user User
 
-		if err := rows.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt); err != nil {
-			return []User{}, err
+		if err := rows.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+			return []User{}, dbError(err)
 		}
 
 		users = append(users, user)
@@ -72,8 +97,11 @@ func (s *UserStore) GetUsersByUsernameOrEmail(username string, email string) ([]
 
 }
 
-func (s *UserStore) CreateUser(username string, email string, hashedPassword string) (*User, error) {
-	tx, err := s.db.Begin()
+func (s *UserStore) CreateUser(ctx context.Context, username string, email string, hashedPassword string) (*User, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("transaction failed to start :- %v", err.Error())
 	}
@@ -86,11 +114,11 @@ func (s *UserStore) CreateUser(username string, email string, hashedPassword str
 
 	var user User
 	query := `INSERT INTO users(email,username,password) VALUES($1,$2,$3) RETURNING
-	id,email,username,password,created_at,updated_at`
+	id,email,username,password,created_at,updated_at,role,disabled_at,token_version`
 
-	row := tx.QueryRow(query, email, username, hashedPassword)
-	if err := row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt); err != nil {
-		return nil, err
+	row := tx.QueryRowContext(ctx, query, email, username, hashedPassword)
+	if err := row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+		return nil, dbError(err)
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -99,3 +127,211 @@ func (s *UserStore) CreateUser(username string, email string, hashedPassword str
 
 	return &user, nil
 }
+
+func (s *UserStore) UpdateUserProfile(ctx context.Context, userId int, username string, email string) (*User, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var user User
+	query := `UPDATE users SET username=$1,email=$2,updated_at=CURRENT_TIMESTAMP WHERE id=$3 RETURNING
+	id,email,username,password,created_at,updated_at,role,disabled_at,token_version`
+	row := s.db.QueryRowContext(ctx, query, username, email, userId)
+	if err := row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+		return nil, dbError(err)
+	}
+	return &user, nil
+}
+
+// UpdateUserPassword stores the new hash and bumps the token version, signing out every session of the user
+func (s *UserStore) UpdateUserPassword(ctx context.Context, userId int, hashedPassword string) error {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET password=$1,token_version=token_version+1,updated_at=CURRENT_TIMESTAMP WHERE id=$2`
+	result, err := s.db.ExecContext(ctx, query, hashedPassword, userId)
+	if err != nil {
+		return dbError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if rowsAffected < 1 {
+		return dbError(sql.ErrNoRows)
+	}
+	return nil
+}
+
+// handOverWorkspaceFormsQuery gives the workspace forms authored by $1 to the longest standing
+// owner (or failing that admin, then member) of the same workspace, so leaving the team doesn't take them along
+const handOverWorkspaceFormsQuery = `UPDATE forms AS f SET user_id = (
+		SELECT wm.user_id FROM workspace_members AS wm
+		WHERE wm.workspace_id = f.workspace_id AND wm.user_id <> $1
+		ORDER BY CASE wm.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, wm.created_at
+		LIMIT 1
+	)
+	WHERE f.user_id = $1 AND f.workspace_id IS NOT NULL AND EXISTS (
+		SELECT 1 FROM workspace_members AS wm WHERE wm.workspace_id = f.workspace_id AND wm.user_id <> $1
+	)`
+
+// DeleteUserById deletes the user, their workspace forms are handed over to the workspace
+// and their personal forms (and everything under them) go with them through the cascades
+func (s *UserStore) DeleteUserById(ctx context.Context, userId int) error {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("transaction failed to start :- %v", err.Error())
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, handOverWorkspaceFormsQuery, userId); err != nil {
+		return dbError(err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id=$1`, userId)
+	if err != nil {
+		return dbError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if rowsAffected < 1 {
+		err = fmt.Errorf("%w: user with id %d not deleted", ErrNotFound, userId)
+		return dbError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("transaction failed to commit:- %v", err.Error())
+	}
+	return nil
+}
+
+// DeleteUserAndTransferForms hands the forms authored by userId over to newOwnerId before deleting the user,
+// both in one transaction so the forms are never left without an owner. workspace forms stay with the workspace.
+// the new owner has to be another account that isn't disabled
+func (s *UserStore) DeleteUserAndTransferForms(ctx context.Context, userId int, newOwnerId int) error {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	if newOwnerId == userId {
+		return fmt.Errorf("%w: forms can't be transferred to the account being deleted", ErrValidation)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("transaction failed to start :- %v", err.Error())
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var newOwnerDisabledAt *string
+	if err = tx.QueryRowContext(ctx, `SELECT disabled_at FROM users WHERE id=$1`, newOwnerId).Scan(&newOwnerDisabledAt); err != nil {
+		return dbError(err)
+	}
+	if newOwnerDisabledAt != nil {
+		err = fmt.Errorf("%w: user %d is disabled and can't take over forms", ErrValidation, newOwnerId)
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, handOverWorkspaceFormsQuery, userId); err != nil {
+		return dbError(err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE forms SET user_id=$1 WHERE user_id=$2`, newOwnerId, userId); err != nil {
+		return dbError(err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id=$1`, userId)
+	if err != nil {
+		return dbError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if rowsAffected < 1 {
+		err = fmt.Errorf("%w: user with id %d not deleted", ErrNotFound, userId)
+		return dbError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("transaction failed to commit:- %v", err.Error())
+	}
+	return nil
+}
+
+// SearchUsers matches query against email and username, an empty query lists everybody
+func (s *UserStore) SearchUsers(ctx context.Context, query string, limit int, offset int) ([]User, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var users []User
+	sqlQuery := `SELECT id,email,username,password,created_at,updated_at,role,disabled_at,token_version FROM users
+	WHERE $1='' OR LOWER(email) LIKE LOWER($4) ESCAPE '\' OR LOWER(username) LIKE LOWER($4) ESCAPE '\'
+	ORDER BY id LIMIT $2 OFFSET $3`
+	rows, err := s.db.QueryContext(ctx, sqlQuery, query, limit, offset, containsPattern(query))
+	if err != nil {
+		return []User{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+			return []User{}, dbError(err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return []User{}, dbError(err)
+	}
+
+	return users, nil
+}
+
+// containsPattern is a LIKE pattern (with \ as the escape character) matching text that contains query,
+// % and _ in the query match themselves instead of standing for any characters
+func containsPattern(query string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query)
+	return "%" + escaped + "%"
+}
+
+func (s *UserStore) SetUserDisabled(ctx context.Context, userId int, disabled bool) (*User, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var user User
+	query := `UPDATE users SET disabled_at=CASE WHEN $1 THEN COALESCE(disabled_at,CURRENT_TIMESTAMP) ELSE NULL END,updated_at=CURRENT_TIMESTAMP
+	WHERE id=$2 RETURNING id,email,username,password,created_at,updated_at,role,disabled_at,token_version`
+	row := s.db.QueryRowContext(ctx, query, disabled, userId)
+	if err := row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+		return nil, dbError(err)
+	}
+	return &user, nil
+}
+
+func (s *UserStore) SetUserRole(ctx context.Context, userId int, role string) (*User, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var user User
+	query := `UPDATE users SET role=$1,updated_at=CURRENT_TIMESTAMP WHERE id=$2
+	RETURNING id,email,username,password,created_at,updated_at,role,disabled_at,token_version`
+	row := s.db.QueryRowContext(ctx, query, role, userId)
+	if err := row.Scan(&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+		return nil, dbError(err)
+	}
+	return &user, nil
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	WorkspaceRoleMember = "member"
+	WorkspaceRoleAdmin  = "admin"
+	WorkspaceRoleOwner  = "owner"
+)
+
+type Workspace struct {
+	Id            int               `json:"id"`
+	WorkspaceName string            `json:"workspace_name"`
+	CreatedBy     *int              `json:"created_by"`
+	CreatedAt     string            `json:"created_at"`
+	Members       []WorkspaceMember `json:"members,omitempty"`
+}
+
+type WorkspaceMembership struct {
+	Workspace
+	Role string `json:"role"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceId int    `json:"workspace_id"`
+	UserId      int    `json:"user_id"`
+	Role        string `json:"role"`
+	CreatedAt   string `json:"created_at"`
+	User        *User  `json:"user"`
+}
+
+type WorkspaceStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// CreateWorkspace creates the workspace with userId as its first owner
+func (s *WorkspaceStore) CreateWorkspace(ctx context.Context, workspaceName string, userId int) (*Workspace, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %v", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var workspace Workspace
+	query := `INSERT INTO workspaces(workspace_name,created_by) VALUES($1,$2) RETURNING id,workspace_name,created_by,created_at`
+	row := tx.QueryRowContext(ctx, query, workspaceName, userId)
+	if err = row.Scan(&workspace.Id, &workspace.WorkspaceName, &workspace.CreatedBy, &workspace.CreatedAt); err != nil {
+		return nil, dbError(err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `INSERT INTO workspace_members(workspace_id,user_id,role) VALUES($1,$2,$3)`, workspace.Id, userId, WorkspaceRoleOwner); err != nil {
+		return nil, dbError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return &workspace, nil
+}
+
+func (s *WorkspaceStore) GetWorkspaceById(ctx context.Context, workspaceId int) (*Workspace, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var workspace Workspace
+	query := `SELECT id,workspace_name,created_by,created_at FROM workspaces WHERE id=$1`
+	row := s.db.QueryRowContext(ctx, query, workspaceId)
+	if err := row.Scan(&workspace.Id, &workspace.WorkspaceName, &workspace.CreatedBy, &workspace.CreatedAt); err != nil {
+		return nil, dbError(err)
+	}
+	return &workspace, nil
+}
+
+// GetWorkspacesByUserId lists the workspaces userId is a member of along with their role in each
+func (s *WorkspaceStore) GetWorkspacesByUserId(ctx context.Context, userId int) ([]WorkspaceMembership, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var workspaces []WorkspaceMembership
+	query := `SELECT w.id,w.workspace_name,w.created_by,w.created_at,wm.role
+	FROM workspaces AS w INNER JOIN workspace_members AS wm ON w.id=wm.workspace_id
+	WHERE wm.user_id=$1 ORDER BY w.id`
+	rows, err := s.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return []WorkspaceMembership{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var workspace WorkspaceMembership
+		if err := rows.Scan(&workspace.Id, &workspace.WorkspaceName, &workspace.CreatedBy, &workspace.CreatedAt, &workspace.Role); err != nil {
+			return []WorkspaceMembership{}, dbError(err)
+		}
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, nil
+}
+
+func (s *WorkspaceStore) GetWorkspaceMember(ctx context.Context, workspaceId int, userId int) (*WorkspaceMember, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var member WorkspaceMember
+	query := `SELECT workspace_id,user_id,role,created_at FROM workspace_members WHERE workspace_id=$1 AND user_id=$2`
+	row := s.db.QueryRowContext(ctx, query, workspaceId, userId)
+	if err := row.Scan(&member.WorkspaceId, &member.UserId, &member.Role, &member.CreatedAt); err != nil {
+		return nil, dbError(err)
+	}
+	return &member, nil
+}
+
+func (s *WorkspaceStore) GetWorkspaceMembers(ctx context.Context, workspaceId int) ([]WorkspaceMember, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var members []WorkspaceMember
+	query := `SELECT wm.workspace_id,wm.user_id,wm.role,wm.created_at,
+	u.id,u.email,u.username,u.password,u.created_at,u.updated_at,u.role,u.disabled_at,u.token_version
+	FROM workspace_members AS wm INNER JOIN users AS u ON wm.user_id=u.id
+	WHERE wm.workspace_id=$1 ORDER BY wm.created_at`
+	rows, err := s.db.QueryContext(ctx, query, workspaceId)
+	if err != nil {
+		return []WorkspaceMember{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member WorkspaceMember
+		var user User
+		if err := rows.Scan(&member.WorkspaceId, &member.UserId, &member.Role, &member.CreatedAt,
+			&user.Id, &user.Email, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.Role, &user.DisabledAt, &user.TokenVersion); err != nil {
+			return []WorkspaceMember{}, dbError(err)
+		}
+		member.User = &user
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// AddWorkspaceMember adds userId to the workspace, or changes their role if they're already a member
+func (s *WorkspaceStore) AddWorkspaceMember(ctx context.Context, workspaceId int, userId int, role string) (*WorkspaceMember, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var member WorkspaceMember
+	query := `INSERT INTO workspace_members(workspace_id,user_id,role) VALUES($1,$2,$3)
+	ON CONFLICT(workspace_id,user_id) DO UPDATE SET role=EXCLUDED.role
+	RETURNING workspace_id,user_id,role,created_at`
+	row := s.db.QueryRowContext(ctx, query, workspaceId, userId, role)
+	if err := row.Scan(&member.WorkspaceId, &member.UserId, &member.Role, &member.CreatedAt); err != nil {
+		return nil, dbError(err)
+	}
+	return &member, nil
+}
+
+func (s *WorkspaceStore) DeleteWorkspaceMember(ctx context.Context, workspaceId int, userId int) error {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM workspace_members WHERE workspace_id=$1 AND user_id=$2`
+	result, err := s.db.ExecContext(ctx, query, workspaceId, userId)
+	if err != nil {
+		return dbError(err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if rowsAffected < 1 {
+		return fmt.Errorf("%w: member with id %d not removed from workspace %d", ErrNotFound, userId, workspaceId)
+	}
+	return nil
+}
+
+func (s *WorkspaceStore) CountWorkspaceOwners(ctx context.Context, workspaceId int) (int, error) {
+	ctx, cancel := withTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var count int
+	query := `SELECT COUNT(*) FROM workspace_members WHERE workspace_id=$1 AND role=$2`
+	if err := s.db.QueryRowContext(ctx, query, workspaceId, WorkspaceRoleOwner).Scan(&count); err != nil {
+		return 0, dbError(err)
+	}
+	return count, nil
+}